@@ -0,0 +1,130 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kiagnose/kiagnose/kiagnose/types"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg"
+)
+
+const analyzeSubcommand = "analyze"
+
+// analyze re-analyzes a saved oslat transcript and prints the status keys the checkup would have reported.
+// It returns an error when the input is invalid or when the verdict is a failure.
+func analyze(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet(analyzeSubcommand, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: %s %s [flags] <oslat-transcript-file>\n", os.Args[0], analyzeSubcommand)
+		flags.PrintDefaults()
+	}
+	configMapPath := flags.String("config", "",
+		"Path to a checkup ConfigMap YAML, whose parameters are used for the analysis")
+	latencyThreshold := flags.String("latency-threshold", "",
+		"oslat latency threshold in microseconds. Overrides the ConfigMap's "+pkg.OslatLatencyThresholdParamName)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return fmt.Errorf("expected a single oslat transcript file, got %d arguments", flags.NArg())
+	}
+
+	params := map[string]string{}
+	if *configMapPath != "" {
+		var err error
+		if params, err = readConfigMapParams(*configMapPath); err != nil {
+			return err
+		}
+	}
+	if *latencyThreshold != "" {
+		params[pkg.OslatLatencyThresholdParamName] = *latencyThreshold
+	}
+
+	transcript, err := os.ReadFile(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	statusData, err := pkg.Analyze(string(transcript), params)
+	if err != nil {
+		return err
+	}
+
+	printStatusData(out, statusData)
+
+	if statusData[types.SucceededKey] != "true" {
+		return fmt.Errorf("checkup failed: %s", statusData[types.FailureReasonKey])
+	}
+
+	return nil
+}
+
+func printStatusData(out io.Writer, statusData map[string]string) {
+	keys := make([]string, 0, len(statusData))
+	for key := range statusData {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	const (
+		minWidth = 0
+		tabWidth = 8
+		padding  = 2
+	)
+	w := tabwriter.NewWriter(out, minWidth, tabWidth, padding, ' ', 0)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s\t%s\n", key, statusData[key])
+	}
+	w.Flush()
+}
+
+func readConfigMapParams(path string) (map[string]string, error) {
+	rawConfigMap, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var configMap corev1.ConfigMap
+	if err := yaml.Unmarshal(rawConfigMap, &configMap); err != nil {
+		return nil, fmt.Errorf("failed to parse ConfigMap %q: %w", path, err)
+	}
+
+	params := map[string]string{}
+	for key, value := range configMap.Data {
+		if name, found := strings.CutPrefix(key, types.ParamNameKeyPrefix); found {
+			params[name] = value
+		}
+	}
+
+	return params, nil
+}
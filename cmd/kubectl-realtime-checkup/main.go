@@ -0,0 +1,123 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/plugin"
+)
+
+// params collects repeated `--param name=value` flags.
+type params map[string]string
+
+func (p params) String() string {
+	var pairs []string
+	for name, value := range p {
+		pairs = append(pairs, name+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (p params) Set(rawParam string) error {
+	name, value, found := strings.Cut(rawParam, "=")
+	if !found || name == "" {
+		return fmt.Errorf("param %q is not in the form name=value", rawParam)
+	}
+	p[name] = value
+	return nil
+}
+
+func main() {
+	const errMessagePrefix = "kubectl-realtime-checkup failed"
+
+	checkupParams := params{}
+
+	kubeconfigPath := flag.String("kubeconfig", "", "Path to a kubeconfig file")
+	namespace := flag.String("namespace", "", "Namespace to run the checkup in. Defaults to the kubeconfig context's namespace")
+	name := flag.String("name", plugin.DefaultName, "Name of the checkup Job, also used as a prefix for the rest of the created objects")
+	image := flag.String("image", plugin.DefaultImage, "Checkup image")
+	timeout := flag.Duration("timeout", 30*time.Minute, "Checkup timeout (spec.timeout)")
+	vmImage := flag.String("vm-image", "", "VM under test container disk image (spec.param.vmUnderTestContainerDiskImage)")
+	targetNode := flag.String("target-node", "", "Node to schedule the VM under test on (spec.param.vmUnderTestTargetNodeName)")
+	oslatDuration := flag.String("oslat-duration", "", "oslat duration (spec.param.oslatDuration)")
+	latencyThreshold := flag.String("latency-threshold", "",
+		"oslat latency threshold in microseconds (spec.param.oslatLatencyThresholdMicroSeconds)")
+	flag.Var(checkupParams, "param", "Additional checkup parameter in the form name=value, may be repeated")
+	flag.Parse()
+
+	for paramName, paramValue := range map[string]string{
+		plugin.VMUnderTestContainerDiskImageParamName: *vmImage,
+		plugin.VMUnderTestTargetNodeNameParamName:     *targetNode,
+		plugin.OslatDurationParamName:                 *oslatDuration,
+		plugin.OslatLatencyThresholdParamName:         *latencyThreshold,
+	} {
+		if paramValue != "" {
+			checkupParams[paramName] = paramValue
+		}
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = *kubeconfigPath
+	configOverrides := &clientcmd.ConfigOverrides{}
+	configOverrides.Context.Namespace = *namespace
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+
+	restConfig, err := clientConfig.ClientConfig()
+	if err != nil {
+		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+	}
+
+	checkupNamespace, _, err := clientConfig.Namespace()
+	if err != nil {
+		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer cancel()
+
+	cfg := plugin.Config{
+		Name:      *name,
+		Namespace: checkupNamespace,
+		Image:     *image,
+		Timeout:   *timeout,
+		Params:    checkupParams,
+	}
+	if err := plugin.Run(ctx, client, cfg, os.Stdout); err != nil {
+		cancel()
+		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+	}
+}
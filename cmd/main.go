@@ -20,26 +20,115 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
 
+	"k8s.io/client-go/tools/clientcmd"
+
+	kconfig "github.com/kiagnose/kiagnose/kiagnose/config"
 	"github.com/kiagnose/kiagnose/kiagnose/environment"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == analyzeSubcommand {
+		if err := analyze(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("kubevirt-realtime-checkup %s failed: %v\n", analyzeSubcommand, err)
+		}
+		return
+	}
+
+	kubeconfigPath := flag.String("kubeconfig", "",
+		"Path to a kubeconfig file. When set, the checkup runs out of cluster")
+	namespaceFlag := flag.String("namespace", "",
+		"Namespace to run the checkup in. Defaults to the checkup pod's namespace, or to the kubeconfig's current context one")
+	configMapName := flag.String("configmap", "",
+		"Name of the checkup's ConfigMap. Overrides the "+kconfig.ConfigMapNameEnvVarName+" environment variable")
+	flag.Parse()
+
 	log.Println("kubevirt-realtime-checkup starting...")
 	rawEnv := environment.EnvToMap(os.Environ())
 
 	const errMessagePrefix = "kubevirt-realtime-checkup failed"
 
-	namespace, err := environment.ReadNamespaceFile()
-	if err != nil {
-		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+	namespace := *namespaceFlag
+	if namespace == "" {
+		var err error
+		if namespace, err = defaultNamespace(*kubeconfigPath); err != nil {
+			log.Fatalf("%s: %v\n", errMessagePrefix, err)
+		}
+	}
+
+	if *configMapName != "" {
+		rawEnv[kconfig.ConfigMapNameEnvVarName] = *configMapName
+		if rawEnv[kconfig.ConfigMapNamespaceEnvVarName] == "" {
+			rawEnv[kconfig.ConfigMapNamespaceEnvVarName] = namespace
+		}
+	}
+
+	if *kubeconfigPath != "" {
+		if err := setOutOfClusterEnv(rawEnv); err != nil {
+			log.Fatalf("%s: %v\n", errMessagePrefix, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	go abortOnSignal(cancel)
+
+	if err := pkg.Run(ctx, rawEnv, namespace, *kubeconfigPath); err != nil {
+		log.Printf("%s: %v\n", errMessagePrefix, err)
+		cancel(nil)
+		os.Exit(pkg.ExitCode(err))
 	}
+}
 
-	if err = pkg.Run(rawEnv, namespace); err != nil {
-		log.Fatalf("%s: %v\n", errMessagePrefix, err)
+// defaultNamespace returns the namespace of the kubeconfig's current context when running out of cluster,
+// and the checkup pod's namespace otherwise.
+func defaultNamespace(kubeconfigPath string) (string, error) {
+	if kubeconfigPath == "" {
+		return environment.ReadNamespaceFile()
 	}
+
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath}, &clientcmd.ConfigOverrides{})
+	namespace, _, err := clientConfig.Namespace()
+	return namespace, err
+}
+
+// setOutOfClusterEnv adjusts the environment for running without a checkup pod.
+// The pod UID is dropped, so the objects the checkup creates are not owned by a non-existing pod.
+func setOutOfClusterEnv(rawEnv map[string]string) error {
+	log.Println("Running out of cluster, created objects will not have an owner reference")
+	delete(rawEnv, kconfig.PodUIDEnvVarName)
+
+	if rawEnv[kconfig.PodNameEnvVarName] != "" {
+		return nil
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	rawEnv[kconfig.PodNameEnvVarName] = hostname
+
+	return nil
+}
+
+// abortOnSignal cancels the checkup's context once SIGTERM or SIGINT is received,
+// so the checkup could tear down its resources and report before being killed.
+func abortOnSignal(cancel context.CancelCauseFunc) {
+	signalCh := make(chan os.Signal, 1)
+	signal.Notify(signalCh, syscall.SIGTERM, syscall.SIGINT)
+
+	sig := <-signalCh
+	log.Printf("Received signal %q, aborting...", sig)
+	cancel(fmt.Errorf("%w: %s", pkg.ErrAbortedBySignal, sig))
 }
@@ -14,6 +14,7 @@ require (
 	k8s.io/client-go v12.0.0+incompatible
 	kubevirt.io/api v0.0.0-20230706190111-5527663af491
 	kubevirt.io/client-go v1.0.0
+	sigs.k8s.io/yaml v1.3.0
 )
 
 require (
@@ -76,7 +77,6 @@ require (
 	kubevirt.io/controller-lifecycle-operator-sdk/api v0.0.0-20220329064328-f3cc58c6ed90 // indirect
 	sigs.k8s.io/json v0.0.0-20221116044647-bc3834ca7abd // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.2.3 // indirect
-	sigs.k8s.io/yaml v1.3.0 // indirect
 )
 
 // Pinned to kubernetes-0.26.3
@@ -0,0 +1,55 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package pkg
+
+import (
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/reporter"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+// OslatLatencyThresholdParamName is the checkup parameter holding the latency threshold the verdict is based on.
+const OslatLatencyThresholdParamName = config.OslatLatencyThresholdParamName
+
+// Analyze reproduces the checkup verdict out of a saved oslat transcript, using the given checkup parameters.
+// It returns the status keys the checkup would have reported, with no cluster involved.
+func Analyze(oslatTranscript string, params map[string]string) (map[string]string, error) {
+	cfg, err := config.NewForAnalysis(params)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := executor.OslatResults(oslatTranscript)
+	if err != nil {
+		return nil, err
+	}
+
+	analysisStatus := status.Status{}
+	analysisStatus.Results = results
+	if err := checkup.Evaluate(analysisStatus.Results, cfg); err != nil {
+		analysisStatus.FailureReason = append(analysisStatus.FailureReason, err.Error())
+		analysisStatus.FailureCode = string(failure.CodeOf(err))
+	}
+
+	return reporter.CompletedStatusData(analysisStatus), nil
+}
@@ -0,0 +1,111 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package pkg_test
+
+import (
+	"os"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kiagnose/kiagnose/types"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/client/fake"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/reporter"
+)
+
+const testTranscriptPath = "testdata/oslat-transcript.log"
+
+func TestAnalyzeShouldReproduceTheVerdict(t *testing.T) {
+	transcript, err := os.ReadFile(testTranscriptPath)
+	assert.NoError(t, err)
+
+	t.Run("when the max latency is within the threshold", func(t *testing.T) {
+		statusData, err := pkg.Analyze(string(transcript), map[string]string{config.OslatLatencyThresholdParamName: "40"})
+		assert.NoError(t, err)
+
+		expectedStatusData := map[string]string{
+			"status.succeeded":                                  "true",
+			"status.failureReason":                              "",
+			"status.failureCode":                                "",
+			"status.result.vmUnderTestActualNodeName":           "",
+			"status.result.oslatMaxLatencyMicroSeconds":         "34",
+			"status.result.oslatCoreMaxLatenciesMicroSeconds":   "2:21,3:34",
+			"status.result.oslatLatencyPercentilesMicroSeconds": "99.99:2,99.999:9,99.9999:11",
+		}
+		assert.Equal(t, expectedStatusData, statusData)
+	})
+
+	t.Run("when the max latency exceeds the threshold", func(t *testing.T) {
+		statusData, err := pkg.Analyze(string(transcript), map[string]string{config.OslatLatencyThresholdParamName: "30"})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "false", statusData["status.succeeded"])
+		assert.Equal(t, "oslat Max Latency measured 34µs exceeded the given threshold 30µs", statusData["status.failureReason"])
+		assert.Equal(t, "LatencyThresholdExceeded", statusData["status.failureCode"])
+		assert.Equal(t, "34", statusData["status.result.oslatMaxLatencyMicroSeconds"])
+	})
+
+	t.Run("when using the default threshold", func(t *testing.T) {
+		statusData, err := pkg.Analyze(string(transcript), map[string]string{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, "true", statusData["status.succeeded"])
+	})
+}
+
+func TestAnalyzeShouldReportTheSameOslatResultsAsTheCheckup(t *testing.T) {
+	transcript, err := os.ReadFile(testTranscriptPath)
+	assert.NoError(t, err)
+
+	kubeVirtClient := fake.NewClient(fake.WithLoggedInUser(), fake.WithCommandOutput(fake.OslatCommandPrefix, string(transcript), 0))
+	configMapClient := newConfigMapClient("", map[string]string{})
+	assert.NoError(t, runCheckup(t, kubeVirtClient, configMapClient, false))
+	checkupStatusData := userConfigMapData(t, configMapClient)
+
+	analysisStatusData, err := pkg.Analyze(string(transcript), map[string]string{})
+	assert.NoError(t, err)
+
+	for _, key := range []string{
+		types.SucceededKey,
+		types.FailureReasonKey,
+		reporter.FailureCodeKey,
+		types.ResultsPrefix + reporter.OslatMaxLatencyKey,
+		types.ResultsPrefix + reporter.OslatCoreMaxLatenciesKey,
+		types.ResultsPrefix + reporter.OslatPercentilesKey,
+	} {
+		assert.Contains(t, analysisStatusData, key)
+		assert.Equal(t, checkupStatusData[key], analysisStatusData[key], key)
+	}
+}
+
+func TestAnalyzeShouldFailWhen(t *testing.T) {
+	t.Run("the threshold is invalid", func(t *testing.T) {
+		_, err := pkg.Analyze("", map[string]string{config.OslatLatencyThresholdParamName: "wrongValue"})
+		assert.ErrorIs(t, err, config.ErrInvalidOslatLatencyThreshold)
+	})
+
+	t.Run("the transcript has no oslat results", func(t *testing.T) {
+		_, err := pkg.Analyze("oslat: Failed to set scheduler policy: Operation not permitted", map[string]string{})
+		assert.ErrorContains(t, err, "failed parsing maximum latency from oslat results")
+	})
+}
@@ -0,0 +1,490 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package pkg_test
+
+import (
+	"context"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sfake "k8s.io/client-go/kubernetes/fake"
+
+	kconfig "github.com/kiagnose/kiagnose/kiagnose/config"
+	"github.com/kiagnose/kiagnose/kiagnose/types"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/goldenbaseline"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/history"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/performanceprofile"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/client/fake"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/launcher"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/reporter"
+)
+
+const (
+	testNamespace     = "target-ns"
+	testConfigMapName = "realtime-checkup-config"
+	testPodName       = "realtime-checkup-6ksmz"
+	testPodUID        = "0123456789-0123456789"
+)
+
+type checkupFlowTestCase struct {
+	description    string
+	consoleOptions []fake.ConsoleOption
+	// setup prepares the cluster objects the case depends on, before the checkup runs.
+	setup   func(t *testing.T, kubeVirtClient *fake.Client)
+	timeout string
+	params  map[string]string
+	// outOfCluster runs the checkup without a checkup pod owning the created objects.
+	outOfCluster          bool
+	expectedFailureReason string
+	expectedFailureCode   failure.Code
+	expectedResults       map[string]string
+	// expectedKeptConfigMaps is the number of ConfigMaps left in the cluster after the teardown.
+	expectedKeptConfigMaps int
+}
+
+const (
+	historyConfigMapName        = "realtime-checkup-history"
+	goldenBaselineConfigMapName = "golden-baseline-poweredge-r750"
+	profileName                 = "cnf-profile"
+	runtimeClass                = "performance-" + profileName
+	realtimeKernel              = "5.14.0-284.rt14.285.el9_2.x86_64"
+)
+
+var checkupFlowTestCases = []checkupFlowTestCase{
+	{
+		description: "succeeds",
+		expectedResults: map[string]string{
+			reporter.OslatMaxLatencyKey:             "13",
+			reporter.VMUnderTestActualNodeNameKey:   fake.NodeName,
+			reporter.MeasuredCPUsInterruptsKey:      "4-ttyS0@3:4,CAL@3:2,LOC@2:60,LOC@3:60",
+			reporter.MeasuredCPUsSoftIRQsKey:        "TIMER@2:60,TIMER@3:60",
+			reporter.MeasuredCPUsContextSwitchesKey: "2:4,3:4",
+			reporter.OslatPercentilesKey:            "99.99:2,99.999:9,99.9999:11",
+			reporter.GuestKernelVersionKey:          fake.GuestKernelVersion,
+			reporter.TimingGuestBootedKey:           "2023-06-06T09:52:32Z",
+			reporter.TimingTunedAppliedKey:          "2023-06-06T09:52:51Z",
+			reporter.TimingGuestRebootedKey:         "2023-06-06T09:53:23Z",
+		},
+	},
+	{
+		description: "reports the latency time series",
+		params:      map[string]string{config.OslatMeasurementWindowParamName: "25s"},
+		expectedResults: map[string]string{
+			reporter.OslatSpikesIntervalKey:    "",
+			reporter.OslatSpikesMinIntervalKey: "50s",
+		},
+	},
+	{
+		description: "reports the rtla osnoise noise attribution",
+		params:      map[string]string{config.RtlaModeParamName: config.RtlaOsnoiseMode},
+		expectedResults: map[string]string{
+			reporter.RtlaModeKey:             config.RtlaOsnoiseMode,
+			reporter.RtlaMaxNoiseKey:         "12",
+			reporter.RtlaNoiseOccurrencesKey: "hw:0,nmi:0,irq:118036,softirq:2,thread:11",
+		},
+	},
+	{
+		description: "reports the rtla timerlat noise attribution",
+		params:      map[string]string{config.RtlaModeParamName: config.RtlaTimerlatMode},
+		expectedResults: map[string]string{
+			reporter.RtlaModeKey:             config.RtlaTimerlatMode,
+			reporter.RtlaIRQMaxLatencyKey:    "12",
+			reporter.RtlaThreadMaxLatencyKey: "17",
+		},
+	},
+	{
+		description: "runs stress workloads during the measurements",
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName: fake.NodeName,
+			config.StressWorkloadsParamName:           "cpu,memory,io,network",
+		},
+		expectedResults: map[string]string{reporter.StressWorkloadsKey: "cpu,memory,io,network"},
+	},
+	{
+		description: "runs the guest housekeeping load during the measurements",
+		params: map[string]string{
+			config.GuestHousekeepingLoadParamName:        config.GuestMemoryLoad,
+			config.GuestHousekeepingLoadWorkersParamName: "3",
+		},
+		expectedResults: map[string]string{
+			reporter.GuestHousekeepingLoadKey:        config.GuestMemoryLoad,
+			reporter.GuestHousekeepingLoadWorkersKey: "3",
+		},
+	},
+	{
+		description: "compares to the baseline",
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName: fake.NodeName,
+			config.BaselineEnabledParamName:           "true",
+		},
+		expectedResults: map[string]string{
+			reporter.BaselineOslatMaxLatencyKey: "9",
+			reporter.OslatOverheadKey:           "4",
+		},
+	},
+	{
+		description: "applies the discovered performance profile",
+		setup:       addPerformanceProfile,
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName:   fake.NodeName,
+			config.PerformanceProfileDiscoveryParamName: "true",
+		},
+		expectedResults: map[string]string{
+			reporter.PerformanceProfileKey:          profileName,
+			reporter.VMUnderTestRuntimeClassNameKey: runtimeClass,
+		},
+	},
+	{
+		description:            "archives the kernel trace when the trace threshold is exceeded",
+		params:                 map[string]string{config.OslatTraceThresholdParamName: "10"},
+		expectedKeptConfigMaps: 1,
+	},
+	{
+		description: "detects a regression against the previous runs",
+		setup:       addHistory,
+		params: map[string]string{
+			config.HistoryConfigMapNameParamName: historyConfigMapName,
+			config.HistorySizeParamName:          "4",
+			config.RegressionWindowParamName:     "2",
+		},
+		expectedResults: map[string]string{
+			reporter.HostKernelVersionKey:            realtimeKernel,
+			reporter.RegressionDetectedKey:           "true",
+			reporter.RegressionPreviousRunsKey:       "2",
+			reporter.RegressionPreviousMaxLatencyKey: "10",
+			reporter.RegressionIncreasePercentKey:    "30.0",
+		},
+		expectedKeptConfigMaps: 1,
+	},
+	{
+		description: "compares to the golden baseline",
+		setup: addGoldenBaseline(`{"hardwareModel": "PowerEdge R750", "maxLatencyMicroSeconds": 12,
+			"percentilesMicroSeconds": {"99.999": 9}}`),
+		params: map[string]string{config.GoldenBaselineConfigMapNameParamName: goldenBaselineConfigMapName},
+		expectedResults: map[string]string{
+			reporter.GoldenBaselineHardwareModelKey: "PowerEdge R750",
+			reporter.GoldenBaselineComparisonKey:    "max:13/13:pass,p99.999:9/9:pass",
+			reporter.OslatCoreMaxLatenciesKey:       "2:12,3:13",
+		},
+		expectedKeptConfigMaps: 1,
+	},
+	{
+		description: "retries infrastructure failures",
+		setup: func(_ *testing.T, kubeVirtClient *fake.Client) {
+			kubeVirtClient.SetNextConsoleOptions(fake.WithDisconnectOn(fake.OslatCommandPrefix))
+		},
+		timeout: "1h",
+		params: map[string]string{
+			config.InfraFailureRetriesParamName: "1",
+			config.SetupTimeoutParamName:        "5m",
+		},
+		expectedResults: map[string]string{reporter.AttemptsKey: "1:ConsoleDisconnected,2:Succeeded"},
+	},
+	{
+		description: "retries when the setup fails before the VMI is created",
+		setup: func(_ *testing.T, kubeVirtClient *fake.Client) {
+			kubeVirtClient.RejectNextPod("OutOfcpu")
+		},
+		timeout: "1h",
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName: fake.NodeName,
+			config.BaselineEnabledParamName:           "true",
+			config.InfraFailureRetriesParamName:       "1",
+			config.SetupTimeoutParamName:              "5m",
+		},
+		expectedResults: map[string]string{reporter.AttemptsKey: "1:SchedulingFailed,2:Succeeded"},
+	},
+	{
+		description: "fails without retrying when the measured latency exceeds the threshold",
+		timeout:     "1h",
+		params: map[string]string{
+			config.InfraFailureRetriesParamName:   "1",
+			config.SetupTimeoutParamName:          "5m",
+			config.OslatLatencyThresholdParamName: "10",
+		},
+		expectedFailureReason: "oslat Max Latency measured 13µs exceeded the given threshold 10µs",
+		expectedFailureCode:   failure.LatencyThresholdExceeded,
+		expectedResults:       map[string]string{reporter.AttemptsKey: "1:LatencyThresholdExceeded"},
+	},
+	{
+		description: "fails without retrying when oslat fails",
+		consoleOptions: []fake.ConsoleOption{
+			fake.WithCommandOutput(fake.OslatCommandPrefix, "oslat: Failed to set scheduler policy: Operation not permitted", 1),
+		},
+		timeout: "1h",
+		params: map[string]string{
+			config.InfraFailureRetriesParamName: "1",
+			config.SetupTimeoutParamName:        "5m",
+		},
+		expectedFailureReason: "oslat test failed with exit code: 1",
+		expectedFailureCode:   failure.ToolExecutionFailed,
+		expectedResults:       map[string]string{reporter.AttemptsKey: "1:ToolExecutionFailed"},
+	},
+	{
+		description:           "fails when the serial console disconnects while oslat is launched",
+		consoleOptions:        []fake.ConsoleOption{fake.WithDisconnectOn(fake.OslatCommandPrefix)},
+		expectedFailureReason: "failed to run Oslat on VMI",
+		expectedFailureCode:   failure.ConsoleDisconnected,
+	},
+	{
+		description:           "fails when unexpected interrupts hit the measured CPUs",
+		params:                map[string]string{config.FailOnUnexpectedInterruptsParamName: "true"},
+		expectedFailureReason: "unexpected interrupts hit the measured CPUs: [4-ttyS0@3:4 CAL@3:2]",
+		expectedFailureCode:   failure.UnexpectedInterrupts,
+	},
+	{
+		description:           "fails when rtla fails",
+		consoleOptions:        []fake.ConsoleOption{fake.WithCommandOutput("rtla ", "-bash: rtla: command not found", 127)},
+		params:                map[string]string{config.RtlaModeParamName: config.RtlaOsnoiseMode},
+		expectedFailureReason: "rtla osnoise failed with exit code: 127",
+		expectedFailureCode:   failure.ToolExecutionFailed,
+	},
+	{
+		description: "fails when the overhead over the baseline exceeds the threshold",
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName: fake.NodeName,
+			config.BaselineEnabledParamName:           "true",
+			config.BaselineMaxOverheadParamName:       "3",
+		},
+		expectedFailureReason: "oslat Max Latency overhead over the baseline measured 4µs exceeded the given threshold 3µs",
+		expectedFailureCode:   failure.LatencyThresholdExceeded,
+	},
+	{
+		description:           "fails when the VM under test does not run with the given runtime class",
+		params:                map[string]string{config.VMUnderTestRuntimeClassNameParamName: runtimeClass},
+		expectedFailureReason: `runs with runtime class "" instead of "performance-cnf-profile"`,
+		expectedFailureCode:   failure.ConfigInvalid,
+	},
+	{
+		description: "fails when no performance profile matches the target node",
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName:   fake.NodeName,
+			config.PerformanceProfileDiscoveryParamName: "true",
+		},
+		expectedFailureReason: "failed to discover the performance profile",
+		expectedFailureCode:   failure.ConfigInvalid,
+	},
+	{
+		description:           "fails when the golden baseline ConfigMap does not exist",
+		params:                map[string]string{config.GoldenBaselineConfigMapNameParamName: goldenBaselineConfigMapName},
+		expectedFailureReason: `configmaps "golden-baseline-poweredge-r750" not found`,
+		expectedFailureCode:   failure.ConfigInvalid,
+	},
+	{
+		description: "fails when a core exceeds its golden baseline tolerance",
+		setup: addGoldenBaseline(`{"hardwareModel": "PowerEdge R750", "coreMaxLatenciesMicroSeconds": {"2": 12, "3": 10},
+			"tolerancePercent": 5}`),
+		params:                 map[string]string{config.GoldenBaselineConfigMapNameParamName: goldenBaselineConfigMapName},
+		expectedFailureReason:  "oslat results exceeded the golden baseline: core3:13/10:fail",
+		expectedFailureCode:    failure.LatencyThresholdExceeded,
+		expectedResults:        map[string]string{reporter.GoldenBaselineComparisonKey: "core2:12/12:pass,core3:13/10:fail"},
+		expectedKeptConfigMaps: 1,
+	},
+	{
+		description:           "fails when the guest housekeeping load fails to start",
+		consoleOptions:        []fake.ConsoleOption{fake.WithCommandOutput("sleep 1; pgrep -x stress-ng", "", 1)},
+		params:                map[string]string{config.GuestHousekeepingLoadParamName: config.GuestCPULoad},
+		expectedFailureReason: "guest cpu load is not running",
+		expectedFailureCode:   failure.ToolExecutionFailed,
+	},
+	{
+		description: "tears down when the setup fails out of cluster",
+		setup: func(_ *testing.T, kubeVirtClient *fake.Client) {
+			kubeVirtClient.RejectNextPod("OutOfcpu")
+		},
+		params: map[string]string{
+			config.VMUnderTestTargetNodeNameParamName: fake.NodeName,
+			config.StressWorkloadsParamName:           "cpu",
+		},
+		outOfCluster:          true,
+		expectedFailureReason: "has terminated in phase",
+		expectedFailureCode:   failure.SchedulingFailed,
+	},
+}
+
+func TestCheckupFlow(t *testing.T) {
+	for _, testCase := range checkupFlowTestCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			kubeVirtClient := fake.NewClient(append([]fake.ConsoleOption{fake.WithLoggedInUser()}, testCase.consoleOptions...)...)
+			if testCase.setup != nil {
+				testCase.setup(t, kubeVirtClient)
+			}
+			configMapClient := newConfigMapClient(testCase.timeout, testCase.params)
+
+			err := runCheckup(t, kubeVirtClient, configMapClient, testCase.outOfCluster)
+
+			results := userConfigMapData(t, configMapClient)
+			if testCase.expectedFailureReason == "" {
+				assert.NoError(t, err)
+				assert.Equal(t, "true", results[types.SucceededKey])
+				assert.Empty(t, results[types.FailureReasonKey])
+			} else {
+				assert.ErrorContains(t, err, testCase.expectedFailureReason)
+				assert.Equal(t, testCase.expectedFailureCode.ExitCode(), pkg.ExitCode(err))
+				assert.Equal(t, "false", results[types.SucceededKey])
+				assert.Contains(t, results[types.FailureReasonKey], testCase.expectedFailureReason)
+			}
+			assert.Equal(t, string(testCase.expectedFailureCode), results[reporter.FailureCodeKey])
+			for key, expectedValue := range testCase.expectedResults {
+				assert.Equal(t, expectedValue, results[types.ResultsPrefix+key], key)
+			}
+
+			assert.Empty(t, kubeVirtClient.VirtualMachineInstanceNames())
+			assert.Empty(t, kubeVirtClient.PodNames())
+			assert.Len(t, kubeVirtClient.ConfigMapNames(), testCase.expectedKeptConfigMaps)
+		})
+	}
+}
+
+func addPerformanceProfile(_ *testing.T, kubeVirtClient *fake.Client) {
+	const workerCNFRoleKey = "node-role.kubernetes.io/worker-cnf"
+
+	kubeVirtClient.SetDefaultRuntimeClass(runtimeClass)
+	kubeVirtClient.AddNode(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   fake.NodeName,
+			Labels: map[string]string{workerCNFRoleKey: ""},
+		},
+		Status: corev1.NodeStatus{
+			NodeInfo:    corev1.NodeSystemInfo{KernelVersion: realtimeKernel},
+			Allocatable: corev1.ResourceList{"hugepages-2Mi": resource.MustParse("8Gi")},
+		},
+	})
+	kubeVirtClient.AddPerformanceProfile(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": profileName},
+		"spec": map[string]interface{}{
+			"cpu":            map[string]interface{}{"isolated": "2-7", "reserved": "0-1"},
+			"hugepages":      map[string]interface{}{"defaultHugepagesSize": "2M"},
+			"nodeSelector":   map[string]interface{}{workerCNFRoleKey: ""},
+			"realTimeKernel": map[string]interface{}{"enabled": true},
+		},
+		"status": map[string]interface{}{"runtimeClass": runtimeClass},
+	}})
+	kubeVirtClient.AddTunedProfile(&unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      fake.NodeName,
+			"namespace": performanceprofile.TunedNamespace,
+			"annotations": map[string]interface{}{
+				performanceprofile.TunedBootcmdlineAnnotation: "skew_tick=1 nohz_full=2-7 default_hugepagesz=2M hugepagesz=2M",
+			},
+		},
+	}})
+}
+
+func addHistory(t *testing.T, kubeVirtClient *fake.Client) {
+	kubeVirtClient.AddNode(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: fake.NodeName},
+		Status:     corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{KernelVersion: realtimeKernel}},
+	})
+	historyData, err := history.Encode([]history.Entry{
+		{Node: fake.NodeName, MaxLatencyMicroSeconds: 8, Succeeded: true},
+		{Node: "other-node", MaxLatencyMicroSeconds: 30, Succeeded: true},
+		{Node: fake.NodeName, MaxLatencyMicroSeconds: 10, Succeeded: true},
+		{Node: fake.NodeName, MaxLatencyMicroSeconds: 10, Succeeded: true},
+	})
+	assert.NoError(t, err)
+	_, err = kubeVirtClient.CreateConfigMap(context.Background(), testNamespace, &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: historyConfigMapName},
+		Data:       map[string]string{history.DataKey: historyData},
+	})
+	assert.NoError(t, err)
+}
+
+func addGoldenBaseline(goldenBaseline string) func(t *testing.T, kubeVirtClient *fake.Client) {
+	return func(t *testing.T, kubeVirtClient *fake.Client) {
+		_, err := kubeVirtClient.CreateConfigMap(context.Background(), testNamespace, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: goldenBaselineConfigMapName},
+			Data:       map[string]string{goldenbaseline.DataKey: goldenBaseline},
+		})
+		assert.NoError(t, err)
+	}
+}
+
+// runCheckup wires the checkup the same way the main flow does, using the given fake clients.
+// Running out of cluster, there is no checkup pod to own the created objects.
+func runCheckup(t *testing.T, kubeVirtClient *fake.Client, configMapClient *k8sfake.Clientset, outOfCluster bool) error {
+	rawEnv := map[string]string{
+		"CONFIGMAP_NAMESPACE": testNamespace,
+		"CONFIGMAP_NAME":      testConfigMapName,
+		"HOSTNAME":            testPodName,
+		"POD_UID":             testPodUID,
+	}
+	if outOfCluster {
+		rawEnv["HOSTNAME"] = "workstation"
+		delete(rawEnv, "POD_UID")
+	}
+
+	baseConfig, err := kconfig.Read(configMapClient, rawEnv)
+	assert.NoError(t, err)
+
+	cfg, err := config.New(baseConfig)
+	assert.NoError(t, err)
+
+	l := launcher.New(
+		checkup.New(kubeVirtClient, testNamespace, cfg, executor.New(kubeVirtClient, testNamespace, cfg)),
+		reporter.New(configMapClient, baseConfig.ConfigMapNamespace, baseConfig.ConfigMapName),
+		launcher.WithRetries(cfg.InfraFailureRetries),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), baseConfig.Timeout)
+	defer cancel()
+
+	return l.Run(ctx)
+}
+
+// newConfigMapClient creates the user ConfigMap with the given params, the timeout defaulting to 30m.
+func newConfigMapClient(timeout string, params map[string]string) *k8sfake.Clientset {
+	const defaultTimeout = "30m"
+	if timeout == "" {
+		timeout = defaultTimeout
+	}
+
+	data := map[string]string{
+		types.TimeoutKey: timeout,
+		types.ParamNameKeyPrefix + config.VMUnderTestContainerDiskImageParamName: "quay.io/kiagnose/kubevirt-realtime-checkup-vm:main",
+		types.ParamNameKeyPrefix + config.OslatDurationParamName:                 "1m",
+	}
+	for name, value := range params {
+		data[types.ParamNameKeyPrefix+name] = value
+	}
+
+	return k8sfake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: testConfigMapName, Namespace: testNamespace},
+		Data:       data,
+	})
+}
+
+func userConfigMapData(t *testing.T, configMapClient *k8sfake.Clientset) map[string]string {
+	configMap, err := configMapClient.CoreV1().ConfigMaps(testNamespace).Get(context.Background(), testConfigMapName, metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	return configMap.Data
+}
@@ -0,0 +1,87 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package checkup
+
+import (
+	"fmt"
+	"strings"
+)
+
+// The boot script steps, as recorded in its status file.
+const (
+	bootStepMaskSwap          = "mask-swap"
+	bootStepConfigureTuned    = "configure-tuned"
+	bootStepRestartTuned      = "restart-tuned"
+	bootStepApplyTunedProfile = "apply-tuned-profile"
+	bootStepReboot            = "reboot"
+	bootStepSetupTracing      = "setup-tracing"
+	bootStepMarkReady         = "mark-ready"
+)
+
+const (
+	bootScriptStatusStepKey     = "step"
+	bootScriptStatusExitCodeKey = "exitCode"
+	bootScriptStatusStderrKey   = "stderr"
+)
+
+// bootScriptStatus is the outcome of the last step the boot script ran, as "<key>=<value>" lines.
+type bootScriptStatus struct {
+	step string
+	// exitCode is empty while the step did not complete.
+	exitCode string
+	stderr   string
+}
+
+func parseBootScriptStatus(content string) bootScriptStatus {
+	var status bootScriptStatus
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case bootScriptStatusStepKey:
+			status.step = value
+		case bootScriptStatusExitCodeKey:
+			status.exitCode = value
+		case bootScriptStatusStderrKey:
+			status.stderr = value
+		}
+	}
+	return status
+}
+
+// failure describes why the boot script did not get the guest ready, empty when it is not to blame.
+func (s bootScriptStatus) failure() string {
+	const successExitCode = "0"
+	switch {
+	case s.step == "":
+		return "boot script did not run"
+	case s.exitCode == "":
+		return fmt.Sprintf("boot script step %q did not complete", s.step)
+	case s.exitCode != successExitCode:
+		return fmt.Sprintf("boot script step %q failed with exit code %s: %s", s.step, s.exitCode, s.stderr)
+	case s.step == bootStepReboot:
+		return "guest did not reboot once the tuned profile was applied"
+	default:
+		return ""
+	}
+}
@@ -21,6 +21,7 @@ package checkup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"path"
@@ -29,14 +30,23 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	k8srand "k8s.io/apimachinery/pkg/util/rand"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
 
 	kvcorev1 "kubevirt.io/api/core/v1"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/configmap"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/interrupts"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/oslat"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/goldenbaseline"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/history"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/performanceprofile"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/pod"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/vmi"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
@@ -45,20 +55,42 @@ type kubeVirtVMIClient interface {
 		namespace string,
 		vmi *kvcorev1.VirtualMachineInstance) (*kvcorev1.VirtualMachineInstance, error)
 	GetVirtualMachineInstance(ctx context.Context, namespace, name string) (*kvcorev1.VirtualMachineInstance, error)
+	WatchVirtualMachineInstance(ctx context.Context, namespace, name string) (watch.Interface, error)
 	DeleteVirtualMachineInstance(ctx context.Context, namespace, name string) error
 	CreateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error)
+	GetConfigMap(ctx context.Context, namespace, name string) (*corev1.ConfigMap, error)
+	UpdateConfigMap(ctx context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error)
 	DeleteConfigMap(ctx context.Context, namespace, name string) error
+	CreatePod(ctx context.Context, namespace string, pod *corev1.Pod) (*corev1.Pod, error)
+	GetPod(ctx context.Context, namespace, name string) (*corev1.Pod, error)
+	DeletePod(ctx context.Context, namespace, name string) error
+	ListPods(ctx context.Context, namespace, labelSelector string) ([]corev1.Pod, error)
+	GetNode(ctx context.Context, name string) (*corev1.Node, error)
+	ListPerformanceProfiles(ctx context.Context) ([]unstructured.Unstructured, error)
+	GetTunedProfile(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error)
 }
 
 type testExecutor interface {
 	Execute(ctx context.Context, vmiName string) (status.Results, error)
+	BootScriptStatus(ctx context.Context, vmiName string) (string, error)
 }
 
 type Checkup struct {
 	client               kubeVirtVMIClient
 	namespace            string
 	vmUnderTestConfigMap *corev1.ConfigMap
+	traceConfigMapName   string
+	stressPods           []*corev1.Pod
+	baselinePod          *corev1.Pod
+	baselineMaxLatency   time.Duration
+	runtimeClassName     string
+	performanceProfile   string
+	goldenBaseline       *goldenbaseline.Baseline
 	vmi                  *kvcorev1.VirtualMachineInstance
+	// vmiCreationRequested is set once the VMI creation was requested, as it may exist even when the request failed.
+	vmiCreationRequested bool
+	vmiPhase             kvcorev1.VirtualMachineInstancePhase
+	timing               status.Timing
 	results              status.Results
 	executor             testExecutor
 	cfg                  config.Config
@@ -67,6 +99,13 @@ type Checkup struct {
 const (
 	VMUnderTestConfigMapNamePrefix = "realtime-vm-config"
 	VMINamePrefix                  = "realtime-vmi-under-test"
+	TraceConfigMapNamePrefix       = "realtime-checkup-trace"
+	TraceConfigMapDataKey          = "trace"
+	StressPodNamePrefix            = "realtime-checkup-stress"
+	StressWorkloadLabel            = "kiagnose.io/realtime-checkup-stress-workload"
+	BaselinePodNamePrefix          = "realtime-checkup-baseline"
+	// VMILauncherLabel is set by KubeVirt on the virt-launcher pod, holding the UID of the VMI it runs.
+	VMILauncherLabel = "kubevirt.io/created-by"
 )
 
 func New(client kubeVirtVMIClient, namespace string, checkupConfig config.Config, executor testExecutor) *Checkup {
@@ -79,28 +118,59 @@ func New(client kubeVirtVMIClient, namespace string, checkupConfig config.Config
 		client:               client,
 		namespace:            namespace,
 		vmUnderTestConfigMap: newVMUnderTestConfigMap(vmiUnderTestCMName, checkupConfig),
+		traceConfigMapName:   traceConfigMapName(randomSuffix),
+		stressPods:           newStressPods(randomSuffix, checkupConfig),
+		baselinePod:          newBaselinePod(randomSuffix, checkupConfig),
 		vmi:                  newRealtimeVMI(vmiUnderTestName(randomSuffix), checkupConfig, vmiUnderTestCMName),
+		runtimeClassName:     checkupConfig.VMUnderTestRuntimeClassName,
 		executor:             executor,
 		cfg:                  checkupConfig,
 	}
 }
 
-func (c *Checkup) Setup(ctx context.Context) error {
-	const setupTimeout = 10 * time.Minute
-	setupCtx, cancel := context.WithTimeout(ctx, setupTimeout)
-	defer cancel()
+// Reset prepares the checkup for a fresh attempt, naming the objects it creates anew.
+// It should be called once the previous attempt was torn down.
+func (c *Checkup) Reset() {
+	*c = *New(c.client, c.namespace, c.cfg, c.executor)
+}
 
+func (c *Checkup) Setup(ctx context.Context) error {
 	const errMessagePrefix = "Setup"
 
+	if c.cfg.GoldenBaselineConfigMapName != "" {
+		if err := c.loadGoldenBaseline(ctx); err != nil {
+			return failure.New(failure.ConfigInvalid, fmt.Errorf("%s: %w", errMessagePrefix, err))
+		}
+	}
+
+	if c.cfg.PerformanceProfileDiscovery {
+		if err := c.applyPerformanceProfile(ctx); err != nil {
+			return failure.New(failure.ConfigInvalid, fmt.Errorf("%s: %w", errMessagePrefix, err))
+		}
+	}
+
+	if c.baselinePod != nil {
+		pod.WithRuntimeClassName(c.runtimeClassName)(c.baselinePod)
+		if err := c.runBaseline(ctx); err != nil {
+			return fmt.Errorf("%s: %w", errMessagePrefix, err)
+		}
+	}
+
+	setupCtx, cancel := context.WithTimeout(ctx, c.cfg.SetupTimeout)
+	defer cancel()
+
 	if err := c.createVMUnderTestCM(setupCtx); err != nil {
 		return fmt.Errorf("%s: %w", errMessagePrefix, err)
 	}
 
+	c.vmi.Namespace = c.namespace
+	c.vmiCreationRequested = true
 	createdVMI, err := c.client.CreateVirtualMachineInstance(setupCtx, c.namespace, c.vmi)
 	if err != nil {
 		return err
 	}
 	c.vmi = createdVMI
+	c.timing.VMICreated = time.Now()
 
 	var updatedVMIUnderTest *kvcorev1.VirtualMachineInstance
 	updatedVMIUnderTest, err = c.waitForVMIToBeReady(setupCtx)
@@ -109,6 +179,13 @@ func (c *Checkup) Setup(ctx context.Context) error {
 	}
 
 	c.vmi = updatedVMIUnderTest
+	c.timing.VMIReady = time.Now()
+
+	if len(c.stressPods) > 0 {
+		if err := c.startStressPods(setupCtx); err != nil {
+			return failure.New(failure.SchedulingFailed, fmt.Errorf("%s: %w", errMessagePrefix, err))
+		}
+	}
 
 	return nil
 }
@@ -116,34 +193,116 @@ func (c *Checkup) Setup(ctx context.Context) error {
 func (c *Checkup) Run(ctx context.Context) error {
 	var err error
 
+	if c.runtimeClassName != "" {
+		if err := c.verifyLauncherRuntimeClass(ctx); err != nil {
+			return failure.New(failure.ConfigInvalid, err)
+		}
+	}
+
 	c.results, err = c.executor.Execute(ctx, c.vmi.Name)
 	if err != nil {
 		return err
 	}
 	c.results.VMUnderTestActualNodeName = c.vmi.Status.NodeName
+	c.results.Timing.VMICreated = c.timing.VMICreated
+	c.results.Timing.VMIScheduled = c.timing.VMIScheduled
+	c.results.Timing.VirtLauncherRunning = c.timing.VirtLauncherRunning
+	c.results.Timing.VMIReady = c.timing.VMIReady
+	c.results.StressWorkloads = c.cfg.StressWorkloads
+	c.results.PerformanceProfile = c.performanceProfile
+	c.results.VMUnderTestRuntimeClassName = c.runtimeClassName
+	if c.baselinePod != nil {
+		c.results.BaselineOslatMaxLatency = c.baselineMaxLatency
+		c.results.OslatOverhead = c.results.OslatMaxLatency - c.baselineMaxLatency
+	}
+
+	if c.goldenBaseline != nil {
+		c.results.GoldenBaselineHardwareModel = c.goldenBaseline.HardwareModel
+		c.results.GoldenBaselineComparisons = c.goldenBaseline.Compare(c.results)
+	}
+
+	if c.results.OslatTrace != "" {
+		if err := c.archiveTrace(ctx); err != nil {
+			log.Printf("Failed to archive the kernel trace: %v", err)
+		}
+	}
+
+	evaluationErr := Evaluate(c.results, c.cfg)
+
+	if c.cfg.HistoryConfigMapName != "" {
+		c.results.HostKernelVersion = c.hostKernelVersion(ctx)
+		if err := c.recordHistory(ctx, evaluationErr == nil); err != nil {
+			log.Printf("Failed to record the run history: %v", err)
+		}
+	}
+
+	return evaluationErr
+}
+
+// Evaluate returns the checkup verdict on the given results, failing when a measurement exceeds its threshold.
+func Evaluate(results status.Results, cfg config.Config) error {
+	if results.OslatMaxLatency > cfg.OslatLatencyThreshold {
+		return failure.New(failure.LatencyThresholdExceeded, fmt.Errorf("oslat Max Latency measured %s exceeded the given threshold %s",
+			results.OslatMaxLatency.String(), cfg.OslatLatencyThreshold.String()))
+	}
+
+	if cfg.FailOnUnexpectedInterrupts && results.MeasuredCPUsInterrupts != nil {
+		if unexpected := interrupts.Unexpected(results.MeasuredCPUsInterrupts.Interrupts); len(unexpected) > 0 {
+			return failure.New(failure.UnexpectedInterrupts, fmt.Errorf("unexpected interrupts hit the measured CPUs: %v", unexpected))
+		}
+	}
 
-	if c.results.OslatMaxLatency > c.cfg.OslatLatencyThreshold {
-		return fmt.Errorf("oslat Max Latency measured %s exceeded the given threshold %s",
-			c.results.OslatMaxLatency.String(), c.cfg.OslatLatencyThreshold.String())
+	if cfg.BaselineMaxOverhead > 0 && results.BaselineOslatMaxLatency > 0 && results.OslatOverhead > cfg.BaselineMaxOverhead {
+		return failure.New(failure.LatencyThresholdExceeded,
+			fmt.Errorf("oslat Max Latency overhead over the baseline measured %s exceeded the given threshold %s",
+				results.OslatOverhead.String(), cfg.BaselineMaxOverhead.String()))
 	}
+
+	var failedMetrics []string
+	for _, comparison := range results.GoldenBaselineComparisons {
+		if !comparison.Passed {
+			failedMetrics = append(failedMetrics, comparison.String())
+		}
+	}
+	if len(failedMetrics) > 0 {
+		return failure.New(failure.LatencyThresholdExceeded,
+			fmt.Errorf("oslat results exceeded the golden baseline: %s", strings.Join(failedMetrics, ",")))
+	}
+
 	return nil
 }
 
+// Teardown removes the objects the checkup created, tolerating the ones a failed or aborted setup did not create.
 func (c *Checkup) Teardown(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, c.cfg.TeardownTimeout)
+	defer cancel()
+
 	const errPrefix = "teardown"
 
-	if err := c.deleteVMI(ctx); err != nil {
+	if err := c.deleteStressPods(ctx); err != nil {
 		return fmt.Errorf("%s: %w", errPrefix, err)
 	}
 
-	if err := c.deleteVMUnderTestCM(ctx); err != nil {
+	if err := c.deleteBaselinePod(ctx); err != nil {
 		return fmt.Errorf("%s: %w", errPrefix, err)
 	}
 
-	if err := c.waitForVMIDeletion(ctx); err != nil {
+	if c.vmiCreationRequested {
+		if err := c.deleteVMI(ctx); err != nil {
+			return fmt.Errorf("%s: %w", errPrefix, err)
+		}
+	}
+
+	if err := c.deleteVMUnderTestCM(ctx); err != nil {
 		return fmt.Errorf("%s: %w", errPrefix, err)
 	}
 
+	if c.vmiCreationRequested {
+		if err := c.waitForVMIDeletion(ctx); err != nil {
+			return fmt.Errorf("%s: %w", errPrefix, err)
+		}
+	}
+
 	return nil
 }
 
@@ -158,10 +317,299 @@ func (c *Checkup) createVMUnderTestCM(ctx context.Context) error {
 	return err
 }
 
+// deleteVMUnderTestCM deletes the VM under test ConfigMap, ignoring it when it was not created.
 func (c *Checkup) deleteVMUnderTestCM(ctx context.Context) error {
 	log.Printf("Deleting ConfigMap %q...", ObjectFullName(c.namespace, c.vmUnderTestConfigMap.Name))
 
-	return c.client.DeleteConfigMap(ctx, c.namespace, c.vmUnderTestConfigMap.Name)
+	err := c.client.DeleteConfigMap(ctx, c.namespace, c.vmUnderTestConfigMap.Name)
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// archiveTrace stores the collected kernel trace in a ConfigMap which outlives the checkup.
+func (c *Checkup) archiveTrace(ctx context.Context) error {
+	traceConfigMap := configmap.New(c.traceConfigMapName, "", "", map[string]string{TraceConfigMapDataKey: c.results.OslatTrace})
+	traceConfigMapFullName := ObjectFullName(c.namespace, traceConfigMap.Name)
+	log.Printf("Creating ConfigMap %q...", traceConfigMapFullName)
+
+	if _, err := c.client.CreateConfigMap(ctx, c.namespace, traceConfigMap); err != nil {
+		return err
+	}
+	c.results.OslatTraceConfigMap = traceConfigMapFullName
+
+	return nil
+}
+
+// loadGoldenBaseline reads the golden baseline document, before any of the checkup objects are created.
+func (c *Checkup) loadGoldenBaseline(ctx context.Context) error {
+	goldenBaselineConfigMapFullName := ObjectFullName(c.namespace, c.cfg.GoldenBaselineConfigMapName)
+	log.Printf("Reading the golden baseline from ConfigMap %q...", goldenBaselineConfigMapFullName)
+
+	goldenBaselineConfigMap, err := c.client.GetConfigMap(ctx, c.namespace, c.cfg.GoldenBaselineConfigMapName)
+	if err != nil {
+		return err
+	}
+
+	baseline, err := goldenbaseline.Parse(goldenBaselineConfigMap.Data[goldenbaseline.DataKey])
+	if err != nil {
+		return fmt.Errorf("ConfigMap %q: %w", goldenBaselineConfigMapFullName, err)
+	}
+	c.goldenBaseline = &baseline
+
+	return nil
+}
+
+// recordHistory compares the run to the previous runs on the same node, then appends it to the history ConfigMap,
+// which outlives the checkup. The ConfigMap is created by the first run.
+func (c *Checkup) recordHistory(ctx context.Context, succeeded bool) error {
+	historyConfigMapFullName := ObjectFullName(c.namespace, c.cfg.HistoryConfigMapName)
+	historyConfigMap, err := c.client.GetConfigMap(ctx, c.namespace, c.cfg.HistoryConfigMapName)
+	historyExists := err == nil
+	if err != nil {
+		if !k8serrors.IsNotFound(err) {
+			return err
+		}
+		historyConfigMap = configmap.New(c.cfg.HistoryConfigMapName, "", "", nil)
+	}
+
+	entries, err := history.Decode(historyConfigMap.Data[history.DataKey])
+	if err != nil {
+		return fmt.Errorf("failed to decode ConfigMap %q: %w", historyConfigMapFullName, err)
+	}
+
+	entry := history.NewEntry(time.Now(), c.results, succeeded)
+	c.results.Regression = history.DetectRegression(entries, entry, c.cfg.RegressionWindow, c.cfg.RegressionThresholdPercent)
+	if regression := c.results.Regression; regression != nil && regression.Detected {
+		log.Printf("Max Oslat Latency regressed by %.1f%% over the average %s of the previous %d runs on node %q",
+			regression.IncreasePercent, regression.PreviousAverageMaxLatency.String(), regression.PreviousRuns, entry.Node)
+	}
+
+	data, err := history.Encode(history.Append(entries, entry, c.cfg.HistorySize))
+	if err != nil {
+		return err
+	}
+	historyConfigMap.Data = map[string]string{history.DataKey: data}
+
+	if historyExists {
+		log.Printf("Updating ConfigMap %q...", historyConfigMapFullName)
+		_, err = c.client.UpdateConfigMap(ctx, c.namespace, historyConfigMap)
+		return err
+	}
+
+	log.Printf("Creating ConfigMap %q...", historyConfigMapFullName)
+	_, err = c.client.CreateConfigMap(ctx, c.namespace, historyConfigMap)
+	return err
+}
+
+// hostKernelVersion returns the kernel version of the VM under test node, or an empty string when it cannot be read.
+func (c *Checkup) hostKernelVersion(ctx context.Context) string {
+	node, err := c.client.GetNode(ctx, c.vmi.Status.NodeName)
+	if err != nil {
+		log.Printf("Failed to read the kernel version of node %q: %v", c.vmi.Status.NodeName, err)
+		return ""
+	}
+
+	return node.Status.NodeInfo.KernelVersion
+}
+
+// startStressPods creates the stress pods and waits for them to run, so they hammer the node throughout the oslat run.
+func (c *Checkup) startStressPods(ctx context.Context) error {
+	for _, stressPod := range c.stressPods {
+		log.Printf("Creating stress pod %q...", ObjectFullName(c.namespace, stressPod.Name))
+		if _, err := c.client.CreatePod(ctx, c.namespace, stressPod); err != nil {
+			return err
+		}
+	}
+
+	for _, stressPod := range c.stressPods {
+		if err := c.waitForPodToRun(ctx, stressPod.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Checkup) waitForPodToRun(ctx context.Context, name string) error {
+	podFullName := ObjectFullName(c.namespace, name)
+	log.Printf("Waiting for pod %q to run...", podFullName)
+
+	conditionFn := func(ctx context.Context) (bool, error) {
+		pod, err := c.client.GetPod(ctx, c.namespace, name)
+		if err != nil {
+			return false, err
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("pod %q has terminated in phase %q", podFullName, pod.Status.Phase)
+		}
+		return false, nil
+	}
+	const pollInterval = 5 * time.Second
+	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
+		return fmt.Errorf("failed to wait for pod %q to run: %w", podFullName, err)
+	}
+
+	return nil
+}
+
+// deleteStressPods deletes the stress pods, ignoring the ones which were not created.
+func (c *Checkup) deleteStressPods(ctx context.Context) error {
+	for _, stressPod := range c.stressPods {
+		podFullName := ObjectFullName(c.namespace, stressPod.Name)
+		log.Printf("Deleting stress pod %q...", podFullName)
+		if err := c.client.DeletePod(ctx, c.namespace, stressPod.Name); err != nil && !k8serrors.IsNotFound(err) {
+			log.Printf("Failed to delete pod: %q", podFullName)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyPerformanceProfile discovers the performance profile of the target node, validates the node against it,
+// and applies its hugepage size to the VM under test.
+// Its runtime class, unless given by the user, is only verified to run the VM under test, and runs the baseline pod.
+func (c *Checkup) applyPerformanceProfile(ctx context.Context) error {
+	log.Printf("Discovering the performance profile of node %q...", c.cfg.VMUnderTestTargetNodeName)
+	profile, err := performanceprofile.Discover(ctx, c.client, c.cfg.VMUnderTestTargetNodeName,
+		performanceprofile.Requirements{CPUs: vmiCPUCoresCount, Memory: vmiGuestMemory})
+	if err != nil {
+		return fmt.Errorf("failed to discover the performance profile: %w", err)
+	}
+	log.Printf("Using performance profile %q: runtime class %q, isolated CPUs %q, reserved CPUs %q, hugepage size %q",
+		profile.Name, profile.RuntimeClass, profile.IsolatedCPUs, profile.ReservedCPUs, profile.HugepageSize)
+
+	c.performanceProfile = profile.Name
+	if c.runtimeClassName == "" {
+		c.runtimeClassName = profile.RuntimeClass
+	}
+	if hugepageSize := profile.VMIHugepageSize(); hugepageSize != "" {
+		vmi.WithMemory(hugepageSize, vmiGuestMemory)(c.vmi)
+	}
+
+	return nil
+}
+
+// verifyLauncherRuntimeClass checks the VMI virt-launcher pod runs with the expected runtime class.
+// KubeVirt sets it from its default runtime class, as a VMI cannot specify one.
+func (c *Checkup) verifyLauncherRuntimeClass(ctx context.Context) error {
+	launcherPods, err := c.client.ListPods(ctx, c.namespace, VMILauncherLabel+"="+string(c.vmi.UID))
+	if err != nil {
+		return err
+	}
+	if len(launcherPods) == 0 {
+		return fmt.Errorf("failed to find the launcher pod of VMI %q", ObjectFullName(c.vmi.Namespace, c.vmi.Name))
+	}
+
+	launcherRuntimeClass := ""
+	if launcherPods[0].Spec.RuntimeClassName != nil {
+		launcherRuntimeClass = *launcherPods[0].Spec.RuntimeClassName
+	}
+	if launcherRuntimeClass != c.runtimeClassName {
+		return fmt.Errorf("VMI %q runs with runtime class %q instead of %q, which should be the KubeVirt default runtime class",
+			ObjectFullName(c.vmi.Namespace, c.vmi.Name), launcherRuntimeClass, c.runtimeClassName)
+	}
+
+	return nil
+}
+
+// runBaseline runs oslat in the baseline pod before the VM under test is created, so both may get the same CPUs.
+// The pod is removed once done, releasing its CPUs.
+func (c *Checkup) runBaseline(ctx context.Context) error {
+	baselineCtx, cancel := context.WithTimeout(ctx, c.cfg.OslatDuration+config.OslatTimeoutGrace)
+	defer cancel()
+
+	podFullName := ObjectFullName(c.namespace, c.baselinePod.Name)
+	log.Printf("Creating baseline pod %q, running oslat for %s...", podFullName, c.cfg.OslatDuration.String())
+	if _, err := c.client.CreatePod(baselineCtx, c.namespace, c.baselinePod); err != nil {
+		return err
+	}
+
+	output, err := c.waitForPodToComplete(baselineCtx, c.baselinePod.Name)
+	if err != nil {
+		return failure.New(failure.ToolExecutionFailed, err)
+	}
+
+	results, err := oslat.Parse(output)
+	if err != nil {
+		return failure.New(failure.ResultParseFailed, fmt.Errorf("failed parsing the baseline oslat results: %w", err))
+	}
+	c.baselineMaxLatency = results.MaxLatency()
+	log.Printf("Baseline Max Oslat Latency measured: %s", c.baselineMaxLatency.String())
+
+	return c.deleteBaselinePod(baselineCtx)
+}
+
+// waitForPodToComplete waits for the pod to succeed, returning its termination message.
+func (c *Checkup) waitForPodToComplete(ctx context.Context, name string) (string, error) {
+	podFullName := ObjectFullName(c.namespace, name)
+	log.Printf("Waiting for pod %q to complete...", podFullName)
+
+	var terminationMessage string
+	conditionFn := func(ctx context.Context) (bool, error) {
+		pod, err := c.client.GetPod(ctx, c.namespace, name)
+		if err != nil {
+			return false, err
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			terminationMessage = podTerminationMessage(pod)
+			return true, nil
+		case corev1.PodFailed:
+			if rejection := podRejection(pod); rejection != "" {
+				return false, failure.New(failure.SchedulingFailed, fmt.Errorf("pod %q was rejected by its node: %s", podFullName, rejection))
+			}
+			return false, fmt.Errorf("pod %q has failed: %s", podFullName, podTerminationMessage(pod))
+		}
+		return false, nil
+	}
+	const pollInterval = 5 * time.Second
+	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
+		return "", fmt.Errorf("failed to wait for pod %q to complete: %w", podFullName, err)
+	}
+
+	return terminationMessage, nil
+}
+
+// podRejection describes why the node rejected the pod, e.g. for lack of exclusive CPUs, and is empty when it was admitted.
+// A rejected pod fails before any of its containers runs.
+func podRejection(pod *corev1.Pod) string {
+	if pod.Status.Phase != corev1.PodFailed || len(pod.Status.ContainerStatuses) > 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s: %s", pod.Status.Reason, pod.Status.Message)
+}
+
+func podTerminationMessage(pod *corev1.Pod) string {
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if containerStatus.State.Terminated != nil {
+			return containerStatus.State.Terminated.Message
+		}
+	}
+	return ""
+}
+
+// deleteBaselinePod deletes the baseline pod, ignoring it when it was not created or already removed.
+func (c *Checkup) deleteBaselinePod(ctx context.Context) error {
+	if c.baselinePod == nil {
+		return nil
+	}
+
+	podFullName := ObjectFullName(c.namespace, c.baselinePod.Name)
+	log.Printf("Deleting baseline pod %q...", podFullName)
+	if err := c.client.DeletePod(ctx, c.namespace, c.baselinePod.Name); err != nil && !k8serrors.IsNotFound(err) {
+		log.Printf("Failed to delete pod: %q", podFullName)
+		return err
+	}
+
+	return nil
 }
 
 func (c *Checkup) waitForVMIToBeReady(ctx context.Context) (*kvcorev1.VirtualMachineInstance, error) {
@@ -170,14 +618,18 @@ func (c *Checkup) waitForVMIToBeReady(ctx context.Context) (*kvcorev1.VirtualMac
 
 	log.Printf("Waiting for VMI %q to be ready...", vmiFullName)
 
-	conditionFn := func(ctx context.Context) (bool, error) {
-		var err error
-		updatedVMI, err = c.client.GetVirtualMachineInstance(ctx, c.vmi.Namespace, c.vmi.Name)
-		if err != nil {
-			return false, err
+	conditionFn := func(vmi *kvcorev1.VirtualMachineInstance) (bool, error) {
+		if vmi == nil {
+			return false, fmt.Errorf("VMI %q was deleted", vmiFullName)
 		}
+		updatedVMI = vmi
+		c.recordVMIPhase(vmi.Status.Phase)
 
-		for _, condition := range updatedVMI.Status.Conditions {
+		if vmi.Status.Phase == kvcorev1.Failed {
+			return false, failure.New(failure.VMIFailed, fmt.Errorf("VMI %q has failed", vmiFullName))
+		}
+
+		for _, condition := range vmi.Status.Conditions {
 			if condition.Type == kvcorev1.VirtualMachineInstanceReady && condition.Status == corev1.ConditionTrue {
 				return true, nil
 			}
@@ -185,9 +637,18 @@ func (c *Checkup) waitForVMIToBeReady(ctx context.Context) (*kvcorev1.VirtualMac
 
 		return false, nil
 	}
-	const pollInterval = 5 * time.Second
-	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
-		return nil, fmt.Errorf("failed to wait for VMI %q be ready: %w", vmiFullName, err)
+	if err := c.watchVMI(ctx, conditionFn); err != nil {
+		err = fmt.Errorf("failed to wait for VMI %q be ready: %w", vmiFullName, err)
+		if !errors.Is(err, wait.ErrWaitTimeout) {
+			return nil, err
+		}
+		if updatedVMI == nil || updatedVMI.Status.NodeName == "" {
+			return nil, failure.New(failure.SchedulingFailed, err)
+		}
+		if bootScriptFailure := c.bootScriptFailure(ctx); bootScriptFailure != "" {
+			err = fmt.Errorf("%w: %s", err, bootScriptFailure)
+		}
+		return nil, failure.New(failure.VMIBootTimeout, err)
 	}
 
 	log.Printf("VMI %q has successfully reached ready condition", vmiFullName)
@@ -195,6 +656,112 @@ func (c *Checkup) waitForVMIToBeReady(ctx context.Context) (*kvcorev1.VirtualMac
 	return updatedVMI, nil
 }
 
+// bootScriptFailure reads the boot script status through the VMI console, and describes why it did not get the guest ready.
+// As the setup context is already done by then, the status is given a short time of its own to be read.
+func (c *Checkup) bootScriptFailure(ctx context.Context) string {
+	const readBootScriptStatusTimeout = time.Minute
+	statusCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), readBootScriptStatusTimeout)
+	defer cancel()
+
+	vmiFullName := ObjectFullName(c.vmi.Namespace, c.vmi.Name)
+	log.Printf("Reading the boot script status of VMI %q...", vmiFullName)
+
+	content, err := c.executor.BootScriptStatus(statusCtx, c.vmi.Name)
+	if err != nil {
+		log.Printf("Failed to read the boot script status of VMI %q: %v", vmiFullName, err)
+		return ""
+	}
+
+	return parseBootScriptStatus(content).failure()
+}
+
+// recordVMIPhase records the time the VMI under test was first observed in each of its phases.
+func (c *Checkup) recordVMIPhase(phase kvcorev1.VirtualMachineInstancePhase) {
+	if phase == c.vmiPhase {
+		return
+	}
+	c.vmiPhase = phase
+
+	timestamp := time.Now()
+	switch phase {
+	case kvcorev1.Scheduled:
+		c.timing.VMIScheduled = timestamp
+	case kvcorev1.Running:
+		c.timing.VirtLauncherRunning = timestamp
+	}
+	log.Printf("VMI %q phase is %q since %s", ObjectFullName(c.vmi.Namespace, c.vmi.Name), phase,
+		timestamp.UTC().Format(time.RFC3339Nano))
+}
+
+// watchVMI calls the condition with each state of the VMI under test, nil once it is deleted, until the condition is met.
+// It gets the VMI and watches it from then on, starting over once the watch closes and every vmiResyncInterval.
+// Once the context is done, it fails with wait.ErrWaitTimeout.
+func (c *Checkup) watchVMI(ctx context.Context, conditionFn func(*kvcorev1.VirtualMachineInstance) (bool, error)) error {
+	for ctx.Err() == nil {
+		vmi, err := c.client.GetVirtualMachineInstance(ctx, c.vmi.Namespace, c.vmi.Name)
+		switch {
+		case k8serrors.IsNotFound(err):
+			vmi = nil
+		case err != nil && ctx.Err() != nil:
+			return wait.ErrWaitTimeout
+		case err != nil:
+			return err
+		}
+
+		if done, err := conditionFn(vmi); done || err != nil {
+			return err
+		}
+
+		if done, err := c.watchVMIUntilResync(ctx, conditionFn); done || err != nil {
+			return err
+		}
+	}
+
+	return wait.ErrWaitTimeout
+}
+
+func (c *Checkup) watchVMIUntilResync(ctx context.Context, conditionFn func(*kvcorev1.VirtualMachineInstance) (bool, error)) (bool, error) {
+	const vmiResyncInterval = 30 * time.Second
+	watchCtx, cancel := context.WithTimeout(ctx, vmiResyncInterval)
+	defer cancel()
+
+	watcher, err := c.client.WatchVirtualMachineInstance(watchCtx, c.vmi.Namespace, c.vmi.Name)
+	if err != nil {
+		log.Printf("Failed to watch VMI %q, polling it instead: %v", ObjectFullName(c.vmi.Namespace, c.vmi.Name), err)
+		<-watchCtx.Done()
+		return false, nil
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-watchCtx.Done():
+			return false, nil
+		case event, ok := <-watcher.ResultChan():
+			if !ok {
+				return false, nil
+			}
+
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				vmi, isVMI := event.Object.(*kvcorev1.VirtualMachineInstance)
+				if !isVMI {
+					continue
+				}
+				if done, err := conditionFn(vmi); done || err != nil {
+					return done, err
+				}
+			case watch.Deleted:
+				if done, err := conditionFn(nil); done || err != nil {
+					return done, err
+				}
+			case watch.Error:
+				return false, nil
+			}
+		}
+	}
+}
+
 func (c *Checkup) deleteVMI(ctx context.Context) error {
 	if c.vmi == nil {
 		return fmt.Errorf("failed to delete VMI, object doesn't exist")
@@ -203,7 +770,7 @@ func (c *Checkup) deleteVMI(ctx context.Context) error {
 	vmiFullName := ObjectFullName(c.vmi.Namespace, c.vmi.Name)
 
 	log.Printf("Trying to delete VMI: %q", vmiFullName)
-	if err := c.client.DeleteVirtualMachineInstance(ctx, c.vmi.Namespace, c.vmi.Name); err != nil {
+	if err := c.client.DeleteVirtualMachineInstance(ctx, c.vmi.Namespace, c.vmi.Name); err != nil && !k8serrors.IsNotFound(err) {
 		log.Printf("Failed to delete VMI: %q", vmiFullName)
 		return err
 	}
@@ -215,15 +782,10 @@ func (c *Checkup) waitForVMIDeletion(ctx context.Context) error {
 	vmiFullName := ObjectFullName(c.vmi.Namespace, c.vmi.Name)
 	log.Printf("Waiting for VMI %q to be deleted...", vmiFullName)
 
-	conditionFn := func(ctx context.Context) (bool, error) {
-		_, err := c.client.GetVirtualMachineInstance(ctx, c.vmi.Namespace, c.vmi.Name)
-		if k8serrors.IsNotFound(err) {
-			return true, nil
-		}
-		return false, err
+	conditionFn := func(vmi *kvcorev1.VirtualMachineInstance) (bool, error) {
+		return vmi == nil, nil
 	}
-	const pollInterval = 5 * time.Second
-	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
+	if err := c.watchVMI(ctx, conditionFn); err != nil {
 		return fmt.Errorf("failed to wait for VMI %q to be deleted: %v", vmiFullName, err)
 	}
 
@@ -234,7 +796,7 @@ func (c *Checkup) waitForVMIDeletion(ctx context.Context) error {
 
 func newVMUnderTestConfigMap(name string, checkupConfig config.Config) *corev1.ConfigMap {
 	vmUnderTestConfigData := map[string]string{
-		config.BootScriptName: generateBootScript(),
+		config.BootScriptName: generateBootScript(checkupConfig),
 	}
 	return configmap.New(name,
 		checkupConfig.PodName,
@@ -242,13 +804,16 @@ func newVMUnderTestConfigMap(name string, checkupConfig config.Config) *corev1.C
 		vmUnderTestConfigData)
 }
 
+const (
+	vmiCPUCoresCount = 4
+	vmiHugePageSize  = "1Gi"
+	vmiGuestMemory   = "4Gi"
+)
+
 func newRealtimeVMI(name string, checkupConfig config.Config, configMapName string) *kvcorev1.VirtualMachineInstance {
 	const (
 		CPUSocketsCount   = 1
-		CPUCoresCount     = 4
 		CPUTreadsCount    = 1
-		hugePageSize      = "1Gi"
-		guestMemory       = "4Gi"
 		rootDiskName      = "rootdisk"
 		configDiskSerial  = "DEADBEEF"
 		cloudInitDiskName = "cloudinitdisk"
@@ -260,8 +825,8 @@ func newRealtimeVMI(name string, checkupConfig config.Config, configMapName stri
 		vmi.WithoutCRIOCPULoadBalancing(),
 		vmi.WithoutCRIOCPUQuota(),
 		vmi.WithoutCRIOIRQLoadBalancing(),
-		vmi.WithRealtimeCPU(CPUSocketsCount, CPUCoresCount, CPUTreadsCount),
-		vmi.WithMemory(hugePageSize, guestMemory),
+		vmi.WithRealtimeCPU(CPUSocketsCount, vmiCPUCoresCount, CPUTreadsCount),
+		vmi.WithMemory(vmiHugePageSize, vmiGuestMemory),
 		vmi.WithoutAutoAttachGraphicsDevice(),
 		vmi.WithoutAutoAttachMemBalloon(),
 		vmi.WithAutoAttachSerialConsole(),
@@ -277,36 +842,184 @@ func newRealtimeVMI(name string, checkupConfig config.Config, configMapName stri
 	)
 }
 
-func generateBootScript() string {
-	const isolatedCores = "2-3"
+func generateBootScript(checkupConfig config.Config) string {
 	sb := strings.Builder{}
 
 	sb.WriteString("#!/bin/bash\n")
+	sb.WriteString("exec {BASH_XTRACEFD}>>" + config.BootScriptTraceFileFullPath + "\n")
 	sb.WriteString("set -x\n")
 	sb.WriteString("\n")
 	sb.WriteString("checkup_tuned_adm_set_marker_full_path=" + config.BootScriptTunedAdmSetMarkerFileFullPath + "\n")
+	sb.WriteString("checkup_timing_full_path=" + config.BootScriptTimingFileFullPath + "\n")
+	sb.WriteString("checkup_status_full_path=" + config.BootScriptStatusFileFullPath + "\n")
 	sb.WriteString("\n")
-	sb.WriteString("if systemctl --type swap list-units | grep -q '.swap'; then\n")
-	sb.WriteString("  systemctl mask \"$(systemctl --type swap list-units | grep '.swap' | awk '{print $1}')\"\n")
-	sb.WriteString("fi\n")
+	sb.WriteString(generateRunStep())
 	sb.WriteString("\n")
-	sb.WriteString("if [ ! -f \"$checkup_tuned_adm_set_marker_full_path\" ]; then\n")
+	sb.WriteString("mask_swap() {\n")
+	sb.WriteString("  if systemctl --type swap list-units | grep -q '.swap'; then\n")
+	sb.WriteString("    systemctl mask \"$(systemctl --type swap list-units | grep '.swap' | awk '{print $1}')\"\n")
+	sb.WriteString("  fi\n")
+	sb.WriteString("}\n")
+	sb.WriteString("\n")
+	sb.WriteString("configure_tuned() {\n")
 	sb.WriteString("  tuned_conf=\"/etc/tuned/realtime-virtual-guest-variables.conf\"\n")
-	sb.WriteString("  echo \"isolated_cores=" + isolatedCores + "\" > \"$tuned_conf\"\n")
+	sb.WriteString("  echo \"isolated_cores=" + config.VMUnderTestIsolatedCPUs + "\" > \"$tuned_conf\"\n")
 	sb.WriteString("  echo \"isolate_managed_irq=Y\" >> \"$tuned_conf\"\n")
-	sb.WriteString("  systemctl restart tuned.service\n")
-	sb.WriteString("  tuned-adm profile realtime-virtual-guest\n")
+	sb.WriteString("}\n")
+	sb.WriteString("\n")
+	if checkupConfig.OslatTraceThreshold > 0 {
+		sb.WriteString(generateTracingSetup())
+		sb.WriteString("\n")
+	}
+	sb.WriteString("mark_ready() {\n")
+	sb.WriteString("  touch " + config.BootScriptReadinessMarkerFileFullPath + " &&\n")
+	sb.WriteString("    chcon -t virt_qemu_ga_exec_t " + config.BootScriptReadinessMarkerFileFullPath + "\n")
+	sb.WriteString("}\n")
+	sb.WriteString("\n")
+	sb.WriteString("run_step " + bootStepMaskSwap + " mask_swap\n")
+	sb.WriteString("\n")
+	sb.WriteString("if [ ! -f \"$checkup_tuned_adm_set_marker_full_path\" ]; then\n")
+	sb.WriteString("  " + recordBootStep(config.BootStepGuestBooted))
+	sb.WriteString("  run_step " + bootStepConfigureTuned + " configure_tuned\n")
+	sb.WriteString("  run_step " + bootStepRestartTuned + " systemctl restart tuned.service\n")
+	sb.WriteString("  run_step " + bootStepApplyTunedProfile + " tuned-adm profile realtime-virtual-guest\n")
+	sb.WriteString("  " + recordBootStep(config.BootStepTunedApplied))
 	sb.WriteString("  touch $checkup_tuned_adm_set_marker_full_path\n")
-	sb.WriteString("  reboot\n")
+	sb.WriteString("  run_step " + bootStepReboot + " reboot\n")
 	sb.WriteString("  exit 0\n")
 	sb.WriteString("fi\n")
 	sb.WriteString("\n")
-	sb.WriteString("touch " + config.BootScriptReadinessMarkerFileFullPath + "\n")
-	sb.WriteString("chcon -t virt_qemu_ga_exec_t " + config.BootScriptReadinessMarkerFileFullPath + "\n")
+	sb.WriteString(recordBootStep(config.BootStepGuestRebooted))
+	if checkupConfig.OslatTraceThreshold > 0 {
+		sb.WriteString("run_step " + bootStepSetupTracing + " setup_tracing\n")
+	}
+	sb.WriteString("run_step " + bootStepMarkReady + " mark_ready\n")
+
+	return sb.String()
+}
+
+// generateRunStep defines run_step, which runs a boot step and records its outcome in the boot script status file,
+// exiting on failure. The status of a step which did not complete has no exit code.
+func generateRunStep() string {
+	const stderrTailLines = 5
+	sb := strings.Builder{}
+
+	sb.WriteString("run_step() {\n")
+	sb.WriteString("  local step=\"$1\"\n")
+	sb.WriteString("  shift\n")
+	sb.WriteString("  printf '" + bootScriptStatusStepKey + "=%s\\n' \"$step\" > \"$checkup_status_full_path\"\n")
+	sb.WriteString("  local stderr\n")
+	sb.WriteString("  stderr=$(\"$@\" 2>&1 > /dev/null)\n")
+	sb.WriteString("  local exit_code=$?\n")
+	sb.WriteString(fmt.Sprintf("  printf '%s=%%s\\n%s=%%d\\n%s=%%s\\n' \"$step\" \"$exit_code\" ",
+		bootScriptStatusStepKey, bootScriptStatusExitCodeKey, bootScriptStatusStderrKey))
+	sb.WriteString(fmt.Sprintf("\"$(echo \"$stderr\" | tail -n %d | tr '\\n' ' ')\" \\\n", stderrTailLines))
+	sb.WriteString("    > \"$checkup_status_full_path\"\n")
+	sb.WriteString("  [ \"$exit_code\" -eq 0 ] || exit \"$exit_code\"\n")
+	sb.WriteString("}\n")
+
+	return sb.String()
+}
+
+// recordBootStep appends the boot step time to the timing file.
+func recordBootStep(step string) string {
+	return "echo \"" + step + " $(date -u +%s)\" >> \"$checkup_timing_full_path\"\n"
+}
+
+// generateTracingSetup defines setup_tracing, which enables the kernel tracing of scheduling, interrupt and timer events,
+// so that oslat can stop it once its trace threshold is breached.
+func generateTracingSetup() string {
+	const traceBufferSizeKB = 16384
+	sb := strings.Builder{}
+
+	sb.WriteString("setup_tracing() {\n")
+	sb.WriteString("  tracing_dir=" + config.GuestTracingDirectory + "\n")
+	sb.WriteString("  mountpoint -q \"$tracing_dir\" || mount -t tracefs nodev \"$tracing_dir\" || return\n")
+	sb.WriteString("  echo 0 > \"$tracing_dir/tracing_on\"\n")
+	sb.WriteString(fmt.Sprintf("  echo %d > \"$tracing_dir/buffer_size_kb\"\n", traceBufferSizeKB))
+	sb.WriteString("  for trace_event in sched irq irq_vectors timer workqueue; do\n")
+	sb.WriteString("    if [ -d \"$tracing_dir/events/$trace_event\" ]; then\n")
+	sb.WriteString("      echo 1 > \"$tracing_dir/events/$trace_event/enable\"\n")
+	sb.WriteString("    fi\n")
+	sb.WriteString("  done\n")
+	sb.WriteString("  echo 1 > \"$tracing_dir/tracing_on\"\n")
+	sb.WriteString("}\n")
 
 	return sb.String()
 }
 
+func newStressPods(suffix string, checkupConfig config.Config) []*corev1.Pod {
+	const (
+		stressContainerName = "stress"
+		scratchVolumeName   = "scratch"
+		scratchDirectory    = "/scratch"
+	)
+
+	var stressPods []*corev1.Pod
+	for _, workload := range checkupConfig.StressWorkloads {
+		stressPods = append(stressPods, pod.New(stressPodName(workload, suffix),
+			pod.WithOwnerReference(checkupConfig.PodName, checkupConfig.PodUID),
+			pod.WithLabel(StressWorkloadLabel, workload),
+			pod.WithNodeSelector(checkupConfig.VMUnderTestTargetNodeName),
+			pod.WithZeroTerminationGracePeriodSeconds(),
+			pod.WithContainer(stressContainerName, checkupConfig.StressImage,
+				[]string{"stress-ng"}, stressWorkloadArgs(workload, scratchDirectory)),
+			pod.WithEmptyDirVolume(scratchVolumeName, scratchDirectory),
+		))
+	}
+
+	return stressPods
+}
+
+// stressWorkloadArgs returns the stress-ng arguments of the workload, running a worker per node CPU where applicable.
+func stressWorkloadArgs(workload, scratchDirectory string) []string {
+	switch workload {
+	case config.StressCPUWorkload:
+		return []string{"--cpu", "0", "--cpu-method", "all"}
+	case config.StressMemoryWorkload:
+		return []string{"--stream", "0"}
+	case config.StressIOWorkload:
+		return []string{"--hdd", "2", "--iomix", "2", "--temp-path", scratchDirectory}
+	case config.StressNetworkWorkload:
+		return []string{"--sock", "2", "--udp", "2"}
+	}
+	return nil
+}
+
+// newBaselinePod returns a guaranteed QoS pod running oslat on its exclusive CPUs, with the VM under test
+// CRI-O annotations, or nil when the baseline is disabled. The oslat output is reported as the termination message.
+func newBaselinePod(suffix string, checkupConfig config.Config) *corev1.Pod {
+	if !checkupConfig.BaselineEnabled {
+		return nil
+	}
+
+	const (
+		baselineContainerName = "oslat"
+		baselineCPUs          = "2"
+		baselineMemory        = "512Mi"
+	)
+
+	return pod.New(baselinePodName(suffix),
+		pod.WithOwnerReference(checkupConfig.PodName, checkupConfig.PodUID),
+		pod.WithAnnotation(vmi.CRIOCPULoadBalancingAnnotation, vmi.Disable),
+		pod.WithAnnotation(vmi.CRIOCPUQuotaAnnotation, vmi.Disable),
+		pod.WithAnnotation(vmi.CRIOIRQLoadBalancingAnnotation, vmi.Disable),
+		pod.WithNodeSelector(checkupConfig.VMUnderTestTargetNodeName),
+		pod.WithZeroTerminationGracePeriodSeconds(),
+		pod.WithContainer(baselineContainerName, checkupConfig.BaselineImage,
+			[]string{"/bin/sh", "-c"}, []string{baselineOslatScript(checkupConfig.OslatDuration)}),
+		pod.WithGuaranteedResources(baselineCPUs, baselineMemory),
+		pod.WithCapabilities("SYS_NICE", "IPC_LOCK"),
+	)
+}
+
+// baselineOslatScript runs oslat on the CPUs allocated to the container, the same way it runs in the VM under test.
+func baselineOslatScript(duration time.Duration) string {
+	return "cpus=$(grep Cpus_allowed_list /proc/self/status | cut -f2) && " +
+		fmt.Sprintf("oslat --cpu-list \"$cpus\" --rtprio 1 --duration %s --workload memmove --workload-mem 4K", duration.String()) +
+		" > /dev/termination-log 2>&1"
+}
+
 func realtimeVMIBootCommands(configDiskSerial string) []string {
 	const configMountDirectory = "/mnt/app-config"
 
@@ -327,6 +1040,18 @@ func vmiUnderTestConfigMapName(suffix string) string {
 	return VMUnderTestConfigMapNamePrefix + "-" + suffix
 }
 
+func stressPodName(workload, suffix string) string {
+	return StressPodNamePrefix + "-" + workload + "-" + suffix
+}
+
+func baselinePodName(suffix string) string {
+	return BaselinePodNamePrefix + "-" + suffix
+}
+
+func traceConfigMapName(suffix string) string {
+	return TraceConfigMapNamePrefix + "-" + suffix
+}
+
 func ObjectFullName(namespace, name string) string {
 	return fmt.Sprintf("%s/%s", namespace, name)
 }
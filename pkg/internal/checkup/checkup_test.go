@@ -30,12 +30,17 @@ import (
 
 	corev1 "k8s.io/api/core/v1"
 	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 
 	kvcorev1 "kubevirt.io/api/core/v1"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/history"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
@@ -66,11 +71,127 @@ func TestCheckupShouldSucceed(t *testing.T) {
 	assert.ErrorContains(t, err, "not found")
 
 	actualResults := testCheckup.Results()
+	assert.False(t, actualResults.Timing.VMICreated.IsZero())
+	assert.False(t, actualResults.Timing.VMIReady.IsZero())
+	actualResults.Timing = status.Timing{}
 	expectedResults := status.Results{}
 
 	assert.Equal(t, expectedResults, actualResults)
 }
 
+func TestSetupShouldEnableKernelTracingWhenTraceThresholdIsSet(t *testing.T) {
+	testClient := newClientStub()
+	testConfig := newTestConfig()
+	testConfig.OslatTraceThreshold = 30 * time.Microsecond
+	testCheckup := checkup.New(testClient, testNamespace, testConfig, executorStub{})
+
+	assert.NoError(t, testCheckup.Setup(context.Background()))
+
+	assert.Len(t, testClient.createdConfigMaps, 1)
+	for _, configMap := range testClient.createdConfigMaps {
+		assert.Contains(t, configMap.Data[config.BootScriptName], "echo 1 > \"$tracing_dir/tracing_on\"")
+		assert.Contains(t, configMap.Data[config.BootScriptName], "run_step setup-tracing setup_tracing")
+	}
+}
+
+func TestCheckupShouldRunStressPodsOnTheTargetNode(t *testing.T) {
+	testClient := newClientStub()
+	testConfig := newTestConfig()
+	testConfig.StressWorkloads = []string{config.StressCPUWorkload, config.StressIOWorkload}
+	testConfig.StressImage = config.StressDefaultImage
+	testCheckup := checkup.New(testClient, testNamespace, testConfig, executorStub{})
+
+	assert.NoError(t, testCheckup.Setup(context.Background()))
+
+	assert.Len(t, testClient.createdPods, 2)
+	for _, stressPod := range testClient.createdPods {
+		assert.True(t, strings.HasPrefix(stressPod.Name, checkup.StressPodNamePrefix), stressPod.Name)
+		assert.Equal(t, testTargetNodeName, stressPod.Spec.NodeSelector[corev1.LabelHostname])
+		assert.Equal(t, config.StressDefaultImage, stressPod.Spec.Containers[0].Image)
+		assert.Contains(t, []string{config.StressCPUWorkload, config.StressIOWorkload}, stressPod.Labels[checkup.StressWorkloadLabel])
+	}
+
+	assert.NoError(t, testCheckup.Run(context.Background()))
+	assert.Equal(t, testConfig.StressWorkloads, testCheckup.Results().StressWorkloads)
+
+	assert.NoError(t, testCheckup.Teardown(context.Background()))
+	assert.Empty(t, testClient.createdPods)
+}
+
+func TestSetupShouldFailWhenAStressPodTerminates(t *testing.T) {
+	testClient := newClientStub()
+	testClient.podPhase = corev1.PodFailed
+	testConfig := newTestConfig()
+	testConfig.StressWorkloads = []string{config.StressMemoryWorkload}
+	testCheckup := checkup.New(testClient, testNamespace, testConfig, executorStub{})
+
+	assert.ErrorContains(t, testCheckup.Setup(context.Background()), "has terminated in phase \"Failed\"")
+
+	assert.NoError(t, testCheckup.Teardown(context.Background()))
+	assert.Empty(t, testClient.createdPods)
+}
+
+func TestSetupShouldWatchTheVMIUntilItIsReady(t *testing.T) {
+	testClient := newClientStub()
+	testClient.vmiWatchedPhases = []kvcorev1.VirtualMachineInstancePhase{kvcorev1.Scheduling, kvcorev1.Scheduled, kvcorev1.Running}
+	testCheckup := checkup.New(testClient, testNamespace, newTestConfig(), executorStub{})
+
+	assert.NoError(t, testCheckup.Setup(context.Background()))
+	assert.NoError(t, testCheckup.Run(context.Background()))
+	assert.NoError(t, testCheckup.Teardown(context.Background()))
+
+	timing := testCheckup.Results().Timing
+	assert.False(t, timing.VMICreated.IsZero())
+	assert.False(t, timing.VMIScheduled.Before(timing.VMICreated))
+	assert.False(t, timing.VirtLauncherRunning.Before(timing.VMIScheduled))
+	assert.False(t, timing.VMIReady.Before(timing.VirtLauncherRunning))
+}
+
+func TestSetupShouldReportWhyTheBootScriptDidNotGetTheGuestReady(t *testing.T) {
+	testCases := []struct {
+		description         string
+		bootScriptStatus    string
+		bootScriptStatusErr error
+		expectedReason      string
+	}{
+		{
+			description:      "a step failed",
+			bootScriptStatus: "step=apply-tuned-profile\nexitCode=1\nstderr=Cannot find profile realtime-virtual-guest \n",
+			expectedReason:   `boot script step "apply-tuned-profile" failed with exit code 1: Cannot find profile realtime-virtual-guest`,
+		},
+		{
+			description:      "a step did not complete",
+			bootScriptStatus: "step=restart-tuned\n",
+			expectedReason:   `boot script step "restart-tuned" did not complete`,
+		},
+		{
+			description:      "the guest did not reboot",
+			bootScriptStatus: "step=reboot\nexitCode=0\nstderr= \n",
+			expectedReason:   "guest did not reboot once the tuned profile was applied",
+		},
+		{
+			description:         "the status cannot be read",
+			bootScriptStatusErr: errors.New("failed to login"),
+			expectedReason:      "be ready: timed out waiting for the condition",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			testClient := newClientStub()
+			testClient.vmiWatchedPhases = []kvcorev1.VirtualMachineInstancePhase{kvcorev1.Scheduled}
+			testConfig := newTestConfig()
+			testConfig.SetupTimeout = 100 * time.Millisecond
+			testExecutor := executorStub{bootScriptStatus: testCase.bootScriptStatus, bootScriptStatusErr: testCase.bootScriptStatusErr}
+			testCheckup := checkup.New(testClient, testNamespace, testConfig, testExecutor)
+
+			err := testCheckup.Setup(context.Background())
+			assert.ErrorContains(t, err, testCase.expectedReason)
+			assert.Equal(t, failure.VMIBootTimeout, failure.CodeOf(err))
+		})
+	}
+}
+
 func TestSetupShouldFail(t *testing.T) {
 	t.Run("when VM under test's ConfigMap creation fails", func(t *testing.T) {
 		expectedConfigMapCreationError := errors.New("failed to create ConfigMap")
@@ -104,6 +225,37 @@ func TestSetupShouldFail(t *testing.T) {
 
 		assert.ErrorContains(t, testCheckup.Setup(context.Background()), expectedVMIReadFailure.Error())
 	})
+
+	t.Run("when the VMI fails", func(t *testing.T) {
+		testClient := newClientStub()
+		testClient.vmiWatchedPhases = []kvcorev1.VirtualMachineInstancePhase{kvcorev1.Scheduled, kvcorev1.Failed}
+		testCheckup := checkup.New(testClient, testNamespace, newTestConfig(), executorStub{})
+
+		err := testCheckup.Setup(context.Background())
+		assert.ErrorContains(t, err, "has failed")
+		assert.Equal(t, failure.VMIFailed, failure.CodeOf(err))
+	})
+}
+
+func TestTeardownShouldSucceedAfterAPartialSetup(t *testing.T) {
+	t.Run("when VM under test's ConfigMap creation fails", func(t *testing.T) {
+		testClient := newClientStub()
+		testClient.configMapCreationFailure = errors.New("failed to create ConfigMap")
+		testCheckup := checkup.New(testClient, testNamespace, newTestConfig(), executorStub{})
+
+		assert.Error(t, testCheckup.Setup(context.Background()))
+		assert.NoError(t, testCheckup.Teardown(context.Background()))
+	})
+
+	t.Run("when VMI creation fails", func(t *testing.T) {
+		testClient := newClientStub()
+		testClient.vmiCreationFailure = errors.New("failed to create VMI")
+		testCheckup := checkup.New(testClient, testNamespace, newTestConfig(), executorStub{})
+
+		assert.Error(t, testCheckup.Setup(context.Background()))
+		assert.NoError(t, testCheckup.Teardown(context.Background()))
+		assert.Empty(t, testClient.createdConfigMaps)
+	})
 }
 
 func TestTeardownShouldFailWhen(t *testing.T) {
@@ -159,12 +311,18 @@ type clientStub struct {
 	createdConfigMaps        map[string]*corev1.ConfigMap
 	configMapCreationFailure error
 	configMapDeletionFailure error
+	createdPods              map[string]*corev1.Pod
+	podPhase                 corev1.PodPhase
+	// vmiWatchedPhases are the phases the created VMI goes through once watched, becoming ready once running.
+	vmiWatchedPhases []kvcorev1.VirtualMachineInstancePhase
 }
 
 func newClientStub() *clientStub {
 	return &clientStub{
 		createdVMIs:       map[string]*kvcorev1.VirtualMachineInstance{},
 		createdConfigMaps: map[string]*corev1.ConfigMap{},
+		createdPods:       map[string]*corev1.Pod{},
+		podPhase:          corev1.PodRunning,
 	}
 }
 
@@ -180,6 +338,11 @@ func (cs *clientStub) CreateVirtualMachineInstance(_ context.Context,
 	vmiFullName := checkup.ObjectFullName(vmi.Namespace, vmi.Name)
 	cs.createdVMIs[vmiFullName] = vmi
 
+	if len(cs.vmiWatchedPhases) > 0 {
+		vmi.Status.Phase = kvcorev1.Pending
+		return vmi, nil
+	}
+
 	vmi.Status.Conditions = append(vmi.Status.Conditions, kvcorev1.VirtualMachineInstanceCondition{
 		Type:   kvcorev1.VirtualMachineInstanceReady,
 		Status: corev1.ConditionTrue,
@@ -202,6 +365,31 @@ func (cs *clientStub) GetVirtualMachineInstance(_ context.Context, namespace, na
 	return vmi, nil
 }
 
+func (cs *clientStub) WatchVirtualMachineInstance(_ context.Context, namespace, name string) (watch.Interface, error) {
+	vmi, err := cs.GetVirtualMachineInstance(context.Background(), namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	watcher := watch.NewFakeWithChanSize(len(cs.vmiWatchedPhases), false)
+	for _, phase := range cs.vmiWatchedPhases {
+		watchedVMI := vmi.DeepCopy()
+		watchedVMI.Status.Phase = phase
+		if phase != kvcorev1.Pending && phase != kvcorev1.Scheduling {
+			watchedVMI.Status.NodeName = testTargetNodeName
+		}
+		if phase == kvcorev1.Running {
+			watchedVMI.Status.Conditions = append(watchedVMI.Status.Conditions, kvcorev1.VirtualMachineInstanceCondition{
+				Type:   kvcorev1.VirtualMachineInstanceReady,
+				Status: corev1.ConditionTrue,
+			})
+		}
+		watcher.Modify(watchedVMI)
+	}
+
+	return watcher, nil
+}
+
 func (cs *clientStub) DeleteVirtualMachineInstance(_ context.Context, namespace, name string) error {
 	if cs.vmiDeletionFailure != nil {
 		return cs.vmiDeletionFailure
@@ -231,6 +419,22 @@ func (cs *clientStub) CreateConfigMap(_ context.Context, namespace string, confi
 	return configMap, nil
 }
 
+func (cs *clientStub) GetConfigMap(_ context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	configMap, exist := cs.createdConfigMaps[checkup.ObjectFullName(namespace, name)]
+	if !exist {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "configmaps"}, name)
+	}
+
+	return configMap, nil
+}
+
+func (cs *clientStub) UpdateConfigMap(_ context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	configMap.Namespace = namespace
+	cs.createdConfigMaps[checkup.ObjectFullName(configMap.Namespace, configMap.Name)] = configMap
+
+	return configMap, nil
+}
+
 func (cs *clientStub) DeleteConfigMap(_ context.Context, namespace, name string) error {
 	if cs.configMapDeletionFailure != nil {
 		return cs.configMapDeletionFailure
@@ -247,6 +451,51 @@ func (cs *clientStub) DeleteConfigMap(_ context.Context, namespace, name string)
 	return nil
 }
 
+func (cs *clientStub) CreatePod(_ context.Context, namespace string, pod *corev1.Pod) (*corev1.Pod, error) {
+	pod.Namespace = namespace
+	pod.Status.Phase = cs.podPhase
+
+	cs.createdPods[checkup.ObjectFullName(pod.Namespace, pod.Name)] = pod
+
+	return pod, nil
+}
+
+func (cs *clientStub) GetPod(_ context.Context, namespace, name string) (*corev1.Pod, error) {
+	pod, exist := cs.createdPods[checkup.ObjectFullName(namespace, name)]
+	if !exist {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "pods"}, name)
+	}
+
+	return pod, nil
+}
+
+func (cs *clientStub) DeletePod(_ context.Context, namespace, name string) error {
+	podFullName := checkup.ObjectFullName(namespace, name)
+	if _, exist := cs.createdPods[podFullName]; !exist {
+		return k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "pods"}, name)
+	}
+
+	delete(cs.createdPods, podFullName)
+
+	return nil
+}
+
+func (cs *clientStub) ListPods(_ context.Context, _, _ string) ([]corev1.Pod, error) {
+	return nil, nil
+}
+
+func (cs *clientStub) GetNode(_ context.Context, name string) (*corev1.Node, error) {
+	return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "", Resource: "nodes"}, name)
+}
+
+func (cs *clientStub) ListPerformanceProfiles(_ context.Context) ([]unstructured.Unstructured, error) {
+	return nil, nil
+}
+
+func (cs *clientStub) GetTunedProfile(_ context.Context, _, name string) (*unstructured.Unstructured, error) {
+	return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "tuned.openshift.io", Resource: "profiles"}, name)
+}
+
 func (cs *clientStub) VMIName() string {
 	for _, vmi := range cs.createdVMIs {
 		if strings.Contains(vmi.Name, checkup.VMINamePrefix) {
@@ -282,8 +531,104 @@ func TestRunFailure(t *testing.T) {
 	assert.Equal(t, expectedResults, actualResults)
 }
 
+func TestRunShouldArchiveTheKernelTrace(t *testing.T) {
+	const trace = "# tracer: nop\n"
+
+	testClient := newClientStub()
+	testCheckup := checkup.New(testClient, testNamespace, newTestConfig(), executorStub{results: status.Results{OslatTrace: trace}})
+
+	assert.NoError(t, testCheckup.Setup(context.Background()))
+	assert.NoError(t, testCheckup.Run(context.Background()))
+	assert.NoError(t, testCheckup.Teardown(context.Background()))
+
+	traceConfigMapName := testCheckup.Results().OslatTraceConfigMap
+	assert.Regexp(t, "^"+testNamespace+"/"+checkup.TraceConfigMapNamePrefix+"-", traceConfigMapName)
+	assert.Len(t, testClient.createdConfigMaps, 1)
+
+	traceConfigMap := testClient.createdConfigMaps[traceConfigMapName]
+	assert.NotNil(t, traceConfigMap)
+	assert.Empty(t, traceConfigMap.OwnerReferences)
+	assert.Equal(t, trace, traceConfigMap.Data[checkup.TraceConfigMapDataKey])
+}
+
+func TestRunShouldRecordTheHistory(t *testing.T) {
+	const historyConfigMapName = "realtime-checkup-history"
+
+	previousEntries := []history.Entry{
+		{MaxLatencyMicroSeconds: 8, Succeeded: true},
+		{Node: "other-node", MaxLatencyMicroSeconds: 30, Succeeded: true},
+		{MaxLatencyMicroSeconds: 10, Succeeded: true},
+		{MaxLatencyMicroSeconds: 10, Succeeded: true},
+	}
+
+	testCases := []struct {
+		description        string
+		previousEntries    []history.Entry
+		expectedEntries    []history.Entry
+		expectedRegression *status.Regression
+	}{
+		{
+			description:     "creating the history on the first run",
+			expectedEntries: []history.Entry{},
+		},
+		{
+			description:     "detecting a regression against the previous runs on the same node",
+			previousEntries: previousEntries,
+			expectedEntries: previousEntries[1:],
+			expectedRegression: &status.Regression{
+				Detected:                  true,
+				PreviousRuns:              2,
+				PreviousAverageMaxLatency: 10 * time.Microsecond,
+				IncreasePercent:           30,
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			testClient := newClientStub()
+			if testCase.previousEntries != nil {
+				data, err := history.Encode(testCase.previousEntries)
+				assert.NoError(t, err)
+				_, err = testClient.CreateConfigMap(context.Background(), testNamespace, &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: historyConfigMapName},
+					Data:       map[string]string{history.DataKey: data},
+				})
+				assert.NoError(t, err)
+			}
+
+			testConfig := newTestConfig()
+			testConfig.HistoryConfigMapName = historyConfigMapName
+			testConfig.HistorySize = 4
+			testConfig.RegressionWindow = 2
+			testConfig.RegressionThresholdPercent = config.RegressionDefaultThresholdPercent
+			testCheckup := checkup.New(testClient, testNamespace, testConfig,
+				executorStub{results: status.Results{OslatMaxLatency: 13 * time.Microsecond}})
+
+			assert.NoError(t, testCheckup.Setup(context.Background()))
+			assert.NoError(t, testCheckup.Run(context.Background()))
+			assert.NoError(t, testCheckup.Teardown(context.Background()))
+
+			assert.Equal(t, testCase.expectedRegression, testCheckup.Results().Regression)
+
+			historyConfigMap := testClient.createdConfigMaps[checkup.ObjectFullName(testNamespace, historyConfigMapName)]
+			assert.NotNil(t, historyConfigMap)
+			assert.Empty(t, historyConfigMap.OwnerReferences)
+			entries, err := history.Decode(historyConfigMap.Data[history.DataKey])
+			assert.NoError(t, err)
+			assert.Len(t, entries, len(testCase.expectedEntries)+1)
+			assert.Equal(t, testCase.expectedEntries, entries[:len(testCase.expectedEntries)])
+			assert.Equal(t, int64(13), entries[len(entries)-1].MaxLatencyMicroSeconds)
+			assert.True(t, entries[len(entries)-1].Succeeded)
+		})
+	}
+}
+
 type executorStub struct {
-	executeErr error
+	results             status.Results
+	executeErr          error
+	bootScriptStatus    string
+	bootScriptStatusErr error
 }
 
 func (es executorStub) Execute(_ context.Context, vmiName string) (status.Results, error) {
@@ -291,7 +636,11 @@ func (es executorStub) Execute(_ context.Context, vmiName string) (status.Result
 		return status.Results{}, es.executeErr
 	}
 
-	return status.Results{}, nil
+	return es.results, nil
+}
+
+func (es executorStub) BootScriptStatus(_ context.Context, _ string) (string, error) {
+	return es.bootScriptStatus, es.bootScriptStatusErr
 }
 
 func newTestConfig() config.Config {
@@ -302,5 +651,7 @@ func newTestConfig() config.Config {
 		VMUnderTestContainerDiskImage: testVMUnderTestImage,
 		OslatDuration:                 10 * time.Minute,
 		OslatLatencyThreshold:         45 * time.Microsecond,
+		SetupTimeout:                  config.DefaultSetupTimeout,
+		TeardownTimeout:               config.DefaultTeardownTimeout,
 	}
 }
@@ -26,19 +26,26 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
+// New creates a ConfigMap owned by the given pod.
+// The owner reference is omitted when the owner is unknown (e.g. when running out of cluster).
 func New(name, ownerName, ownerUID string, data map[string]string) *k8scorev1.ConfigMap {
-	return &k8scorev1.ConfigMap{
+	newConfigMap := &k8scorev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name: name,
-			OwnerReferences: []metav1.OwnerReference{
-				{
-					APIVersion: "v1",
-					Kind:       "Pod",
-					Name:       ownerName,
-					UID:        types.UID(ownerUID),
-				},
-			},
 		},
 		Data: data,
 	}
+
+	if ownerName != "" && ownerUID != "" {
+		newConfigMap.OwnerReferences = []metav1.OwnerReference{
+			{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       ownerName,
+				UID:        types.UID(ownerUID),
+			},
+		}
+	}
+
+	return newConfigMap
 }
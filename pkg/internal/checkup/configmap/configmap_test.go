@@ -57,3 +57,19 @@ func TestNew(t *testing.T) {
 
 	assert.Equal(t, expectedConfigMap, actualConfigMap)
 }
+
+func TestNewWithoutOwner(t *testing.T) {
+	name := "my-cm"
+	data := map[string]string{"key": "value"}
+
+	actualConfigMap := configmap.New(name, "", "", data)
+
+	expectedConfigMap := &k8scorev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data: data,
+	}
+
+	assert.Equal(t, expectedConfigMap, actualConfigMap)
+}
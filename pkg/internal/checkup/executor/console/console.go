@@ -21,9 +21,9 @@ package console
 
 import (
 	"fmt"
-	"io"
 	"log"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -36,11 +36,9 @@ type vmiSerialConsoleClient interface {
 	VMISerialConsole(namespace, name string, timeout time.Duration) (kubecli.StreamInterface, error)
 }
 
+// Expecter runs commands on a VMI console session, shared by its copies.
 type Expecter struct {
-	serialConsoleClient vmiSerialConsoleClient
-	vmiNamespace        string
-	vmiName             string
-	opts                []expect.Option
+	session *Session
 }
 
 const (
@@ -48,53 +46,19 @@ const (
 	CRLF             = "\r\n"
 )
 
-// NewExpecter will connect to an already logged in VMI console and return the generated expecter it will wait `timeout` for the connection.
+// NewExpecter returns an expecter of the VMI console. It connects on its first use, and stays connected until closed.
 func NewExpecter(serialConsoleClient vmiSerialConsoleClient,
 	vmiNamespace,
 	vmiName string,
 	opts ...expect.Option) Expecter {
 	return Expecter{
-		serialConsoleClient: serialConsoleClient,
-		vmiNamespace:        vmiNamespace,
-		vmiName:             vmiName,
-		opts:                opts,
+		session: NewSession(serialConsoleClient, vmiNamespace, vmiName, opts...),
 	}
 }
 
-func (e Expecter) spawnConsole(timeout time.Duration) (*expect.GExpect, error) {
-	vmiReader, vmiWriter := io.Pipe()
-	expecterReader, expecterWriter := io.Pipe()
-	resCh := make(chan error)
-
-	startTime := time.Now()
-	con, err := e.serialConsoleClient.VMISerialConsole(e.vmiNamespace, e.vmiName, timeout)
-	if err != nil {
-		return nil, err
-	}
-	timeout -= time.Since(startTime)
-
-	go func() {
-		resCh <- con.Stream(kubecli.StreamOptions{
-			In:  vmiReader,
-			Out: expecterWriter,
-		})
-	}()
-
-	e.opts = append(e.opts, expect.SendTimeout(timeout), expect.Verbose(false))
-	genExpect, _, err := expect.SpawnGeneric(&expect.GenOptions{
-		In:  vmiWriter,
-		Out: expecterReader,
-		Wait: func() error {
-			return <-resCh
-		},
-		Close: func() error {
-			expecterWriter.Close()
-			vmiReader.Close()
-			return nil
-		},
-		Check: func() bool { return true },
-	}, timeout, e.opts...)
-	return genExpect, err
+// Close drops the console connection.
+func (e Expecter) Close() {
+	e.session.Close()
 }
 
 func RetValue(retcode string) string {
@@ -102,29 +66,79 @@ func RetValue(retcode string) string {
 }
 
 func (e Expecter) GetGuestKernelArgs() (string, error) {
-	const cmdLineCmd = "cat /proc/cmdline\n"
-	batch := []expect.Batcher{
-		&expect.BSnd{S: cmdLineCmd},
-		&expect.BExp{R: PromptExpression},
-	}
 	const printKernelArgsTimeout = 30 * time.Second
-	resp, err := e.SafeExpectBatchWithResponse(batch, printKernelArgsTimeout)
-	return resp[0].Output, err
+	return e.output("cat /proc/cmdline", printKernelArgsTimeout)
+}
+
+// GetGuestKernelVersion returns the guest kernel release, as printed by uname.
+func (e Expecter) GetGuestKernelVersion() (string, error) {
+	const printKernelVersionTimeout = 30 * time.Second
+	kernelVersion, err := e.output("uname -r", printKernelVersionTimeout)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(kernelVersion), nil
+}
+
+// ReadFile returns the content of the guest file.
+func (e Expecter) ReadFile(fileFullPath string) (string, error) {
+	const readFileTimeout = 30 * time.Second
+	return e.output("cat "+fileFullPath, readFileTimeout)
 }
 
-// SafeExpectBatchWithResponse runs the batch from `expected`, connecting to a VMI's console and
+// GetGuestBootTimestamps returns the times the boot script recorded by the name of its steps, the first time of each step.
+func (e Expecter) GetGuestBootTimestamps(timingFile string) (map[string]time.Time, error) {
+	output, err := e.ReadFile(timingFile)
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps := map[string]time.Time{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		const expectedFields = 2
+		if len(fields) != expectedFields {
+			continue
+		}
+		epochSeconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the time of boot step %q: %w", fields[0], err)
+		}
+		if _, exists := timestamps[fields[0]]; !exists {
+			timestamps[fields[0]] = time.Unix(epochSeconds, 0).UTC()
+		}
+	}
+
+	return timestamps, nil
+}
+
+// output runs the command, failing if it exits with a non-zero code.
+func (e Expecter) output(command string, timeout time.Duration) (string, error) {
+	output, exitCode, err := e.session.Run(command, timeout)
+	if err != nil {
+		return "", err
+	}
+	if exitCode != 0 {
+		return "", fmt.Errorf("%q failed with exit code %d: %s", command, exitCode, output)
+	}
+
+	return output, nil
+}
+
+// Run runs a single foreground shell command on the VMI's console session, returning its output and exit code.
+// Its output is delimited by sentinels, so it does not depend on the console echoing the command line back intact.
+func (e Expecter) Run(command string, timeout time.Duration) (output string, exitCode int, err error) {
+	return e.session.Run(command, timeout)
+}
+
+// SafeExpectBatchWithResponse runs the batch from `expected` on the VMI's console session,
 // waiting for the batch to return with a response until timeout.
 // It validates that the commands arrive to the console.
 // NOTE: This functions inherits limitations from `expectBatchWithValidatedSend`, refer to it for more information.
 func (e Expecter) SafeExpectBatchWithResponse(expected []expect.Batcher,
 	timeout time.Duration) ([]expect.BatchRes, error) {
-	genExpect, err := e.spawnConsole(timeout)
-	if err != nil {
-		return nil, err
-	}
-	defer genExpect.Close()
-
-	resp, err := expectBatchWithValidatedSend(genExpect, expected, timeout)
+	resp, err := e.session.ExpectBatch(expected, timeout)
 	if err != nil {
 		log.Printf("%v", resp)
 	}
@@ -29,25 +29,22 @@ import (
 	"google.golang.org/grpc/codes"
 )
 
+// LoginToCentOSAsRoot logs into the VMI as root, unless already logged in.
+// The expecter logs in again whenever it reconnects.
 func (e Expecter) LoginToCentOSAsRoot(password string) error {
-	const (
-		connectionTimeout = 10 * time.Second
-		promptTimeout     = 5 * time.Second
-	)
+	return e.session.Login(password)
+}
 
-	genExpect, err := e.spawnConsole(connectionTimeout)
-	if err != nil {
-		return err
-	}
-	defer genExpect.Close()
+func loginToCentOSAsRoot(genExpect *expect.GExpect, vmiName, password string) error {
+	const promptTimeout = 5 * time.Second
 
-	err = genExpect.Send("\n")
+	err := genExpect.Send("\n")
 	if err != nil {
 		return err
 	}
 
 	// Do not login, if we already logged in
-	loggedInPromptRegex := fmt.Sprintf(`(\[root@(localhost|centos|%s) ~\]\# )`, e.vmiName)
+	loggedInPromptRegex := fmt.Sprintf(`(\[root@(localhost|centos|%s) ~\]\# )`, vmiName)
 	b := []expect.Batcher{
 		&expect.BSnd{S: "\n"},
 		&expect.BExp{R: loggedInPromptRegex},
@@ -64,7 +61,7 @@ func (e Expecter) LoginToCentOSAsRoot(password string) error {
 			&expect.Case{
 				// Using only "login: " would match things like "Last failed login: Tue Jun  9 22:25:30 UTC 2020 on ttyS0"
 				// and in case the VM's did not get hostname form DHCP server try the default hostname
-				R:  regexp.MustCompile(fmt.Sprintf(`(localhost|centos|%s) login: `, e.vmiName)),
+				R:  regexp.MustCompile(fmt.Sprintf(`(localhost|centos|%s) login: `, vmiName)),
 				S:  "root\n",
 				T:  expect.Next(),
 				Rt: 10,
@@ -0,0 +1,238 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package console
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	expect "github.com/google/goexpect"
+
+	"kubevirt.io/client-go/kubecli"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+)
+
+const (
+	connectionTimeout = 30 * time.Second
+
+	// sentinel delimits the output and exit code of the commands run by a session.
+	sentinel = "RTCHECKUP"
+)
+
+// Session is a single VMI console connection, on which commands run one after the other.
+// Once logged in, the session logs in again whenever it reconnects.
+// A failing command drops the connection, and the next command reconnects.
+// Failures caused by losing the connection, or failing to establish it, are classified as console disconnections.
+type Session struct {
+	mu                  sync.Mutex
+	serialConsoleClient vmiSerialConsoleClient
+	vmiNamespace        string
+	vmiName             string
+	opts                []expect.Option
+	password            string
+	genExpect           *expect.GExpect
+	disconnected        *atomic.Bool
+	commands            int
+}
+
+func NewSession(serialConsoleClient vmiSerialConsoleClient, vmiNamespace, vmiName string, opts ...expect.Option) *Session {
+	return &Session{
+		serialConsoleClient: serialConsoleClient,
+		vmiNamespace:        vmiNamespace,
+		vmiName:             vmiName,
+		opts:                opts,
+	}
+}
+
+// Login logs into the VMI as root on a new connection, dropping the current one,
+// and keeps the password to log in again whenever the session reconnects.
+func (s *Session) Login(password string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.password = password
+	s.disconnect()
+	return s.connect()
+}
+
+// Run runs a single foreground shell command, returning its output and exit code.
+func (s *Session) Run(command string, timeout time.Duration) (output string, exitCode int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(); err != nil {
+		return "", 0, err
+	}
+
+	s.commands++
+	// The sentinels are quoted so the echoed command line does not match them.
+	commandLine := fmt.Sprintf(`echo '%[1]s''_BEGIN_%[2]d'; %[3]s; echo "%[1]s_END_%[2]d:$?"`, sentinel, s.commands, command)
+	outputRegex := regexp.MustCompile(fmt.Sprintf(`(?s)%[1]s_BEGIN_%[2]d\r\n(.*?)%[1]s_END_%[2]d:(\d+)\r\n`, sentinel, s.commands))
+
+	if err := s.genExpect.Send(commandLine + "\n"); err != nil {
+		return "", 0, s.drop(err)
+	}
+
+	_, match, err := s.genExpect.Expect(outputRegex, timeout)
+	if err != nil {
+		return "", 0, s.drop(fmt.Errorf("failed to run %q: %w", command, err))
+	}
+
+	// The rest of the command output may still arrive, and would garble the next command output.
+	exitCode, err = strconv.Atoi(match[2])
+	if err != nil {
+		return "", 0, s.drop(fmt.Errorf("failed to parse the exit code of %q: %w", command, err))
+	}
+
+	return strings.TrimSuffix(strings.ReplaceAll(match[1], CRLF, "\n"), "\n"), exitCode, nil
+}
+
+// ExpectBatch runs the batch on the session connection.
+// NOTE: This functions inherits limitations from `expectBatchWithValidatedSend`, refer to it for more information.
+func (s *Session) ExpectBatch(batch []expect.Batcher, timeout time.Duration) ([]expect.BatchRes, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+
+	resp, err := expectBatchWithValidatedSend(s.genExpect, batch, timeout)
+	if err != nil {
+		return resp, s.drop(err)
+	}
+	return resp, nil
+}
+
+// Close drops the session connection.
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.disconnect()
+}
+
+// connect connects to the VMI console, logging in once the session was logged in, unless already connected.
+func (s *Session) connect() error {
+	if s.genExpect != nil {
+		return nil
+	}
+
+	genExpect, disconnected, err := s.spawnConsole(connectionTimeout)
+	if err != nil {
+		return failure.New(failure.ConsoleDisconnected, err)
+	}
+	s.genExpect, s.disconnected = genExpect, disconnected
+
+	if s.password != "" {
+		if err := loginToCentOSAsRoot(genExpect, s.vmiName, s.password); err != nil {
+			return s.drop(err)
+		}
+	}
+
+	return nil
+}
+
+// drop drops the connection following the error, classifying the error as a console disconnection when the connection was lost.
+func (s *Session) drop(err error) error {
+	if s.disconnected != nil && s.disconnected.Load() {
+		err = failure.New(failure.ConsoleDisconnected, err)
+	}
+	s.disconnect()
+	return err
+}
+
+func (s *Session) disconnect() {
+	if s.genExpect == nil {
+		return
+	}
+
+	if err := s.genExpect.Close(); err != nil {
+		log.Printf("Failed to close the console of VMI \"%s/%s\": %v", s.vmiNamespace, s.vmiName, err)
+	}
+	s.genExpect, s.disconnected = nil, nil
+}
+
+// spawnConsole connects to the VMI console, returning along with its expecter a flag which is set once the connection is lost.
+func (s *Session) spawnConsole(timeout time.Duration) (*expect.GExpect, *atomic.Bool, error) {
+	vmiReader, vmiWriter := io.Pipe()
+	expecterReader, expecterWriter := io.Pipe()
+	resCh := make(chan error, 1)
+
+	startTime := time.Now()
+	con, err := s.serialConsoleClient.VMISerialConsole(s.vmiNamespace, s.vmiName, timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	timeout -= time.Since(startTime)
+
+	closePipes := func() error {
+		expecterWriter.Close()
+		vmiReader.Close()
+		return nil
+	}
+
+	go func() {
+		err := con.Stream(kubecli.StreamOptions{
+			In:  vmiReader,
+			Out: expecterWriter,
+		})
+		// Let the expecter drain the console output and notice the disconnection,
+		// instead of waiting for output until it times out.
+		closePipes()
+		resCh <- err
+	}()
+
+	disconnected := &atomic.Bool{}
+	opts := append([]expect.Option{}, s.opts...)
+	opts = append(opts, expect.SendTimeout(timeout), expect.Verbose(false))
+	genExpect, _, err := expect.SpawnGeneric(&expect.GenOptions{
+		In:  vmiWriter,
+		Out: disconnectDetectingReader{Reader: expecterReader, disconnected: disconnected},
+		Wait: func() error {
+			return <-resCh
+		},
+		Close: closePipes,
+		Check: func() bool { return !disconnected.Load() },
+	}, timeout, opts...)
+	return genExpect, disconnected, err
+}
+
+// disconnectDetectingReader marks the console as disconnected once its output is exhausted.
+type disconnectDetectingReader struct {
+	io.Reader
+	disconnected *atomic.Bool
+}
+
+func (r disconnectDetectingReader) Read(p []byte) (int, error) {
+	n, err := r.Reader.Read(p)
+	if err != nil {
+		r.disconnected.Store(true)
+	}
+	return n, err
+}
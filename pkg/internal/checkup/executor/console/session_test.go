@@ -0,0 +1,130 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package console_test
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"kubevirt.io/client-go/kubecli"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/client/fake"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+)
+
+const (
+	testNamespace = "test-ns"
+	testVMIName   = "test-vmi"
+
+	commandTimeout = 10 * time.Second
+)
+
+func TestSessionRunsCommandsOnASingleConnection(t *testing.T) {
+	client := &serialConsoleClientStub{serialConsole: fake.NewSerialConsole(testVMIName, fake.WithLoggedInUser())}
+	session := console.NewSession(client, testNamespace, testVMIName)
+	defer session.Close()
+
+	assert.NoError(t, session.Login(config.VMIPassword))
+
+	output, exitCode, err := session.Run("uname -r", commandTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.GuestKernelVersion, output)
+	assert.Zero(t, exitCode)
+
+	output, exitCode, err = session.Run("no-such-command --help", commandTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, "-bash: no-such-command: command not found", output)
+	assert.Equal(t, 127, exitCode)
+
+	assert.Equal(t, 1, client.connections)
+}
+
+func TestSessionShouldReconnectOnceDisconnected(t *testing.T) {
+	client := &serialConsoleClientStub{
+		serialConsole: fake.NewSerialConsole(testVMIName, fake.WithLoggedInUser(), fake.WithDisconnectOn("uname ")),
+	}
+	session := console.NewSession(client, testNamespace, testVMIName)
+	defer session.Close()
+
+	assert.NoError(t, session.Login(config.VMIPassword))
+
+	_, _, err := session.Run("uname -r", commandTimeout)
+	assert.Error(t, err)
+	assert.Equal(t, failure.ConsoleDisconnected, failure.CodeOf(err))
+
+	output, exitCode, err := session.Run("cat /proc/cmdline", commandTimeout)
+	assert.NoError(t, err)
+	assert.Contains(t, output, "BOOT_IMAGE=")
+	assert.Zero(t, exitCode)
+
+	assert.Equal(t, 2, client.connections)
+}
+
+func TestSessionShouldReconnectWhenTheExitCodeCannotBeParsed(t *testing.T) {
+	const overflowingExitCodeCommand = "print-overflowing-exit-code"
+	client := &serialConsoleClientStub{
+		serialConsole: fake.NewSerialConsole(testVMIName,
+			fake.WithLoggedInUser(),
+			fake.WithCommandOutput(overflowingExitCodeCommand, "RTCHECKUP_END_1:99999999999999999999999", 0),
+		),
+	}
+	session := console.NewSession(client, testNamespace, testVMIName)
+	defer session.Close()
+
+	assert.NoError(t, session.Login(config.VMIPassword))
+
+	_, _, err := session.Run(overflowingExitCodeCommand, commandTimeout)
+	assert.ErrorContains(t, err, "failed to parse the exit code")
+
+	output, exitCode, err := session.Run("uname -r", commandTimeout)
+	assert.NoError(t, err)
+	assert.Equal(t, fake.GuestKernelVersion, output)
+	assert.Zero(t, exitCode)
+
+	assert.Equal(t, 2, client.connections)
+}
+
+type serialConsoleClientStub struct {
+	serialConsole *fake.SerialConsole
+	connections   int
+}
+
+func (c *serialConsoleClientStub) VMISerialConsole(_, _ string, _ time.Duration) (kubecli.StreamInterface, error) {
+	c.connections++
+	return c.serialConsole, nil
+}
+
+func TestGetGuestBootTimestampsShouldKeepTheFirstTimeOfEachStep(t *testing.T) {
+	client := &serialConsoleClientStub{serialConsole: fake.NewSerialConsole(testVMIName, fake.WithLoggedInUser())}
+	expecter := console.NewExpecter(client, testNamespace, testVMIName)
+	defer expecter.Close()
+
+	timestamps, err := expecter.GetGuestBootTimestamps(config.BootScriptTimingFileFullPath)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]time.Time{
+		config.BootStepGuestBooted:   time.Unix(1686045152, 0).UTC(),
+		config.BootStepTunedApplied:  time.Unix(1686045171, 0).UTC(),
+		config.BootStepGuestRebooted: time.Unix(1686045203, 0).UTC(),
+	}, timestamps)
+}
@@ -28,28 +28,47 @@ import (
 	"kubevirt.io/client-go/kubecli"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/guestload"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/interrupts"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/oslat"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/rtla"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
+// oslatReportedPercentiles are the latency percentiles reported along with the max latency, telling a rare spike from a steady tail.
+var oslatReportedPercentiles = []float64{99.99, 99.999, 99.9999}
+
 type vmiSerialConsoleClient interface {
 	VMISerialConsole(namespace, name string, timeout time.Duration) (kubecli.StreamInterface, error)
 }
 
 type Executor struct {
-	vmiSerialClient vmiSerialConsoleClient
-	namespace       string
-	vmiPassword     string
-	OslatDuration   time.Duration
+	vmiSerialClient              vmiSerialConsoleClient
+	namespace                    string
+	vmiPassword                  string
+	OslatDuration                time.Duration
+	OslatMeasurementWindow       time.Duration
+	OslatTraceThreshold          time.Duration
+	RtlaMode                     string
+	RtlaDuration                 time.Duration
+	GuestHousekeepingLoad        string
+	GuestHousekeepingLoadWorkers int
 }
 
 func New(client vmiSerialConsoleClient, namespace string, cfg config.Config) Executor {
 	return Executor{
-		vmiSerialClient: client,
-		namespace:       namespace,
-		vmiPassword:     config.VMIPassword,
-		OslatDuration:   cfg.OslatDuration,
+		vmiSerialClient:              client,
+		namespace:                    namespace,
+		vmiPassword:                  config.VMIPassword,
+		OslatDuration:                cfg.OslatDuration,
+		OslatMeasurementWindow:       cfg.OslatMeasurementWindow,
+		OslatTraceThreshold:          cfg.OslatTraceThreshold,
+		RtlaMode:                     cfg.RtlaMode,
+		RtlaDuration:                 cfg.RtlaDuration,
+		GuestHousekeepingLoad:        cfg.GuestHousekeepingLoad,
+		GuestHousekeepingLoadWorkers: cfg.GuestHousekeepingLoadWorkers,
 	}
 }
 
@@ -57,21 +76,215 @@ func (e Executor) Execute(ctx context.Context, vmiUnderTestName string) (status.
 	log.Printf("Login to VMI under test...")
 	vmiUnderTestConsoleExpecter := console.NewExpecter(e.vmiSerialClient, e.namespace, vmiUnderTestName)
 	if err := vmiUnderTestConsoleExpecter.LoginToCentOSAsRoot(e.vmiPassword); err != nil {
-		return status.Results{}, fmt.Errorf("failed to login to VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err)
+		return status.Results{}, failure.New(failure.GuestLoginFailed,
+			fmt.Errorf("failed to login to VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
 	}
+	defer vmiUnderTestConsoleExpecter.Close()
+	consoleLoggedIn := time.Now()
 
 	kernelArgs, _ := vmiUnderTestConsoleExpecter.GetGuestKernelArgs()
 	log.Printf("VMI under test guest kernel Args: %s", kernelArgs)
 
-	oslatClient := oslat.NewClient(vmiUnderTestConsoleExpecter, e.OslatDuration)
+	guestKernelVersion, err := vmiUnderTestConsoleExpecter.GetGuestKernelVersion()
+	if err != nil {
+		log.Printf("Failed to read the guest kernel version of VMI \"%s/%s\": %v", e.namespace, vmiUnderTestName, err)
+	}
+
+	interruptsClient := interrupts.NewClient(vmiUnderTestConsoleExpecter)
+	interruptsBefore, err := interruptsClient.Snapshot()
+	if err != nil {
+		return status.Results{}, failure.New(failure.ToolExecutionFailed,
+			fmt.Errorf("failed to read the interrupts of VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+	}
+
+	oslatClient := oslat.NewClient(vmiUnderTestConsoleExpecter, e.OslatDuration, oslat.WithTraceThreshold(e.OslatTraceThreshold))
+	results, err := e.runOslatUnderLoad(ctx, vmiUnderTestConsoleExpecter, oslatClient, vmiUnderTestName)
+	if err != nil {
+		return status.Results{}, err
+	}
+	results.GuestKernelVersion = guestKernelVersion
+	results.Timing = e.guestTiming(vmiUnderTestConsoleExpecter, vmiUnderTestName)
+	results.Timing.ConsoleLoggedIn = consoleLoggedIn
+	results.GuestHousekeepingLoad = e.GuestHousekeepingLoad
+	results.GuestHousekeepingLoadWorkers = e.GuestHousekeepingLoadWorkers
+
+	interruptsAfter, err := interruptsClient.Snapshot()
+	if err != nil {
+		return status.Results{}, failure.New(failure.ToolExecutionFailed,
+			fmt.Errorf("failed to read the interrupts of VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+	}
+	if results.MeasuredCPUsInterrupts, err = accountInterrupts(interruptsBefore, interruptsAfter); err != nil {
+		return status.Results{}, err
+	}
+
+	if e.OslatTraceThreshold > 0 && results.OslatMaxLatency > e.OslatTraceThreshold {
+		log.Printf("Max Oslat Latency exceeded the trace threshold (%s), collecting the kernel trace...", e.OslatTraceThreshold.String())
+		if results.OslatTrace, err = oslatClient.CollectTrace(ctx); err != nil {
+			log.Printf("Failed to collect the kernel trace from VMI \"%s/%s\": %v", e.namespace, vmiUnderTestName, err)
+		}
+	}
+
+	if e.RtlaMode != "" {
+		if results.Rtla, err = e.runRtla(ctx, vmiUnderTestConsoleExpecter, vmiUnderTestName); err != nil {
+			return status.Results{}, err
+		}
+	}
+
+	return results, nil
+}
+
+// BootScriptStatus returns the status file the boot script recorded in the VM under test.
+// It gives up once the context is done, leaving the console to be closed once the login or the read returns.
+func (e Executor) BootScriptStatus(ctx context.Context, vmiName string) (string, error) {
+	type result struct {
+		content string
+		err     error
+	}
+
+	resultCh := make(chan result, 1)
+	go func() {
+		expecter := console.NewExpecter(e.vmiSerialClient, e.namespace, vmiName)
+		defer expecter.Close()
+
+		if err := expecter.LoginToCentOSAsRoot(e.vmiPassword); err != nil {
+			resultCh <- result{"", fmt.Errorf("failed to login to VMI \"%s/%s\": %w", e.namespace, vmiName, err)}
+			return
+		}
+
+		content, err := expecter.ReadFile(config.BootScriptStatusFileFullPath)
+		resultCh <- result{content, err}
+	}()
+
+	select {
+	case res := <-resultCh:
+		return res.content, res.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("failed to read the boot script status of VMI \"%s/%s\": %w", e.namespace, vmiName, ctx.Err())
+	}
+}
+
+// guestTiming returns the times of the guest boot steps, as recorded by the boot script.
+func (e Executor) guestTiming(expecter console.Expecter, vmiUnderTestName string) status.Timing {
+	bootTimestamps, err := expecter.GetGuestBootTimestamps(config.BootScriptTimingFileFullPath)
+	if err != nil {
+		log.Printf("Failed to read the boot steps timing of VMI \"%s/%s\": %v", e.namespace, vmiUnderTestName, err)
+		return status.Timing{}
+	}
+
+	return status.Timing{
+		GuestBooted:   bootTimestamps[config.BootStepGuestBooted],
+		TunedApplied:  bootTimestamps[config.BootStepTunedApplied],
+		GuestRebooted: bootTimestamps[config.BootStepGuestRebooted],
+	}
+}
+
+// runOslatUnderLoad runs oslat while the optional guest housekeeping load runs, stopping the load once oslat is done.
+func (e Executor) runOslatUnderLoad(ctx context.Context, expecter console.Expecter, oslatClient *oslat.Client,
+	vmiUnderTestName string) (status.Results, error) {
+	if e.GuestHousekeepingLoad != "" {
+		log.Printf("Starting a guest %s load with %d workers on the housekeeping vCPUs %s...",
+			e.GuestHousekeepingLoad, e.GuestHousekeepingLoadWorkers, config.VMUnderTestHousekeepingCPUs)
+		loadClient := guestload.NewClient(expecter, e.GuestHousekeepingLoad, e.GuestHousekeepingLoadWorkers)
+		if err := loadClient.Start(e.OslatDuration + config.OslatTimeoutGrace); err != nil {
+			return status.Results{}, failure.New(failure.ToolExecutionFailed,
+				fmt.Errorf("failed to start the guest load on VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+		}
+		defer func() {
+			if err := loadClient.Stop(); err != nil {
+				log.Printf("Failed to stop the guest load on VMI \"%s/%s\": %v", e.namespace, vmiUnderTestName, err)
+			}
+		}()
+	}
+
+	if e.OslatMeasurementWindow > 0 {
+		return e.runOslatWindows(ctx, oslatClient, vmiUnderTestName)
+	}
+	return e.runOslat(ctx, oslatClient, vmiUnderTestName)
+}
+
+// runRtla runs rtla once oslat is done, as the noise it measures may otherwise be caused by oslat.
+func (e Executor) runRtla(ctx context.Context, expecter console.Expecter, vmiUnderTestName string) (*status.RtlaResults, error) {
+	log.Printf("Running rtla %s on VMI under test for %s...", e.RtlaMode, e.RtlaDuration.String())
+	rtlaResults, err := rtla.NewClient(expecter, e.RtlaMode, e.RtlaDuration).Run(ctx)
+	if err != nil {
+		return nil, failure.New(failure.ToolExecutionFailed,
+			fmt.Errorf("failed to run rtla on VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+	}
+
+	return &rtlaResults, nil
+}
+
+func (e Executor) runOslat(ctx context.Context, oslatClient *oslat.Client, vmiUnderTestName string) (status.Results, error) {
 	log.Printf("Running Oslat test on VMI under test for %s...", e.OslatDuration.String())
-	maxLatency, err := oslatClient.Run(ctx)
+	measurement, err := oslatClient.Measure(ctx, oslatReportedPercentiles)
+	if err != nil {
+		return status.Results{}, failure.New(failure.ToolExecutionFailed,
+			fmt.Errorf("failed to run Oslat on VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+	}
+	log.Printf("Max Oslat Latency measured: %s", measurement.MaxLatency.String())
+	for _, percentile := range measurement.Percentiles {
+		log.Printf("Oslat Latency %gth percentile: %s", percentile.Percent, percentile.Latency.String())
+	}
+
+	return oslatMeasurementResults(measurement), nil
+}
+
+// OslatResults returns the results the checkup reports out of a single window oslat output.
+func OslatResults(oslatOutput string) (status.Results, error) {
+	measurement, err := oslat.ParseMeasurement(oslatOutput, oslatReportedPercentiles)
 	if err != nil {
-		return status.Results{}, fmt.Errorf("failed to run Oslat on VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err)
+		return status.Results{}, err
 	}
-	log.Printf("Max Oslat Latency measured: %s", maxLatency.String())
 
+	return oslatMeasurementResults(measurement), nil
+}
+
+func oslatMeasurementResults(measurement oslat.Measurement) status.Results {
 	return status.Results{
-		OslatMaxLatency: maxLatency,
-	}, nil
+		OslatMaxLatency:       measurement.MaxLatency,
+		OslatCoreMaxLatencies: measurement.CoreMaxLatencies,
+		OslatPercentiles:      measurement.Percentiles,
+	}
+}
+
+func (e Executor) runOslatWindows(ctx context.Context, oslatClient *oslat.Client, vmiUnderTestName string) (status.Results, error) {
+	log.Printf("Running Oslat test on VMI under test for %s, in %s windows...",
+		e.OslatDuration.String(), e.OslatMeasurementWindow.String())
+	windows, err := oslatClient.RunWindows(ctx, e.OslatMeasurementWindow)
+	if err != nil {
+		return status.Results{}, failure.New(failure.ToolExecutionFailed,
+			fmt.Errorf("failed to run Oslat on VMI \"%s/%s\": %w", e.namespace, vmiUnderTestName, err))
+	}
+
+	results := status.Results{
+		OslatWindows:                     windows,
+		OslatSpikesMinDetectableInterval: oslat.MinDetectableSpikesInterval(e.OslatMeasurementWindow),
+	}
+	for _, window := range windows {
+		results.OslatMaxLatency = max(results.OslatMaxLatency, window.MaxLatency)
+	}
+	log.Printf("Max Oslat Latency measured: %s", results.OslatMaxLatency.String())
+
+	if results.OslatSpikesInterval = oslat.DetectPeriodicSpikes(windows); results.OslatSpikesInterval > 0 {
+		log.Printf("Latency spikes recur every %s, suggesting a periodic interferer", results.OslatSpikesInterval.String())
+	}
+
+	return results, nil
+}
+
+func accountInterrupts(before, after interrupts.Snapshot) (*status.InterruptAccounting, error) {
+	measuredCPUs, err := interrupts.ParseCPUList(config.VMUnderTestIsolatedCPUs)
+	if err != nil {
+		return nil, err
+	}
+
+	accounting := interrupts.Deltas(before, after, measuredCPUs)
+	for _, count := range accounting.Interrupts {
+		log.Printf("Interrupt %q hit measured CPU %d %d times", count.Source, count.CPU, count.Count)
+	}
+	for _, count := range accounting.SoftIRQs {
+		log.Printf("Softirq %q hit measured CPU %d %d times", count.Source, count.CPU, count.Count)
+	}
+
+	return &accounting, nil
 }
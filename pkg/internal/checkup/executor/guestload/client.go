@@ -0,0 +1,117 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package guestload
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	expect "github.com/google/goexpect"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+)
+
+type consoleExpecter interface {
+	SafeExpectBatchWithResponse(expected []expect.Batcher, timeout time.Duration) ([]expect.BatchRes, error)
+}
+
+type Client struct {
+	consoleExpecter consoleExpecter
+	load            string
+	workers         int
+}
+
+func NewClient(vmiUnderTestConsoleExpecter consoleExpecter, load string, workers int) *Client {
+	return &Client{
+		consoleExpecter: vmiUnderTestConsoleExpecter,
+		load:            load,
+		workers:         workers,
+	}
+}
+
+const commandTimeout = 30 * time.Second
+
+// Start runs stress-ng in the background on the housekeeping vCPUs.
+// The load stops by itself once the given duration elapses, in case Stop is never called.
+func (c Client) Start(duration time.Duration) error {
+	batch := []expect.Batcher{
+		&expect.BSnd{S: buildStressCmd(c.load, c.workers, duration) + "\n"},
+		&expect.BExp{R: console.PromptExpression},
+		&expect.BSnd{S: "sleep 1; pgrep -x stress-ng > /dev/null\n"},
+		&expect.BExp{R: console.PromptExpression},
+		&expect.BSnd{S: "echo $?\n"},
+		&expect.BExp{R: console.PromptExpression},
+	}
+	resp, err := c.consoleExpecter.SafeExpectBatchWithResponse(batch, commandTimeout)
+	if err != nil {
+		return err
+	}
+
+	const expectedResponses = 3
+	if len(resp) != expectedResponses {
+		return fmt.Errorf("unexpected number of responses: %d", len(resp))
+	}
+
+	exitCode, err := getExitCode(resp[2].Output)
+	if err != nil {
+		return fmt.Errorf("failed to get the guest %s load status: %w", c.load, err)
+	}
+	if exitCode != 0 {
+		return fmt.Errorf("guest %s load is not running", c.load)
+	}
+
+	return nil
+}
+
+// Stop kills the background load and waits for it to exit.
+func (c Client) Stop() error {
+	batch := []expect.Batcher{
+		&expect.BSnd{S: "pkill -x stress-ng; wait\n"},
+		&expect.BExp{R: console.PromptExpression},
+	}
+	_, err := c.consoleExpecter.SafeExpectBatchWithResponse(batch, commandTimeout)
+	return err
+}
+
+// buildStressCmd builds a background stress-ng command, pinned to the housekeeping vCPUs.
+func buildStressCmd(load string, workers int, duration time.Duration) string {
+	stressors := map[string]string{
+		config.GuestCPULoad:    "cpu",
+		config.GuestMemoryLoad: "stream",
+		config.GuestTLBLoad:    "tlb-shootdown",
+	}
+
+	return fmt.Sprintf("stress-ng --taskset %s --%s %d --timeout %ds --quiet > /dev/null 2>&1 &",
+		config.VMUnderTestHousekeepingCPUs, stressors[load], workers, int64(duration.Seconds()))
+}
+
+func getExitCode(output string) (int, error) {
+	matches := regexp.MustCompile(`\r\n(\d+)\r\n`).FindStringSubmatch(output)
+
+	const expectedMatches = 2
+	if len(matches) != expectedMatches {
+		return 0, fmt.Errorf("failed to parse exit value")
+	}
+
+	return strconv.Atoi(matches[1])
+}
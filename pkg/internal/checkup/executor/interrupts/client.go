@@ -0,0 +1,83 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package interrupts
+
+import (
+	"fmt"
+	"time"
+
+	expect "github.com/google/goexpect"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+)
+
+type consoleExpecter interface {
+	SafeExpectBatchWithResponse(expected []expect.Batcher, timeout time.Duration) ([]expect.BatchRes, error)
+}
+
+type Client struct {
+	consoleExpecter consoleExpecter
+}
+
+func NewClient(vmiUnderTestConsoleExpecter consoleExpecter) *Client {
+	return &Client{consoleExpecter: vmiUnderTestConsoleExpecter}
+}
+
+// Snapshot reads the guest interrupts, softirqs and scheduler counters.
+func (c Client) Snapshot() (Snapshot, error) {
+	const (
+		interruptsCmd   = "cat /proc/interrupts\n"
+		softIRQsCmd     = "cat /proc/softirqs\n"
+		schedStatCmd    = "cat /proc/schedstat\n"
+		snapshotTimeout = 30 * time.Second
+	)
+
+	batch := []expect.Batcher{
+		&expect.BSnd{S: interruptsCmd},
+		&expect.BExp{R: console.PromptExpression},
+		&expect.BSnd{S: softIRQsCmd},
+		&expect.BExp{R: console.PromptExpression},
+		&expect.BSnd{S: schedStatCmd},
+		&expect.BExp{R: console.PromptExpression},
+	}
+	resp, err := c.consoleExpecter.SafeExpectBatchWithResponse(batch, snapshotTimeout)
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	const expectedResponses = 3
+	if len(resp) != expectedResponses {
+		return Snapshot{}, fmt.Errorf("unexpected number of responses: %d", len(resp))
+	}
+
+	var snapshot Snapshot
+	if snapshot.Interrupts, err = ParseCounters(resp[0].Output); err != nil {
+		return Snapshot{}, failure.New(failure.ResultParseFailed, fmt.Errorf("failed to parse the interrupts: %w", err))
+	}
+	if snapshot.SoftIRQs, err = ParseCounters(resp[1].Output); err != nil {
+		return Snapshot{}, failure.New(failure.ResultParseFailed, fmt.Errorf("failed to parse the softirqs: %w", err))
+	}
+	if snapshot.ContextSwitches, err = ParseSchedStat(resp[2].Output); err != nil {
+		return Snapshot{}, failure.New(failure.ResultParseFailed, fmt.Errorf("failed to parse the scheduler statistics: %w", err))
+	}
+
+	return snapshot, nil
+}
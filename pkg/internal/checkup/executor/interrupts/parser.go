@@ -0,0 +1,229 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package interrupts
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+var (
+	ErrInvalidCPUList  = errors.New("invalid CPU list")
+	ErrNoCPUColumns    = errors.New("no CPU columns header found")
+	ErrNoSchedulerStat = errors.New("no per CPU scheduler statistics found")
+)
+
+// Counters are the per CPU counts of each interrupt source, as listed by /proc/interrupts and /proc/softirqs.
+type Counters map[string]map[int]uint64
+
+// Snapshot is the state of the guest interruption counters at a point in time.
+type Snapshot struct {
+	Interrupts Counters
+	SoftIRQs   Counters
+	// ContextSwitches are the per CPU number of switches to a task, taken from /proc/schedstat.
+	ContextSwitches map[int]uint64
+}
+
+// expectedInterrupts are the interrupt sources expected to hit isolated CPUs: the residual tick of nohz_full CPUs.
+var expectedInterrupts = map[string]bool{
+	"LOC": true,
+}
+
+// ParseCounters parses the content of /proc/interrupts or /proc/softirqs.
+// Numbered interrupt sources are named after their number and device, e.g. "24-virtio0-input.0".
+// Lines preceding the CPU columns header, such as the echoed command, are ignored.
+func ParseCounters(output string) (Counters, error) {
+	var cpuColumns []int
+	counters := Counters{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if cpuColumns == nil {
+			cpuColumns = parseCPUColumns(fields)
+			continue
+		}
+
+		if len(fields) < 2 || !strings.HasSuffix(fields[0], ":") {
+			continue
+		}
+
+		source := strings.TrimSuffix(fields[0], ":")
+		perCPUCounts := map[int]uint64{}
+		column := 0
+		for ; column < len(cpuColumns) && column+1 < len(fields); column++ {
+			count, err := strconv.ParseUint(fields[column+1], 10, 64)
+			if err != nil {
+				break
+			}
+			perCPUCounts[cpuColumns[column]] = count
+		}
+		if column == 0 {
+			continue
+		}
+
+		if description := fields[column+1:]; len(description) > 0 && isNumber(source) {
+			source += "-" + description[len(description)-1]
+		}
+		counters[source] = perCPUCounts
+	}
+
+	if cpuColumns == nil {
+		return nil, ErrNoCPUColumns
+	}
+
+	return counters, nil
+}
+
+// ParseSchedStat parses the per CPU number of switches to a task (the pcount field) out of /proc/schedstat.
+func ParseSchedStat(output string) (map[int]uint64, error) {
+	const pcountField = 9
+
+	contextSwitches := map[int]uint64{}
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) <= pcountField || !strings.HasPrefix(fields[0], "cpu") {
+			continue
+		}
+
+		cpu, err := strconv.Atoi(strings.TrimPrefix(fields[0], "cpu"))
+		if err != nil {
+			continue
+		}
+		pcount, err := strconv.ParseUint(fields[pcountField], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scheduler statistics of CPU %d: %w", cpu, err)
+		}
+		contextSwitches[cpu] = pcount
+	}
+
+	if len(contextSwitches) == 0 {
+		return nil, ErrNoSchedulerStat
+	}
+
+	return contextSwitches, nil
+}
+
+// ParseCPUList parses a CPU list such as "2-3,5".
+func ParseCPUList(cpuList string) ([]int, error) {
+	var cpus []int
+	for _, cpuRange := range strings.Split(cpuList, ",") {
+		first, last, isRange := strings.Cut(cpuRange, "-")
+		if !isRange {
+			last = first
+		}
+
+		firstCPU, err := strconv.Atoi(first)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCPUList, cpuList)
+		}
+		lastCPU, err := strconv.Atoi(last)
+		if err != nil || lastCPU < firstCPU {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCPUList, cpuList)
+		}
+
+		for cpu := firstCPU; cpu <= lastCPU; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+
+	return cpus, nil
+}
+
+// Deltas accounts for the interruptions of the given CPUs between the snapshots.
+// Only the interrupt sources which hit the CPUs are listed, sorted by source and CPU.
+func Deltas(before, after Snapshot, cpus []int) status.InterruptAccounting {
+	accounting := status.InterruptAccounting{
+		Interrupts:      counterDeltas(before.Interrupts, after.Interrupts, cpus),
+		SoftIRQs:        counterDeltas(before.SoftIRQs, after.SoftIRQs, cpus),
+		ContextSwitches: map[int]uint64{},
+	}
+
+	for _, cpu := range cpus {
+		accounting.ContextSwitches[cpu] = delta(before.ContextSwitches[cpu], after.ContextSwitches[cpu])
+	}
+
+	return accounting
+}
+
+// Unexpected returns the counts of interrupt sources which are not expected to hit isolated CPUs.
+func Unexpected(counts []status.InterruptCount) []status.InterruptCount {
+	var unexpected []status.InterruptCount
+	for _, count := range counts {
+		if !expectedInterrupts[count.Source] {
+			unexpected = append(unexpected, count)
+		}
+	}
+
+	return unexpected
+}
+
+func counterDeltas(before, after Counters, cpus []int) []status.InterruptCount {
+	var counts []status.InterruptCount
+	for source, perCPUCounts := range after {
+		for _, cpu := range cpus {
+			if count := delta(before[source][cpu], perCPUCounts[cpu]); count > 0 {
+				counts = append(counts, status.InterruptCount{Source: source, CPU: cpu, Count: count})
+			}
+		}
+	}
+
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Source != counts[j].Source {
+			return counts[i].Source < counts[j].Source
+		}
+		return counts[i].CPU < counts[j].CPU
+	})
+
+	return counts
+}
+
+// delta returns the counter increase, treating a decrease (e.g. on counter wraparound) as no increase.
+func delta(before, after uint64) uint64 {
+	if after < before {
+		return 0
+	}
+	return after - before
+}
+
+func parseCPUColumns(fields []string) []int {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	cpuColumns := make([]int, 0, len(fields))
+	for _, field := range fields {
+		cpu, err := strconv.Atoi(strings.TrimPrefix(field, "CPU"))
+		if !strings.HasPrefix(field, "CPU") || err != nil {
+			return nil
+		}
+		cpuColumns = append(cpuColumns, cpu)
+	}
+
+	return cpuColumns
+}
+
+func isNumber(s string) bool {
+	_, err := strconv.Atoi(s)
+	return err == nil
+}
@@ -0,0 +1,157 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package interrupts_test
+
+import (
+	"strings"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/interrupts"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+const (
+	interruptsOutput = "cat /proc/interrupts\n" +
+		"           CPU0       CPU1       CPU2       CPU3       \n" +
+		"  0:         38          0          0          0   IO-APIC   2-edge      timer\n" +
+		" 27:          0        412          0          3   PCI-MSI 49153-edge      virtio0-input.0\n" +
+		"LOC:      21734      18552       1180       1176   Local timer interrupts\n" +
+		"ERR:          0\n" +
+		"[root@realtime-vmi-under-test-abcde ~]# "
+
+	softIRQsOutput = "                    CPU0       CPU1       CPU2       CPU3       \n" +
+		"          HI:          1          0          0          0\n" +
+		"       TIMER:       4122       3870        209        207\n"
+
+	schedStatOutput = "cat /proc/schedstat\n" +
+		"version 15\n" +
+		"timestamp 4295242547\n" +
+		"cpu0 0 0 0 0 0 0 43213648301 5093172436 30142\n" +
+		"domain0 0f 2215 2211 4 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0\n" +
+		"cpu2 0 0 0 0 0 0 1296534807 80231664 344\n" +
+		"[root@realtime-vmi-under-test-abcde ~]# "
+)
+
+func TestParseCounters(t *testing.T) {
+	t.Run("interrupts", func(t *testing.T) {
+		counters, err := interrupts.ParseCounters(strings.ReplaceAll(interruptsOutput, "\n", "\r\n"))
+		assert.NoError(t, err)
+
+		expectedCounters := interrupts.Counters{
+			"0-timer":            {0: 38, 1: 0, 2: 0, 3: 0},
+			"27-virtio0-input.0": {0: 0, 1: 412, 2: 0, 3: 3},
+			"LOC":                {0: 21734, 1: 18552, 2: 1180, 3: 1176},
+			"ERR":                {0: 0},
+		}
+		assert.Equal(t, expectedCounters, counters)
+	})
+
+	t.Run("softirqs", func(t *testing.T) {
+		counters, err := interrupts.ParseCounters(softIRQsOutput)
+		assert.NoError(t, err)
+
+		expectedCounters := interrupts.Counters{
+			"HI":    {0: 1, 1: 0, 2: 0, 3: 0},
+			"TIMER": {0: 4122, 1: 3870, 2: 209, 3: 207},
+		}
+		assert.Equal(t, expectedCounters, counters)
+	})
+
+	t.Run("only online CPUs are listed", func(t *testing.T) {
+		const output = "           CPU0       CPU2\n" +
+			"LOC:      21734       1180   Local timer interrupts\n"
+
+		counters, err := interrupts.ParseCounters(output)
+		assert.NoError(t, err)
+		assert.Equal(t, interrupts.Counters{"LOC": {0: 21734, 2: 1180}}, counters)
+	})
+
+	t.Run("fails without a CPU columns header", func(t *testing.T) {
+		_, err := interrupts.ParseCounters("cat: /proc/interrupts: No such file or directory\n")
+		assert.ErrorIs(t, err, interrupts.ErrNoCPUColumns)
+	})
+}
+
+func TestParseSchedStat(t *testing.T) {
+	contextSwitches, err := interrupts.ParseSchedStat(schedStatOutput)
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]uint64{0: 30142, 2: 344}, contextSwitches)
+
+	_, err = interrupts.ParseSchedStat("version 15\ntimestamp 4295242547\n")
+	assert.ErrorIs(t, err, interrupts.ErrNoSchedulerStat)
+}
+
+func TestParseCPUList(t *testing.T) {
+	cpus, err := interrupts.ParseCPUList("2-3,5")
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 3, 5}, cpus)
+
+	for _, invalidCPUList := range []string{"", "a-b", "3-2", "2,"} {
+		_, err := interrupts.ParseCPUList(invalidCPUList)
+		assert.ErrorIs(t, err, interrupts.ErrInvalidCPUList, invalidCPUList)
+	}
+}
+
+func TestDeltas(t *testing.T) {
+	before := interrupts.Snapshot{
+		Interrupts: interrupts.Counters{
+			"LOC":                {0: 100, 1: 100, 2: 10, 3: 10},
+			"27-virtio0-input.0": {0: 0, 1: 412, 2: 0, 3: 3},
+		},
+		SoftIRQs: interrupts.Counters{
+			"TIMER": {0: 50, 1: 50, 2: 5, 3: 5},
+		},
+		ContextSwitches: map[int]uint64{0: 1000, 1: 1000, 2: 40, 3: 41},
+	}
+	after := interrupts.Snapshot{
+		Interrupts: interrupts.Counters{
+			"LOC":                {0: 200, 1: 200, 2: 70, 3: 70},
+			"27-virtio0-input.0": {0: 0, 1: 480, 2: 0, 3: 4},
+			"RES":                {0: 3, 1: 0, 2: 0, 3: 0},
+		},
+		SoftIRQs: interrupts.Counters{
+			"TIMER": {0: 150, 1: 150, 2: 5, 3: 5},
+		},
+		ContextSwitches: map[int]uint64{0: 2000, 1: 2000, 2: 44, 3: 45},
+	}
+
+	expectedAccounting := status.InterruptAccounting{
+		Interrupts: []status.InterruptCount{
+			{Source: "27-virtio0-input.0", CPU: 3, Count: 1},
+			{Source: "LOC", CPU: 2, Count: 60},
+			{Source: "LOC", CPU: 3, Count: 60},
+		},
+		ContextSwitches: map[int]uint64{2: 4, 3: 4},
+	}
+	assert.Equal(t, expectedAccounting, interrupts.Deltas(before, after, []int{2, 3}))
+}
+
+func TestUnexpected(t *testing.T) {
+	counts := []status.InterruptCount{
+		{Source: "27-virtio0-input.0", CPU: 3, Count: 1},
+		{Source: "CAL", CPU: 2, Count: 2},
+		{Source: "LOC", CPU: 2, Count: 60},
+	}
+
+	assert.Equal(t, counts[:2], interrupts.Unexpected(counts))
+	assert.Empty(t, interrupts.Unexpected(counts[2:]))
+}
@@ -20,7 +20,6 @@
 package oslat
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"log"
@@ -29,189 +28,350 @@ import (
 	"strings"
 	"time"
 
-	expect "github.com/google/goexpect"
+	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
 type consoleExpecter interface {
-	SafeExpectBatchWithResponse(expected []expect.Batcher, timeout time.Duration) ([]expect.BatchRes, error)
+	Run(command string, timeout time.Duration) (output string, exitCode int, err error)
 }
 
+const (
+	// OutputFile and ExitCodeFile are where the detached oslat run writes its output and exit code in the guest.
+	OutputFile   = "/tmp/realtime-checkup-oslat.log"
+	ExitCodeFile = "/tmp/realtime-checkup-oslat.exit"
+
+	defaultPollInterval = 30 * time.Second
+	// pollTimeout bounds each of the console commands used to launch oslat, poll for its completion and fetch its output.
+	pollTimeout = 2 * time.Minute
+
+	launchStep = "launch oslat"
+	pollStep   = "poll the oslat exit code"
+	fetchStep  = "fetch the oslat output"
+
+	// windowStartMarker is printed along with the epoch seconds before each of the oslat measurement windows.
+	windowStartMarker = "oslat window start:"
+)
+
+var windowStartRegex = regexp.MustCompile(`(?m)^` + windowStartMarker + ` (\d+)\s*$`)
+
 type Client struct {
 	consoleExpecter consoleExpecter
 	testDuration    time.Duration
+	traceThreshold  time.Duration
+	pollInterval    time.Duration
+	pollDelay       time.Duration
+}
+
+type Option func(*Client)
+
+// WithTraceThreshold makes oslat stop the guest kernel tracing once a latency exceeds the given threshold.
+func WithTraceThreshold(traceThreshold time.Duration) Option {
+	return func(c *Client) {
+		c.traceThreshold = traceThreshold
+	}
+}
+
+// WithPollInterval sets the interval at which the detached oslat run is polled for completion.
+func WithPollInterval(pollInterval time.Duration) Option {
+	return func(c *Client) {
+		c.pollInterval = pollInterval
+	}
 }
 
-func NewClient(vmiUnderTestConsoleExpecter consoleExpecter, testDuration time.Duration) *Client {
-	return &Client{
+// WithPollDelay sets how long the detached oslat run is left alone once launched, before it is polled for completion.
+// Zero, the default, leaves the run alone for its expected duration.
+func WithPollDelay(pollDelay time.Duration) Option {
+	return func(c *Client) {
+		c.pollDelay = pollDelay
+	}
+}
+
+func NewClient(vmiUnderTestConsoleExpecter consoleExpecter, testDuration time.Duration, opts ...Option) *Client {
+	c := &Client{
 		consoleExpecter: vmiUnderTestConsoleExpecter,
 		testDuration:    testDuration,
+		pollInterval:    defaultPollInterval,
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
-func (t Client) Run(ctx context.Context) (time.Duration, error) {
-	type result struct {
-		stdout string
-		err    error
+// Measurement is the outcome of a single oslat run.
+type Measurement struct {
+	MaxLatency       time.Duration
+	CoreMaxLatencies []status.CoreLatency
+	// Percentiles are computed out of the oslat histogram, and are missing when the output has none.
+	Percentiles []status.LatencyPercentile
+}
+
+// Measure runs oslat and returns the max latency, per core and over all the cores, along with the given latency percentiles.
+// Oslat runs detached from the console, so the measurement survives console disconnects.
+func (t Client) Measure(ctx context.Context, percents []float64) (Measurement, error) {
+	output, err := t.runDetached(ctx, buildOslatCmd(t.testDuration, t.traceThreshold), t.testDuration)
+	if err != nil {
+		return Measurement{}, err
 	}
 
-	resultCh := make(chan result)
-	go func() {
-		defer close(resultCh)
-		const testTimeoutGrace = 5 * time.Minute
-
-		oslatCmd := buildOslatCmd(t.testDuration)
-
-		resp, err := t.consoleExpecter.SafeExpectBatchWithResponse([]expect.Batcher{
-			&expect.BSnd{S: oslatCmd + "\n"},
-			&expect.BExp{R: console.PromptExpression},
-			&expect.BSnd{S: "echo $?\n"},
-			&expect.BExp{R: console.PromptExpression},
-		},
-			t.testDuration+testTimeoutGrace,
-		)
+	log.Printf("Oslat test completed:\n%v", output)
+	return ParseMeasurement(output, percents)
+}
+
+// ParseMeasurement returns the max latency, per core and over all the cores, along with the given latency percentiles,
+// out of the oslat output.
+func ParseMeasurement(output string, percents []float64) (Measurement, error) {
+	results, err := Parse(output)
+	if err != nil {
+		return Measurement{}, failure.New(failure.ResultParseFailed, fmt.Errorf("failed parsing maximum latency from oslat results: %w", err))
+	}
+
+	measurement := Measurement{MaxLatency: results.MaxLatency()}
+	for i, core := range results.Cores {
+		measurement.CoreMaxLatencies = append(measurement.CoreMaxLatencies, status.CoreLatency{CPU: core, MaxLatency: results.Maximum[i]})
+	}
+
+	if histogram := ParseHistogram(output); len(histogram) > 0 {
+		for _, percent := range percents {
+			measurement.Percentiles = append(measurement.Percentiles,
+				status.LatencyPercentile{Percent: percent, Latency: histogram.Percentile(percent, measurement.MaxLatency)})
+		}
+	}
+
+	return measurement, nil
+}
+
+// runDetached launches the oslat command in the background of the guest, writing its output and exit code to files.
+// It then polls for the exit code over the console session, which reconnects when the connection was lost,
+// and returns the output once the command is done.
+// Failing to reach the console is tolerated until the command is expected to be done, after the given duration.
+func (t Client) runDetached(ctx context.Context, command string, duration time.Duration) (string, error) {
+	for _, launchCommand := range []string{fmt.Sprintf("rm -f %s %s", OutputFile, ExitCodeFile), buildDetachedCmd(command)} {
+		if _, err := t.runCommand(ctx, launchStep, launchCommand, pollTimeout); err != nil {
+			return "", err
+		}
+	}
+
+	var output string
+	conditionFn := func(ctx context.Context) (bool, error) {
+		exitCodeOutput, err := t.runCommand(ctx, pollStep, fmt.Sprintf("cat %s 2>/dev/null || true", ExitCodeFile), pollTimeout)
 		if err != nil {
-			resultCh <- result{"", err}
-			return
+			log.Printf("%v, retrying", err)
+			return false, nil
 		}
 
-		exitCode, err := getExitCode(resp[1].Output)
+		exitCode, err := strconv.Atoi(strings.TrimSpace(exitCodeOutput))
 		if err != nil {
-			resultCh <- result{"", fmt.Errorf("oslat test failed to get exit code: %w", err)}
-			return
+			return false, nil
+		}
+
+		runOutput, err := t.runCommand(ctx, fetchStep, "cat "+OutputFile, pollTimeout)
+		if err != nil {
+			log.Printf("%v, retrying", err)
+			return false, nil
 		}
-		stdout := resp[0].Output
+
 		const successExitCode = 0
 		if exitCode != successExitCode {
-			log.Printf("oslat test returned exit code: %d. stdout: %s", exitCode, stdout)
-			resultCh <- result{stdout, fmt.Errorf("oslat test failed with exit code: %d. See logs for more information", exitCode)}
-			return
+			log.Printf("oslat test returned exit code: %d. stdout: %s", exitCode, runOutput)
+			return false, fmt.Errorf("oslat test failed with exit code: %d. See logs for more information", exitCode)
 		}
+		output = runOutput
 
-		resultCh <- result{stdout, nil}
-	}()
+		return true, nil
+	}
 
-	var res result
-	select {
-	case res = <-resultCh:
-		if res.err != nil {
-			return 0, res.err
+	runCtx, cancel := context.WithTimeout(ctx, duration+config.OslatTimeoutGrace)
+	defer cancel()
+
+	pollDelay := t.pollDelay
+	if pollDelay == 0 {
+		pollDelay = duration
+	}
+	if err := t.pollUntilDone(runCtx, pollDelay, conditionFn); err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("oslat test canceled due to context closing: %w", ctx.Err())
 		}
-	case <-ctx.Done():
-		return 0, fmt.Errorf("oslat test canceled due to context closing: %w", ctx.Err())
+		return "", fmt.Errorf("failed to wait for oslat to complete: %w", err)
 	}
 
-	log.Printf("Oslat test completed:\n%v", res.stdout)
-	return parseMaxLatency(res.stdout)
+	return output, nil
 }
 
-func getExitCode(returnVal string) (int, error) {
-	pattern := `\r\n(\d+)\r\n`
-	re := regexp.MustCompile(pattern)
-	matches := re.FindStringSubmatch(returnVal)
-
-	const minExpectedMatches = 2
-	if len(matches) < minExpectedMatches {
-		return 0, fmt.Errorf("failed to parse exit value")
+// pollUntilDone polls once right after the launch, catching a failing launch at once.
+// As the console traffic raises interrupts in the guest, it then leaves the run alone for the given delay,
+// before polling at the poll interval. A run failing or a console lost meanwhile is only noticed once the delay is over.
+func (t Client) pollUntilDone(ctx context.Context, pollDelay time.Duration, conditionFn wait.ConditionWithContextFunc) error {
+	if done, err := conditionFn(ctx); done || err != nil {
+		return err
 	}
 
-	exitCode, err := strconv.Atoi(matches[1])
-	if err != nil {
-		return 0, err
+	select {
+	case <-time.After(pollDelay):
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 
-	return exitCode, nil
+	return wait.PollImmediateUntilWithContext(ctx, t.pollInterval, conditionFn)
 }
 
-func parseMaxLatency(oslatOutput string) (time.Duration, error) {
-	const maximumKeyword = "Maximum"
-
-	maximumEntryLine, err := getResultEntryByKey(oslatOutput, maximumKeyword)
+// RunWindows runs oslat detached, in consecutive windows of the given duration,
+// and returns the max latency measured in each window.
+func (t Client) RunWindows(ctx context.Context, window time.Duration) ([]status.LatencyWindow, error) {
+	windowsCount := int64((t.testDuration + window - 1) / window)
+	duration := t.testDuration + time.Duration(windowsCount)*config.OslatWindowOverhead
+	output, err := t.runDetached(ctx, buildWindowsCmd(t.testDuration, window, t.traceThreshold), duration)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	maxLatencyValues, units, err := parseMaxEntryLine(maximumEntryLine)
+	windows, err := parseWindows(output)
 	if err != nil {
-		return 0, err
+		log.Printf("Oslat windows output:\n%v", output)
+		return nil, err
 	}
+	log.Printf("Oslat test completed %d windows", len(windows))
 
-	return getMaxLatencyValue(maxLatencyValues, units)
+	return windows, nil
 }
 
-func getResultEntryByKey(input, entryKey string) (string, error) {
-	scanner := bufio.NewScanner(strings.NewReader(input))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if !strings.Contains(line, entryKey) {
-			continue
-		}
-		return line, nil
+// parseWindows returns the max latency of each window, out of the output of the windows command.
+func parseWindows(output string) ([]status.LatencyWindow, error) {
+	output = strings.ReplaceAll(output, console.CRLF, "\n")
+	markers := windowStartRegex.FindAllStringSubmatchIndex(output, -1)
+	if len(markers) == 0 {
+		return nil, failure.New(failure.ResultParseFailed, fmt.Errorf("no oslat window found in the oslat output"))
 	}
-	if scanErr := scanner.Err(); scanErr != nil {
-		return "", scanErr
+
+	var windows []status.LatencyWindow
+	for i, marker := range markers {
+		epochSeconds, err := strconv.ParseInt(output[marker[2]:marker[3]], 10, 64)
+		if err != nil {
+			return nil, failure.New(failure.ResultParseFailed, fmt.Errorf("failed to parse oslat window start time: %w", err))
+		}
+
+		windowEnd := len(output)
+		if i+1 < len(markers) {
+			windowEnd = markers[i+1][0]
+		}
+		maxLatency, err := ParseMaxLatency(output[marker[1]:windowEnd])
+		if err != nil {
+			return nil, fmt.Errorf("oslat window %d: %w", i+1, err)
+		}
+
+		windows = append(windows, status.LatencyWindow{
+			StartTimestamp: time.Unix(epochSeconds, 0).UTC(),
+			MaxLatency:     maxLatency,
+		})
 	}
-	return "", fmt.Errorf("failed parsing maximum latency from oslat results")
+
+	return windows, nil
 }
 
-func extractUnits(line string) (lineWithoutUnits, units string, err error) {
-	re := regexp.MustCompile(`\((.+?)\)`)
-	matches := re.FindStringSubmatch(line)
+// CollectTrace returns the tail of the guest kernel trace buffer.
+func (t Client) CollectTrace(ctx context.Context) (string, error) {
+	const (
+		traceTailLines      = 1000
+		collectTraceTimeout = 2 * time.Minute
+	)
+
+	traceCmd := fmt.Sprintf("tail -n %d %s/trace", traceTailLines, config.GuestTracingDirectory)
+	return t.runCommand(ctx, "collect the kernel trace", traceCmd, collectTraceTimeout)
+}
 
-	const minExpectedMatches = 2
-	if len(matches) < minExpectedMatches {
-		return "", "", fmt.Errorf("units not found in line: %s", line)
+// runCommand runs the command on the console session, failing if it exits with a non-zero code.
+// The errors name the step the command is run for.
+func (t Client) runCommand(ctx context.Context, step, command string, timeout time.Duration) (string, error) {
+	type result struct {
+		output string
+		err    error
 	}
 
-	units = matches[1]
-	lineWithoutUnits = strings.Replace(line, matches[0], "", 1)
-	return lineWithoutUnits, units, nil
+	resultCh := make(chan result, 1)
+	go func() {
+		output, exitCode, err := t.consoleExpecter.Run(command, timeout)
+		if err != nil {
+			resultCh <- result{"", fmt.Errorf("failed to %s: %w", step, err)}
+			return
+		}
+
+		const successExitCode = 0
+		if exitCode != successExitCode {
+			log.Printf("%q returned exit code: %d. stdout: %s", command, exitCode, output)
+			resultCh <- result{"", fmt.Errorf("failed to %s: exit code %d. See logs for more information", step, exitCode)}
+			return
+		}
+
+		resultCh <- result{output, nil}
+	}()
+
+	select {
+	case res := <-resultCh:
+		return res.output, res.err
+	case <-ctx.Done():
+		return "", fmt.Errorf("failed to %s: oslat test canceled due to context closing: %w", step, ctx.Err())
+	}
 }
 
-func parseMaxEntryLine(maximumEntryLine string) (values []string, units string, err error) {
-	const keyValDelimiter = ":"
-	var keyWithValues string
-	keyWithValues, units, err = extractUnits(maximumEntryLine)
+// ParseMaxLatency returns the maximal latency measured over all the cores, out of the oslat output.
+func ParseMaxLatency(oslatOutput string) (time.Duration, error) {
+	results, err := Parse(oslatOutput)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to extract units: %w", err)
+		return 0, failure.New(failure.ResultParseFailed, fmt.Errorf("failed parsing maximum latency from oslat results: %w", err))
 	}
-	keyWithValuesSlice := strings.Split(keyWithValues, keyValDelimiter)
-	return strings.Fields(keyWithValuesSlice[1]), units, nil
+
+	return results.MaxLatency(), nil
 }
 
-func getMaxLatencyValue(values []string, units string) (time.Duration, error) {
-	var coreMaxLatencyDuration time.Duration
-	var maxCoresLatencyDuration time.Duration
-	var err error
-	for _, coreMaxLatencyStr := range values {
-		corMaxLatencyWithUnits := coreMaxLatencyStr + units
-		if coreMaxLatencyDuration, err = time.ParseDuration(corMaxLatencyWithUnits); err != nil {
-			return 0, fmt.Errorf("failed to parse core maximum latency %s: %w", corMaxLatencyWithUnits, err)
-		}
-		if coreMaxLatencyDuration > maxCoresLatencyDuration {
-			maxCoresLatencyDuration = coreMaxLatencyDuration
-		}
+// buildDetachedCmd wraps the command to run in a new session, so it is not hung up on when the console disconnects.
+// It is backgrounded within a subshell, so it can be followed by other commands on the same command line.
+func buildDetachedCmd(command string) string {
+	return fmt.Sprintf("(setsid sh -c '%s > %s 2>&1; echo $? > %s' > /dev/null 2>&1 < /dev/null &)",
+		strings.TrimSpace(command), OutputFile, ExitCodeFile)
+}
+
+// buildWindowsCmd returns a command running oslat in consecutive windows for the whole test duration,
+// the last window being shorter when the test duration is not a multiple of the window.
+// Each window is preceded by its start marker, and the first failing window stops the run with its exit code.
+func buildWindowsCmd(testDuration, window, traceThreshold time.Duration) string {
+	windowStartCmd := fmt.Sprintf(`echo "%s $(date -u +%%s)"`, windowStartMarker)
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("(for i in $(seq %d); do %s; %s || exit $?; done",
+		int64(testDuration/window), windowStartCmd, strings.TrimSpace(buildOslatCmd(window, traceThreshold))))
+	if remainder := testDuration % window; remainder > 0 {
+		sb.WriteString(fmt.Sprintf("; %s; %s", windowStartCmd, strings.TrimSpace(buildOslatCmd(remainder, traceThreshold))))
 	}
-	return maxCoresLatencyDuration, nil
+	sb.WriteString(")")
+
+	return sb.String()
 }
 
-func buildOslatCmd(testDuration time.Duration) string {
+func buildOslatCmd(testDuration, traceThreshold time.Duration) string {
 	const (
-		cpuList          = "2-3"
 		realtimePriority = "1"
 		workload         = "memmove"
 		workloadMemory   = "4K"
 	)
 
 	sb := strings.Builder{}
-	sb.WriteString(fmt.Sprintf("taskset -c %s ", cpuList))
+	sb.WriteString(fmt.Sprintf("taskset -c %s ", config.VMUnderTestIsolatedCPUs))
 	sb.WriteString("oslat ")
-	sb.WriteString(fmt.Sprintf("--cpu-list %s ", cpuList))
+	sb.WriteString(fmt.Sprintf("--cpu-list %s ", config.VMUnderTestIsolatedCPUs))
 	sb.WriteString(fmt.Sprintf("--rtprio %s ", realtimePriority))
 	sb.WriteString(fmt.Sprintf("--duration %s ", testDuration.String()))
 	sb.WriteString(fmt.Sprintf("--workload %s ", workload))
 	sb.WriteString(fmt.Sprintf("--workload-mem %s ", workloadMemory))
+	if traceThreshold > 0 {
+		sb.WriteString(fmt.Sprintf("--trace-threshold %d ", traceThreshold.Microseconds()))
+	}
 
 	return sb.String()
 }
@@ -23,19 +23,20 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 	"time"
 
-	expect "github.com/google/goexpect"
 	assert "github.com/stretchr/testify/require"
 
-	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/oslat"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
 const oslatTestDuration = time.Minute
 
-func TestRunSuccess(t *testing.T) {
+func TestMeasureSuccess(t *testing.T) {
 	expecter := &expecterStub{
 		injectedActualMaxResults: "27 56 (us)",
 	}
@@ -45,14 +46,58 @@ func TestRunSuccess(t *testing.T) {
 		oslatTestDuration,
 	)
 
-	maxLatency, err := oslatClient.Run(context.Background())
-	assert.NoError(t, err, "Run returned an error")
+	measurement, err := oslatClient.Measure(context.Background(), nil)
+	assert.NoError(t, err, "Measure returned an error")
 	expected := 56 * time.Microsecond
-	assert.Equal(t, expected, maxLatency, "Run returned unexpected result")
+	assert.Equal(t, expected, measurement.MaxLatency, "Measure returned unexpected result")
 }
 
-func TestRunFailure(t *testing.T) {
-	t.Run("when console returns batch error", func(t *testing.T) {
+func TestMeasureShouldPassTheTraceThreshold(t *testing.T) {
+	expecter := &expecterStub{
+		injectedActualMaxResults: "27 56 (us)",
+	}
+
+	oslatClient := oslat.NewClient(
+		expecter,
+		oslatTestDuration,
+		oslat.WithTraceThreshold(30*time.Microsecond),
+	)
+
+	measurement, err := oslatClient.Measure(context.Background(), nil)
+	assert.NoError(t, err, "Measure returned an error")
+	assert.Equal(t, 56*time.Microsecond, measurement.MaxLatency, "Measure returned unexpected result")
+	assert.Equal(t, detachedCmd(oslatRunWithTraceThresholdCmd), expecter.launchedCommand)
+}
+
+func TestMeasureShouldSurviveConsoleDisconnects(t *testing.T) {
+	expecter := &expecterStub{
+		injectedActualMaxResults: "27 56 (us)",
+		pollDisconnects:          2,
+	}
+
+	oslatClient := oslat.NewClient(
+		expecter,
+		oslatTestDuration,
+		oslat.WithPollInterval(time.Millisecond),
+		oslat.WithPollDelay(time.Millisecond),
+	)
+
+	measurement, err := oslatClient.Measure(context.Background(), nil)
+	assert.NoError(t, err, "Measure returned an error")
+	assert.Equal(t, 56*time.Microsecond, measurement.MaxLatency, "Measure returned unexpected result")
+	assert.Zero(t, expecter.pollDisconnects)
+}
+
+func TestCollectTraceSuccess(t *testing.T) {
+	oslatClient := oslat.NewClient(&expecterStub{}, oslatTestDuration)
+
+	trace, err := oslatClient.CollectTrace(context.Background())
+	assert.NoError(t, err, "CollectTrace returned an error")
+	assert.Equal(t, traceOutput, trace)
+}
+
+func TestMeasureFailure(t *testing.T) {
+	t.Run("when the console fails to launch oslat", func(t *testing.T) {
 		expectedBatchErr := errors.New("some error")
 		expecter := &expecterStub{
 			expectBatchFailureErr: expectedBatchErr,
@@ -62,8 +107,8 @@ func TestRunFailure(t *testing.T) {
 			oslatTestDuration,
 		)
 
-		_, err := oslatClient.Run(context.Background())
-		assert.ErrorContains(t, err, expectedBatchErr.Error())
+		_, err := oslatClient.Measure(context.Background(), nil)
+		assert.ErrorContains(t, err, "failed to launch oslat: "+expectedBatchErr.Error())
 	})
 	t.Run("when run command returns non-success return value", func(t *testing.T) {
 		expectedRunErr := errors.New("oslat test failed with exit code")
@@ -75,7 +120,7 @@ func TestRunFailure(t *testing.T) {
 			oslatTestDuration,
 		)
 
-		_, err := oslatClient.Run(context.Background())
+		_, err := oslatClient.Measure(context.Background(), nil)
 		assert.ErrorContains(t, err, expectedRunErr.Error())
 	})
 	t.Run("when batch times out", func(t *testing.T) {
@@ -88,7 +133,7 @@ func TestRunFailure(t *testing.T) {
 			oslatTestDuration,
 		)
 
-		_, err := oslatClient.Run(context.Background())
+		_, err := oslatClient.Measure(context.Background(), nil)
 		assert.ErrorContains(t, err, expectedTimeoutErr.Error())
 	})
 	t.Run("when oslat returns invalid data", func(t *testing.T) {
@@ -100,7 +145,7 @@ func TestRunFailure(t *testing.T) {
 			oslatTestDuration,
 		)
 
-		_, err := oslatClient.Run(context.Background())
+		_, err := oslatClient.Measure(context.Background(), nil)
 		assert.ErrorContains(t, err, expectedInvalidOslatOutputErr.Error())
 	})
 	t.Run("when checkup context times out", func(t *testing.T) {
@@ -115,11 +160,76 @@ func TestRunFailure(t *testing.T) {
 		ctx, cancel := context.WithDeadline(context.Background(), exceededDeadline)
 		defer cancel()
 
-		_, err := oslatClient.Run(ctx)
+		_, err := oslatClient.Measure(ctx, nil)
 		assert.ErrorContains(t, err, expectedCheckupTimeoutErr.Error())
 	})
 }
 
+func TestRunWindowsSuccess(t *testing.T) {
+	expecter := &expecterStub{
+		windowsOutput: windowOutput(firstWindowStart, "27 56 (us)") +
+			windowOutput(firstWindowStart+27, "12 14 (us)") +
+			windowOutput(firstWindowStart+54, "31 9 (us)"),
+	}
+	oslatClient := oslat.NewClient(expecter, oslatTestDuration)
+
+	windows, err := oslatClient.RunWindows(context.Background(), oslatTestWindow)
+	assert.NoError(t, err)
+	assert.Equal(t, detachedCmd(oslatWindowsCmd), expecter.launchedCommand)
+
+	expectedWindows := []status.LatencyWindow{
+		{StartTimestamp: time.Unix(firstWindowStart, 0).UTC(), MaxLatency: 56 * time.Microsecond},
+		{StartTimestamp: time.Unix(firstWindowStart+27, 0).UTC(), MaxLatency: 14 * time.Microsecond},
+		{StartTimestamp: time.Unix(firstWindowStart+54, 0).UTC(), MaxLatency: 31 * time.Microsecond},
+	}
+	assert.Equal(t, expectedWindows, windows)
+}
+
+func TestRunWindowsFailure(t *testing.T) {
+	t.Run("when a middle window fails", func(t *testing.T) {
+		expecter := &expecterStub{
+			windowsOutput: windowOutput(firstWindowStart, "27 56 (us)") +
+				oslatWindowStartMarker + " 1686046470\noslat: Failed to set scheduler policy: Operation not permitted\n",
+			expectRunFailureErr: errors.New("oslat test failed with exit code"),
+		}
+		oslatClient := oslat.NewClient(expecter, oslatTestDuration)
+
+		_, err := oslatClient.RunWindows(context.Background(), oslatTestWindow)
+		assert.ErrorContains(t, err, "oslat test failed with exit code: 127")
+	})
+	t.Run("when a middle window output is invalid", func(t *testing.T) {
+		expecter := &expecterStub{
+			windowsOutput: windowOutput(firstWindowStart, "27 56 (us)") +
+				oslatWindowStartMarker + " 1686046470\n" + oslatRunInvalidOutput +
+				windowOutput(firstWindowStart+54, "31 9 (us)"),
+		}
+		oslatClient := oslat.NewClient(expecter, oslatTestDuration)
+
+		_, err := oslatClient.RunWindows(context.Background(), oslatTestWindow)
+		assert.ErrorContains(t, err, "oslat window 2: failed parsing maximum latency from oslat results")
+		assert.Equal(t, failure.ResultParseFailed, failure.CodeOf(err))
+	})
+	t.Run("when the windows do not complete in time", func(t *testing.T) {
+		expecter := &expecterStub{
+			windowsOutput:  windowOutput(firstWindowStart, "27 56 (us)"),
+			exitCodeAbsent: true,
+		}
+		oslatClient := oslat.NewClient(
+			expecter,
+			oslatTestDuration,
+			oslat.WithPollInterval(time.Millisecond),
+			oslat.WithPollDelay(time.Millisecond),
+		)
+
+		const checkupTimeout = 100 * time.Millisecond
+		ctx, cancel := context.WithTimeout(context.Background(), checkupTimeout)
+		defer cancel()
+
+		_, err := oslatClient.RunWindows(ctx, oslatTestWindow)
+		assert.ErrorContains(t, err, "oslat test canceled due to context closing")
+	})
+}
+
 // fakeClock is a custom fake clock implementation
 type fakeClock struct {
 	current time.Time
@@ -137,7 +247,23 @@ func (c fakeClock) Now() time.Time {
 }
 
 const (
-	oslatRunCmd             = "taskset -c 2-3 oslat --cpu-list 2-3 --rtprio 1 --duration 1m0s --workload memmove --workload-mem 4K \n"
+	oslatRunCmd                   = "taskset -c 2-3 oslat --cpu-list 2-3 --rtprio 1 --duration 1m0s --workload memmove --workload-mem 4K \n"
+	oslatRunWithTraceThresholdCmd = "taskset -c 2-3 oslat --cpu-list 2-3 --rtprio 1 --duration 1m0s --workload memmove --workload-mem 4K " +
+		"--trace-threshold 30 \n"
+	oslatTestWindow        = 25 * time.Second
+	oslatWindowStartMarker = "oslat window start:"
+	oslatWindowStartCmd    = `echo "` + oslatWindowStartMarker + ` $(date -u +%s)"`
+	oslatWindowsCmd        = "(for i in $(seq 2); do " + oslatWindowStartCmd + "; " +
+		"taskset -c 2-3 oslat --cpu-list 2-3 --rtprio 1 --duration 25s --workload memmove --workload-mem 4K || exit $?; done; " +
+		oslatWindowStartCmd + "; taskset -c 2-3 oslat --cpu-list 2-3 --rtprio 1 --duration 10s --workload memmove --workload-mem 4K)"
+	firstWindowStart = 1686046443
+
+	collectTraceCmd      = "tail -n 1000 /sys/kernel/tracing/trace"
+	removeOslatFilesCmd  = "rm -f " + oslat.OutputFile + " " + oslat.ExitCodeFile
+	pollOslatExitCodeCmd = "cat " + oslat.ExitCodeFile + " 2>/dev/null || true"
+	fetchOslatOutputCmd  = "cat " + oslat.OutputFile
+	traceOutput          = "# tracer: nop\n" +
+		"           oslat-1432    [002] .....1   312.482934: tracing_mark_write: oslat: Trace threshold (30 us) triggered with 56 us!\n"
 	oslatRunResultsTemplate = "oslat V 2.60\n" +
 		"Total runtime: \t\t60 seconds\n" +
 		"Thread priority: \tSCHED_FIFO:1\n" +
@@ -214,49 +340,79 @@ type expecterStub struct {
 	expectBatchFailureErr    error
 	expectRunFailureErr      error
 	expectRunInvalidOutput   bool
+	// pollDisconnects is the number of times polling for the oslat exit code fails on a console disconnect.
+	pollDisconnects int
+	launchedCommand string
+	// windowsOutput is the output of the oslat measurement windows.
+	windowsOutput string
+	// exitCodeAbsent keeps the oslat run going, as if it never completed.
+	exitCodeAbsent bool
 }
 
-func generateBatchResponseWithRetval(runStdout string, runRetVal int) []expect.BatchRes {
-	return []expect.BatchRes{
-		{
-			Idx:    1,
-			Output: runStdout,
-		},
-		{
-			Idx:    2,
-			Output: fmt.Sprintf("%s%d%s", console.CRLF, runRetVal, console.CRLF),
-		},
+// Run replies to the command the way the console session does, once its output is stripped of the sentinels.
+func (es *expecterStub) Run(command string, _ time.Duration) (output string, exitCode int, err error) {
+	if es.batchRunTimeoutErr != nil {
+		return "", 0, es.batchRunTimeoutErr
+	}
+	if es.expectBatchFailureErr != nil {
+		return "", 0, es.expectBatchFailureErr
 	}
+
+	return es.run(command)
 }
 
-func (es expecterStub) SafeExpectBatchWithResponse(expected []expect.Batcher, _ time.Duration) ([]expect.BatchRes, error) {
+func (es *expecterStub) run(command string) (stdout string, retVal int, err error) {
 	const (
 		successExitCode = 0
 		failureExitCode = 127
 	)
 
-	if es.batchRunTimeoutErr != nil {
-		return nil, es.batchRunTimeoutErr
-	}
-	if es.expectBatchFailureErr != nil {
-		return nil, es.expectBatchFailureErr
-	}
+	switch command {
+	case removeOslatFilesCmd:
+		return "", successExitCode, nil
+
+	case detachedCmd(oslatRunCmd), detachedCmd(oslatRunWithTraceThresholdCmd), detachedCmd(oslatWindowsCmd):
+		es.launchedCommand = command
+		return "", successExitCode, nil
 
-	var batchRes []expect.BatchRes
-	switch expected[0].Arg() {
-	case oslatRunCmd:
+	case pollOslatExitCodeCmd:
+		if es.pollDisconnects > 0 {
+			es.pollDisconnects--
+			return "", 0, errors.New("websocket: close 1006 (abnormal closure): unexpected EOF")
+		}
+		if es.exitCodeAbsent {
+			return "", successExitCode, nil
+		}
+		oslatExitCode := successExitCode
 		if es.expectRunFailureErr != nil {
-			batchRes = generateBatchResponseWithRetval(es.expectRunFailureErr.Error(), failureExitCode)
+			oslatExitCode = failureExitCode
+		}
+		return fmt.Sprintf("%d", oslatExitCode), successExitCode, nil
+
+	case fetchOslatOutputCmd:
+		if es.windowsOutput != "" {
+			return es.windowsOutput, successExitCode, nil
+		} else if es.expectRunFailureErr != nil {
+			return es.expectRunFailureErr.Error(), successExitCode, nil
 		} else if es.expectRunInvalidOutput {
-			batchRes = generateBatchResponseWithRetval(oslatRunInvalidOutput, successExitCode)
-		} else {
-			oslatOutput := fmt.Sprintf(oslatRunResultsTemplate, es.injectedActualMaxResults)
-			batchRes = generateBatchResponseWithRetval(oslatOutput, successExitCode)
+			return oslatRunInvalidOutput, successExitCode, nil
 		}
+		return fmt.Sprintf(oslatRunResultsTemplate, es.injectedActualMaxResults), successExitCode, nil
+
+	case collectTraceCmd:
+		return traceOutput, successExitCode, nil
 
 	default:
-		return nil, fmt.Errorf("command not recognized: %q", expected[0].Arg())
+		return "", 0, fmt.Errorf("command not recognized: %q", command)
 	}
+}
+
+// windowOutput returns the output of a single oslat measurement window.
+func windowOutput(startEpochSeconds int, maxResults string) string {
+	return fmt.Sprintf("%s %d\n", oslatWindowStartMarker, startEpochSeconds) + fmt.Sprintf(oslatRunResultsTemplate, maxResults) + "\n"
+}
 
-	return batchRes, nil
+func detachedCmd(command string) string {
+	return "(setsid sh -c '" + strings.TrimSpace(command) + " > " + oslat.OutputFile + " 2>&1; echo $? > " + oslat.ExitCodeFile +
+		"' > /dev/null 2>&1 < /dev/null &)"
 }
@@ -0,0 +1,381 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package oslat
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Format string
+
+const (
+	TextFormat Format = "text"
+	JSONFormat Format = "json"
+)
+
+const (
+	minimumRow  = "Minimum"
+	averageRow  = "Average"
+	maximumRow  = "Maximum"
+	maxMinRow   = "Max-Min"
+	durationRow = "Duration"
+)
+
+// firstFullSummaryMajorVersion is the first oslat major version, released as part of rt-tests,
+// whose summary includes the Max-Min and Duration rows.
+const firstFullSummaryMajorVersion = 2
+
+var (
+	ErrNoResults         = errors.New("no oslat results found")
+	ErrMissingSummaryRow = errors.New("missing oslat summary row")
+	ErrInvalidSummaryRow = errors.New("invalid oslat summary row")
+)
+
+var (
+	versionRegex = regexp.MustCompile(`(?m)^\s*oslat V\s*(\d+)\.(\d+)`)
+	// A summary row, e.g. "     Maximum:	 12 13 (us)". Anchoring the row name to the line start
+	// keeps messages printed to the console by other programs from being taken for summary rows.
+	summaryRowRegex = regexp.MustCompile(`^\s*(Core|Minimum|Average|Maximum|Max-Min|Duration):\s+(.*?)\s*(?:\((\w+)\))?\s*$`)
+	numberRegex     = regexp.MustCompile(`^\d+(\.\d+)?$`)
+	// A histogram row, e.g. "    012 (us):	 524 211", the last one including the overflows.
+	histogramRowRegex = regexp.MustCompile(`^\s*(\d+) \((\w+)\):\s+([\d\s]+?)\s*(\(including overflows\))?\s*$`)
+)
+
+// Results are the per-core oslat summary rows, ordered by core.
+type Results struct {
+	Format Format
+	// Version is the oslat version, as printed in the output header. It is empty when the header is missing.
+	Version  string
+	Cores    []int
+	Minimum  []time.Duration
+	Average  []time.Duration
+	Maximum  []time.Duration
+	MaxMin   []time.Duration
+	Duration []time.Duration
+}
+
+// Histogram is the oslat latency histogram, summed over all the cores.
+type Histogram []HistogramBucket
+
+// HistogramBucket counts the latencies measured in a single histogram bucket.
+type HistogramBucket struct {
+	Latency time.Duration
+	Count   uint64
+	// Overflow tells the bucket includes all the latencies above it.
+	Overflow bool
+}
+
+// MaxLatency returns the maximal latency measured over all the cores.
+func (r Results) MaxLatency() time.Duration {
+	var maxLatency time.Duration
+	for _, coreMaxLatency := range r.Maximum {
+		if coreMaxLatency > maxLatency {
+			maxLatency = coreMaxLatency
+		}
+	}
+	return maxLatency
+}
+
+// Percentile returns the latency below which the given percentage of the measurements fall.
+// A percentile falling in the overflow bucket is bounded by the given max latency. It is zero for an empty histogram.
+func (h Histogram) Percentile(percent float64, maxLatency time.Duration) time.Duration {
+	var total uint64
+	for _, bucket := range h {
+		total += bucket.Count
+	}
+	if total == 0 {
+		return 0
+	}
+
+	target := uint64(math.Ceil(percent / 100 * float64(total)))
+	var cumulative uint64
+	for _, bucket := range h {
+		cumulative += bucket.Count
+		if cumulative >= target {
+			if bucket.Overflow {
+				return maxLatency
+			}
+			return bucket.Latency
+		}
+	}
+	return maxLatency
+}
+
+// ParseHistogram parses the latency histogram out of the oslat text output.
+// It is empty when the output has no histogram, such as a JSON report.
+func ParseHistogram(output string) Histogram {
+	var histogram Histogram
+	for _, line := range strings.Split(output, "\n") {
+		if bucket, isBucket := parseHistogramRow(strings.TrimRight(line, "\r")); isBucket {
+			histogram = append(histogram, bucket)
+		}
+	}
+	return histogram
+}
+
+// Parse parses the oslat summary out of its text or JSON output.
+// The output may be surrounded by other console output, such as the command line and the shell prompt.
+func Parse(output string) (Results, error) {
+	if jsonStart := strings.Index(output, "{"); jsonStart != -1 && looksLikeJSONReport(output[jsonStart:]) {
+		return parseJSON(output[jsonStart:])
+	}
+
+	return parseText(output)
+}
+
+func parseText(output string) (Results, error) {
+	results := Results{Format: TextFormat}
+	requiredRows := []string{maximumRow}
+
+	if matches := versionRegex.FindStringSubmatch(output); matches != nil {
+		results.Version = matches[1] + "." + matches[2]
+		requiredRows = []string{minimumRow, averageRow, maximumRow}
+		if majorVersion, _ := strconv.Atoi(matches[1]); majorVersion >= firstFullSummaryMajorVersion {
+			requiredRows = append(requiredRows, maxMinRow, durationRow)
+		}
+	}
+
+	rows := map[string][]string{}
+	units := map[string]string{}
+	for _, line := range strings.Split(output, "\n") {
+		matches := summaryRowRegex.FindStringSubmatch(strings.TrimRight(line, "\r"))
+		if matches == nil || !allNumbers(strings.Fields(matches[2])) {
+			continue
+		}
+		rowName := matches[1]
+		if _, exists := rows[rowName]; !exists {
+			rows[rowName] = strings.Fields(matches[2])
+			units[rowName] = matches[3]
+		}
+	}
+
+	if len(rows) == 0 {
+		return Results{}, ErrNoResults
+	}
+
+	for _, rowName := range requiredRows {
+		if _, exists := rows[rowName]; !exists {
+			return Results{}, fmt.Errorf("%w: %q", ErrMissingSummaryRow, rowName)
+		}
+	}
+
+	return results, results.setTextRows(rows, units)
+}
+
+func parseHistogramRow(line string) (HistogramBucket, bool) {
+	matches := histogramRowRegex.FindStringSubmatch(line)
+	if matches == nil {
+		return HistogramBucket{}, false
+	}
+
+	latencies, err := parseDurations([]string{matches[1]}, matches[2])
+	if err != nil {
+		return HistogramBucket{}, false
+	}
+
+	bucket := HistogramBucket{Latency: latencies[0], Overflow: matches[4] != ""}
+	for _, rawCount := range strings.Fields(matches[3]) {
+		count, err := strconv.ParseUint(rawCount, 10, 64)
+		if err != nil {
+			return HistogramBucket{}, false
+		}
+		bucket.Count += count
+	}
+
+	return bucket, true
+}
+
+func (r *Results) setTextRows(rows map[string][]string, units map[string]string) error {
+	coresCount := len(rows[maximumRow])
+	if rawCores, exists := rows["Core"]; exists {
+		coresCount = len(rawCores)
+		for _, rawCore := range rawCores {
+			core, err := strconv.Atoi(rawCore)
+			if err != nil {
+				return fmt.Errorf("%w: invalid core %q", ErrInvalidSummaryRow, rawCore)
+			}
+			r.Cores = append(r.Cores, core)
+		}
+	}
+
+	for rowName, target := range map[string]*[]time.Duration{
+		minimumRow:  &r.Minimum,
+		averageRow:  &r.Average,
+		maximumRow:  &r.Maximum,
+		maxMinRow:   &r.MaxMin,
+		durationRow: &r.Duration,
+	} {
+		rawValues, exists := rows[rowName]
+		if !exists {
+			continue
+		}
+		if len(rawValues) != coresCount {
+			return fmt.Errorf("%w: %q has %d values, expected one per core (%d)", ErrInvalidSummaryRow, rowName, len(rawValues), coresCount)
+		}
+
+		values, err := parseDurations(rawValues, rowUnits(rowName, units[rowName]))
+		if err != nil {
+			return fmt.Errorf("%w: %q: %v", ErrInvalidSummaryRow, rowName, err)
+		}
+		*target = values
+	}
+
+	return nil
+}
+
+// rowUnits returns the units of a summary row, defaulting to the units oslat uses when they are omitted.
+func rowUnits(rowName, units string) string {
+	if units != "" {
+		return units
+	}
+	if rowName == durationRow {
+		return "sec"
+	}
+	return "us"
+}
+
+func parseDurations(rawValues []string, units string) ([]time.Duration, error) {
+	durationUnits := map[string]string{"ns": "ns", "us": "us", "ms": "ms", "sec": "s", "s": "s"}
+	durationUnit, known := durationUnits[units]
+	if !known {
+		return nil, fmt.Errorf("unknown units %q", units)
+	}
+
+	var durations []time.Duration
+	for _, rawValue := range rawValues {
+		duration, err := time.ParseDuration(rawValue + durationUnit)
+		if err != nil {
+			return nil, err
+		}
+		durations = append(durations, duration)
+	}
+	return durations, nil
+}
+
+func allNumbers(fields []string) bool {
+	for _, field := range fields {
+		if !numberRegex.MatchString(field) {
+			return false
+		}
+	}
+	return len(fields) > 0
+}
+
+// maxJSONValue bounds the JSON report values, so they can be represented as a time.Duration.
+const maxJSONValue = float64(math.MaxInt64 / int64(time.Second))
+
+// jsonReport is the rt-tests JSON report written by `oslat --json`.
+type jsonReport struct {
+	// Some rt-tests versions end the key names with a colon.
+	Version      string                `json:"rt_test_version"`
+	VersionColon string                `json:"rt_test_version:"`
+	Threads      map[string]jsonThread `json:"thread"`
+}
+
+type jsonThread struct {
+	CPU      *int     `json:"cpu"`
+	Min      *float64 `json:"min"`
+	Avg      *float64 `json:"avg"`
+	Max      *float64 `json:"max"`
+	Duration *float64 `json:"duration"`
+}
+
+func looksLikeJSONReport(output string) bool {
+	return strings.Contains(output, `"thread"`)
+}
+
+func parseJSON(output string) (Results, error) {
+	var report jsonReport
+	if err := json.NewDecoder(strings.NewReader(output)).Decode(&report); err != nil {
+		return Results{}, fmt.Errorf("failed to decode oslat JSON report: %w", err)
+	}
+
+	if len(report.Threads) == 0 {
+		return Results{}, ErrNoResults
+	}
+
+	results := Results{Format: JSONFormat, Version: report.Version}
+	if results.Version == "" {
+		results.Version = report.VersionColon
+	}
+
+	threadIDs := make([]string, 0, len(report.Threads))
+	for threadID := range report.Threads {
+		threadIDs = append(threadIDs, threadID)
+	}
+	sort.Slice(threadIDs, func(i, j int) bool {
+		return naturalLess(threadIDs[i], threadIDs[j])
+	})
+
+	for _, threadID := range threadIDs {
+		if err := results.addJSONThread(threadID, report.Threads[threadID]); err != nil {
+			return Results{}, err
+		}
+	}
+
+	return results, nil
+}
+
+func (r *Results) addJSONThread(threadID string, thread jsonThread) error {
+	if thread.CPU == nil || thread.Min == nil || thread.Avg == nil || thread.Max == nil {
+		return fmt.Errorf("%w: thread %q lacks its cpu, min, avg or max", ErrMissingSummaryRow, threadID)
+	}
+	for _, value := range []*float64{thread.Min, thread.Avg, thread.Max, thread.Duration} {
+		if value != nil && (*value < 0 || *value > maxJSONValue) {
+			return fmt.Errorf("%w: thread %q has an out of range value %v", ErrInvalidSummaryRow, threadID, *value)
+		}
+	}
+	if *thread.Max < *thread.Min {
+		return fmt.Errorf("%w: thread %q maximum is lower than its minimum", ErrInvalidSummaryRow, threadID)
+	}
+
+	r.Cores = append(r.Cores, *thread.CPU)
+	r.Minimum = append(r.Minimum, microseconds(*thread.Min))
+	r.Average = append(r.Average, microseconds(*thread.Avg))
+	r.Maximum = append(r.Maximum, microseconds(*thread.Max))
+	r.MaxMin = append(r.MaxMin, microseconds(*thread.Max-*thread.Min))
+	if thread.Duration != nil {
+		r.Duration = append(r.Duration, time.Duration(*thread.Duration*float64(time.Second)))
+	}
+
+	return nil
+}
+
+func microseconds(value float64) time.Duration {
+	return time.Duration(value * float64(time.Microsecond))
+}
+
+// naturalLess orders numeric thread IDs by value, falling back to lexical order.
+func naturalLess(a, b string) bool {
+	numA, errA := strconv.Atoi(a)
+	numB, errB := strconv.Atoi(b)
+	if errA == nil && errB == nil {
+		return numA < numB
+	}
+	return a < b
+}
@@ -0,0 +1,206 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package oslat_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/oslat"
+)
+
+const us = time.Microsecond
+
+func TestParseShouldSucceed(t *testing.T) {
+	v260Results := oslat.Results{
+		Format:   oslat.TextFormat,
+		Version:  "2.60",
+		Cores:    []int{2, 3},
+		Minimum:  []time.Duration{1 * us, 1 * us},
+		Average:  []time.Duration{2001 * time.Nanosecond, 2001 * time.Nanosecond},
+		Maximum:  []time.Duration{12 * us, 13 * us},
+		MaxMin:   []time.Duration{11 * us, 12 * us},
+		Duration: []time.Duration{59970 * time.Millisecond, 59970 * time.Millisecond},
+	}
+
+	testCases := []struct {
+		transcript      string
+		expectedResults oslat.Results
+	}{
+		{
+			transcript:      "oslat-v2.60.txt",
+			expectedResults: v260Results,
+		},
+		{
+			transcript:      "oslat-v2.60-console.txt",
+			expectedResults: v260Results,
+		},
+		{
+			transcript: "oslat-v1.10.txt",
+			expectedResults: oslat.Results{
+				Format:  oslat.TextFormat,
+				Version: "1.10",
+				Cores:   []int{1, 2, 3},
+				Minimum: []time.Duration{1 * us, 1 * us, 1 * us},
+				Average: []time.Duration{1 * us, 1 * us, 1 * us},
+				Maximum: []time.Duration{6 * us, 5 * us, 7 * us},
+			},
+		},
+		{
+			transcript: "oslat-v2.60.json",
+			expectedResults: oslat.Results{
+				Format:   oslat.JSONFormat,
+				Version:  "2.60",
+				Cores:    []int{2, 3},
+				Minimum:  []time.Duration{1 * us, 1 * us},
+				Average:  []time.Duration{2 * us, 2 * us},
+				Maximum:  []time.Duration{12 * us, 13 * us},
+				MaxMin:   []time.Duration{11 * us, 12 * us},
+				Duration: []time.Duration{59970 * time.Millisecond, 59970 * time.Millisecond},
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.transcript, func(t *testing.T) {
+			results, err := oslat.Parse(readTranscript(t, testCase.transcript))
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expectedResults, results)
+		})
+	}
+}
+
+func TestParseShouldAcceptMissingUnits(t *testing.T) {
+	results, err := oslat.Parse("oslat V 2.60\n" +
+		"        Core:\t 2 3\n" +
+		"     Minimum:\t 1 1\n" +
+		"     Average:\t 1.500 2.000\n" +
+		"     Maximum:\t 9 21\n" +
+		"     Max-Min:\t 8 20\n" +
+		"    Duration:\t 10.000 10.000\n")
+	assert.NoError(t, err)
+	assert.Equal(t, 21*us, results.MaxLatency())
+	assert.Equal(t, []time.Duration{10 * time.Second, 10 * time.Second}, results.Duration)
+}
+
+func TestParseShouldFailWhen(t *testing.T) {
+	testCases := []struct {
+		description   string
+		output        string
+		expectedError error
+	}{
+		{
+			description:   "there are no results",
+			output:        "oslat V 2.60\nTest starts...\n",
+			expectedError: oslat.ErrNoResults,
+		},
+		{
+			description:   "a summary row is missing",
+			output:        "oslat V 2.60\n     Minimum:\t 1 1 (us)\n     Average:\t 2 2 (us)\n     Maximum:\t 9 21 (us)\n",
+			expectedError: oslat.ErrMissingSummaryRow,
+		},
+		{
+			description:   "a summary row does not have a value per core",
+			output:        "        Core:\t 2 3\n     Maximum:\t 9 (us)\n",
+			expectedError: oslat.ErrInvalidSummaryRow,
+		},
+		{
+			description:   "the units are unknown",
+			output:        "     Maximum:\t 9 21 (parsecs)\n",
+			expectedError: oslat.ErrInvalidSummaryRow,
+		},
+		{
+			description:   "a JSON thread lacks its maximum",
+			output:        `{"thread": {"0": {"cpu": 2, "min": 1, "avg": 2}}}`,
+			expectedError: oslat.ErrMissingSummaryRow,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			_, err := oslat.Parse(testCase.output)
+			assert.ErrorIs(t, err, testCase.expectedError)
+		})
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	transcripts, err := filepath.Glob(filepath.Join("testdata", "oslat-*"))
+	assert.NoError(f, err)
+	for _, transcript := range transcripts {
+		rawTranscript, err := os.ReadFile(transcript)
+		assert.NoError(f, err)
+		f.Add(string(rawTranscript))
+	}
+
+	f.Fuzz(func(t *testing.T, output string) {
+		results, err := oslat.Parse(output)
+		if err != nil {
+			return
+		}
+
+		for _, row := range [][]time.Duration{results.Minimum, results.Average, results.MaxMin, results.Duration} {
+			if len(row) != 0 && len(row) != len(results.Maximum) {
+				t.Fatalf("summary rows have different lengths: %+v", results)
+			}
+		}
+		for _, coreMaxLatency := range results.Maximum {
+			if coreMaxLatency < 0 || coreMaxLatency > results.MaxLatency() {
+				t.Fatalf("invalid max latency %s: %+v", coreMaxLatency, results)
+			}
+		}
+	})
+}
+
+func TestParseHistogram(t *testing.T) {
+	histogram := oslat.ParseHistogram(readTranscript(t, "oslat-v2.60.txt"))
+
+	const bucketsCount = 32
+	assert.Len(t, histogram, bucketsCount)
+	assert.Equal(t, oslat.HistogramBucket{Latency: 2 * us, Count: 582681699 + 615399319}, histogram[1])
+	assert.True(t, histogram[bucketsCount-1].Overflow)
+
+	assert.Equal(t, 2*us, histogram.Percentile(99.99, 13*us))
+	assert.Equal(t, 9*us, histogram.Percentile(99.999, 13*us))
+	assert.Equal(t, 11*us, histogram.Percentile(99.9999, 13*us))
+
+	assert.Empty(t, oslat.ParseHistogram(readTranscript(t, "oslat-v2.60.json")))
+}
+
+func TestHistogramPercentileShouldBeBoundedByTheMaxLatencyInTheOverflowBucket(t *testing.T) {
+	histogram := oslat.Histogram{
+		{Latency: 1 * us, Count: 90},
+		{Latency: 2 * us, Count: 10, Overflow: true},
+	}
+
+	assert.Equal(t, 1*us, histogram.Percentile(90, 150*us))
+	assert.Equal(t, 150*us, histogram.Percentile(99, 150*us))
+	assert.Zero(t, oslat.Histogram{}.Percentile(99, 150*us))
+}
+
+func readTranscript(t *testing.T, name string) string {
+	rawTranscript, err := os.ReadFile(filepath.Join("testdata", name))
+	assert.NoError(t, err)
+	return string(rawTranscript)
+}
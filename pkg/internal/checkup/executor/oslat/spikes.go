@@ -0,0 +1,101 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package oslat
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+const (
+	// spikeFactor is how many times a window's max latency should exceed the median window max latency to be considered a spike.
+	spikeFactor = 2
+	// minPeriodicSpikes is the number of spikes needed to tell a recurrence from a coincidence.
+	minPeriodicSpikes = 3
+	// minSpikesGapWindows is the number of windows periodic spikes should be apart, as spikes in adjacent windows
+	// are taken for a single long interference.
+	minSpikesGapWindows = 2
+)
+
+// DetectPeriodicSpikes looks for latency spikes recurring at a fixed interval, suggesting a periodic interferer
+// such as a housekeeping timer. It returns the interval, or zero when no periodic spikes were found.
+// Spikes recurring less than two windows apart, e.g. at the window duration, show in most of the windows
+// and cannot be told from a steady latency, so they cannot be detected.
+func DetectPeriodicSpikes(windows []status.LatencyWindow) time.Duration {
+	spikes := spikeWindows(windows)
+	if len(spikes) < minPeriodicSpikes {
+		return 0
+	}
+
+	var gaps []int
+	for i := 1; i < len(spikes); i++ {
+		gaps = append(gaps, spikes[i]-spikes[i-1])
+	}
+
+	medianGap := median(gaps)
+	// Spikes in adjacent windows are a single long interference, rather than a recurring one.
+	if medianGap < minSpikesGapWindows {
+		return 0
+	}
+
+	// Allow a spike to fall into a neighboring window, as the windows are not aligned with the interferer.
+	for _, gap := range gaps {
+		if gap < medianGap-1 || gap > medianGap+1 {
+			return 0
+		}
+	}
+
+	first, last := windows[spikes[0]], windows[spikes[len(spikes)-1]]
+	interval := last.StartTimestamp.Sub(first.StartTimestamp) / time.Duration(len(spikes)-1)
+	return interval.Round(time.Second)
+}
+
+// MinDetectableSpikesInterval returns the shortest interval of periodic spikes which can be detected using the given window.
+func MinDetectableSpikesInterval(window time.Duration) time.Duration {
+	return minSpikesGapWindows * window
+}
+
+func spikeWindows(windows []status.LatencyWindow) []int {
+	maxLatencies := make([]int, 0, len(windows))
+	for _, window := range windows {
+		maxLatencies = append(maxLatencies, int(window.MaxLatency))
+	}
+	spikeThreshold := time.Duration(spikeFactor * median(maxLatencies))
+
+	var spikes []int
+	for i, window := range windows {
+		if window.MaxLatency > spikeThreshold {
+			spikes = append(spikes, i)
+		}
+	}
+	return spikes
+}
+
+func median(values []int) int {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sorted := append([]int{}, values...)
+	sort.Ints(sorted)
+	return sorted[len(sorted)/2]
+}
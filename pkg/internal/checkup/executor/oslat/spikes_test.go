@@ -0,0 +1,99 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package oslat_test
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/oslat"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+func TestDetectPeriodicSpikes(t *testing.T) {
+	testCases := []struct {
+		description      string
+		maxLatencies     []int
+		expectedInterval time.Duration
+	}{
+		{
+			description:      "spikes recurring every third window",
+			maxLatencies:     []int{8, 9, 30, 8, 7, 31, 9, 8, 29, 8},
+			expectedInterval: 30 * time.Second,
+		},
+		{
+			description:      "spikes drifting into neighboring windows",
+			maxLatencies:     []int{8, 30, 9, 8, 8, 31, 9, 8, 8, 8, 28, 8, 8, 30},
+			expectedInterval: 40 * time.Second,
+		},
+		{
+			description:      "no spikes",
+			maxLatencies:     []int{8, 9, 8, 8, 7, 9, 9, 8, 9, 8},
+			expectedInterval: 0,
+		},
+		{
+			description:      "too few spikes",
+			maxLatencies:     []int{8, 9, 30, 8, 7, 31, 9, 8, 9, 8},
+			expectedInterval: 0,
+		},
+		{
+			description:      "irregular spikes",
+			maxLatencies:     []int{30, 9, 31, 8, 7, 8, 9, 8, 29, 8, 30},
+			expectedInterval: 0,
+		},
+		{
+			description:      "spikes recurring at the window duration",
+			maxLatencies:     []int{30, 31, 29, 30, 31, 30, 29, 31, 30, 30},
+			expectedInterval: 0,
+		},
+		{
+			description:      "a single long interference",
+			maxLatencies:     []int{8, 9, 30, 31, 30, 29, 9, 8, 9, 8},
+			expectedInterval: 0,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.description, func(t *testing.T) {
+			assert.Equal(t, testCase.expectedInterval, oslat.DetectPeriodicSpikes(newWindows(testCase.maxLatencies)))
+		})
+	}
+}
+
+func TestMinDetectableSpikesInterval(t *testing.T) {
+	assert.Equal(t, 20*time.Second, oslat.MinDetectableSpikesInterval(10*time.Second))
+}
+
+// newWindows returns consecutive 10 seconds windows with the given max latencies, in microseconds.
+func newWindows(maxLatencies []int) []status.LatencyWindow {
+	const windowDuration = 10 * time.Second
+	start := time.Date(2023, time.June, 6, 10, 14, 3, 0, time.UTC)
+
+	var windows []status.LatencyWindow
+	for i, maxLatency := range maxLatencies {
+		windows = append(windows, status.LatencyWindow{
+			StartTimestamp: start.Add(time.Duration(i) * windowDuration),
+			MaxLatency:     time.Duration(maxLatency) * time.Microsecond,
+		})
+	}
+	return windows
+}
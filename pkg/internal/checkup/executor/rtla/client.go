@@ -0,0 +1,135 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package rtla
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"time"
+
+	expect "github.com/google/goexpect"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/console"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+type consoleExpecter interface {
+	SafeExpectBatchWithResponse(expected []expect.Batcher, timeout time.Duration) ([]expect.BatchRes, error)
+}
+
+type Client struct {
+	consoleExpecter consoleExpecter
+	mode            string
+	duration        time.Duration
+}
+
+func NewClient(vmiUnderTestConsoleExpecter consoleExpecter, mode string, duration time.Duration) *Client {
+	return &Client{
+		consoleExpecter: vmiUnderTestConsoleExpecter,
+		mode:            mode,
+		duration:        duration,
+	}
+}
+
+// Run runs the rtla tool of the client mode on the isolated CPUs, and returns its summary.
+func (c Client) Run(ctx context.Context) (status.RtlaResults, error) {
+	type result struct {
+		output string
+		err    error
+	}
+
+	batch := []expect.Batcher{
+		&expect.BSnd{S: buildRtlaCmd(c.mode, c.duration) + "\n"},
+		&expect.BExp{R: console.PromptExpression},
+		&expect.BSnd{S: "echo $?\n"},
+		&expect.BExp{R: console.PromptExpression},
+	}
+
+	resultCh := make(chan result)
+	go func() {
+		defer close(resultCh)
+
+		resp, err := c.consoleExpecter.SafeExpectBatchWithResponse(batch, c.duration+config.RtlaTimeoutGrace)
+		if err != nil {
+			resultCh <- result{"", err}
+			return
+		}
+
+		exitCode, err := getExitCode(resp[1].Output)
+		if err != nil {
+			resultCh <- result{"", fmt.Errorf("rtla %s failed to get exit code: %w", c.mode, err)}
+			return
+		}
+		if exitCode != 0 {
+			log.Printf("rtla %s returned exit code: %d. stdout: %s", c.mode, exitCode, resp[0].Output)
+			resultCh <- result{"", fmt.Errorf("rtla %s failed with exit code: %d. See logs for more information", c.mode, exitCode)}
+			return
+		}
+
+		resultCh <- result{resp[0].Output, nil}
+	}()
+
+	var res result
+	select {
+	case res = <-resultCh:
+		if res.err != nil {
+			return status.RtlaResults{}, res.err
+		}
+	case <-ctx.Done():
+		return status.RtlaResults{}, fmt.Errorf("rtla %s canceled due to context closing: %w", c.mode, ctx.Err())
+	}
+
+	results, err := c.parse(res.output)
+	if err != nil {
+		return status.RtlaResults{}, failure.New(failure.ResultParseFailed, fmt.Errorf("failed parsing rtla %s results: %w", c.mode, err))
+	}
+	results.Mode = c.mode
+
+	return results, nil
+}
+
+func (c Client) parse(output string) (status.RtlaResults, error) {
+	if c.mode == config.RtlaTimerlatMode {
+		return ParseTimerlat(output)
+	}
+	return ParseOsnoise(output)
+}
+
+func getExitCode(output string) (int, error) {
+	matches := regexp.MustCompile(`\r\n(\d+)\r\n`).FindStringSubmatch(output)
+
+	const expectedMatches = 2
+	if len(matches) != expectedMatches {
+		return 0, fmt.Errorf("failed to parse exit value")
+	}
+
+	return strconv.Atoi(matches[1])
+}
+
+// buildRtlaCmd builds a quiet rtla top command, printing only the summary once the given duration elapses.
+func buildRtlaCmd(mode string, duration time.Duration) string {
+	return fmt.Sprintf("rtla %s top --cpus %s --duration %ds --quiet",
+		mode, config.VMUnderTestIsolatedCPUs, int64(duration.Seconds()))
+}
@@ -0,0 +1,91 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package rtla
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+var ErrNoResults = errors.New("no rtla results found")
+
+var (
+	ansiEscapeRegex = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+	// osnoiseRowRegex matches an osnoise top summary row:
+	// CPU, Period, Runtime, Noise, % CPU Aval, Max Noise, Max Single, HW, NMI, IRQ, Softirq and Thread.
+	osnoiseRowRegex = regexp.MustCompile(`(?m)^\s*(\d+)\s+#\d+\s+\d+\s+\d+\s+[\d.]+\s+(\d+)\s+\d+` +
+		`\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*$`)
+
+	// timerlatRowRegex matches a timerlat top summary row:
+	// CPU, COUNT, the IRQ cur, min, avg and max latencies, and the thread cur, min, avg and max latencies.
+	// Newer rtla versions append the user-space thread latencies, which are ignored.
+	timerlatRowRegex = regexp.MustCompile(`(?m)^\s*(\d+)\s+#\d+\s+\|\s+\d+\s+\d+\s+\d+\s+(\d+)\s+\|\s+\d+\s+\d+\s+\d+\s+(\d+)`)
+)
+
+// ParseOsnoise parses the osnoise top summary, accumulating the noise occurrences of all CPUs by source.
+func ParseOsnoise(output string) (status.RtlaResults, error) {
+	rows := osnoiseRowRegex.FindAllStringSubmatch(ansiEscapeRegex.ReplaceAllString(output, ""), -1)
+	if len(rows) == 0 {
+		return status.RtlaResults{}, ErrNoResults
+	}
+
+	results := status.RtlaResults{NoiseOccurrences: &status.NoiseOccurrences{}}
+	for _, row := range rows {
+		results.MaxNoise = max(results.MaxNoise, microseconds(row[2]))
+		results.NoiseOccurrences.HW += count(row[3])
+		results.NoiseOccurrences.NMI += count(row[4])
+		results.NoiseOccurrences.IRQ += count(row[5])
+		results.NoiseOccurrences.SoftIRQ += count(row[6])
+		results.NoiseOccurrences.Thread += count(row[7])
+	}
+
+	return results, nil
+}
+
+// ParseTimerlat parses the timerlat top summary, taking the max IRQ and thread latencies of all CPUs.
+func ParseTimerlat(output string) (status.RtlaResults, error) {
+	rows := timerlatRowRegex.FindAllStringSubmatch(ansiEscapeRegex.ReplaceAllString(output, ""), -1)
+	if len(rows) == 0 {
+		return status.RtlaResults{}, ErrNoResults
+	}
+
+	var results status.RtlaResults
+	for _, row := range rows {
+		results.IRQMaxLatency = max(results.IRQMaxLatency, microseconds(row[2]))
+		results.ThreadMaxLatency = max(results.ThreadMaxLatency, microseconds(row[3]))
+	}
+
+	return results, nil
+}
+
+// count parses a matched number, which is known to be a valid integer.
+func count(rawCount string) uint64 {
+	c, _ := strconv.ParseUint(rawCount, 10, 64)
+	return c
+}
+
+func microseconds(rawMicroseconds string) time.Duration {
+	return time.Duration(count(rawMicroseconds)) * time.Microsecond
+}
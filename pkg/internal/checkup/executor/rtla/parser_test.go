@@ -0,0 +1,92 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package rtla_test
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/rtla"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+const (
+	osnoiseOutput = "rtla osnoise top --cpus 2-3 --duration 60s --quiet\r\n" +
+		"\x1b[2;37;49m                                          " +
+		"Operating System Noise                                                       \x1b[0;0;0m\r\n" +
+		"duration:   0 00:01:00 | time is in us\r\n" +
+		"CPU Period       Runtime        Noise  % CPU Aval   Max Noise   " +
+		"Max Single          HW          NMI          IRQ      Softirq       Thread\r\n" +
+		"  2 #59       59000000          261    99.99955          12            " +
+		"4             0            0        59025            0            6\r\n" +
+		"  3 #59       59000000          230    99.99961          31            " +
+		"3             1            0        59011            2            5\r\n" +
+		"[root@realtime-vmi-under-test-abcde ~]# "
+
+	timerlatOutput = "rtla timerlat top --cpus 2-3 --duration 60s --quiet\r\n" +
+		"                                     Timer Latency\r\n" +
+		"  0 00:01:00   |          IRQ Timer Latency (us)        |         Thread Timer Latency (us)      |    Ret user Timer Latency (us)\r\n" +
+		"CPU COUNT      |      cur       min       avg       max |      cur       " +
+		"min       avg       max |      cur       min       avg       max\r\n" +
+		"  2 #59995     |        2         1         2        12 |        5         " +
+		"4         5        17 |        6         5         6        19\r\n" +
+		"  3 #59994     |        2         1         2        14 |        5         " +
+		"4         5        16 |        6         5         6        18\r\n" +
+		"[root@realtime-vmi-under-test-abcde ~]# "
+)
+
+func TestParseOsnoise(t *testing.T) {
+	results, err := rtla.ParseOsnoise(osnoiseOutput)
+	assert.NoError(t, err)
+
+	expectedResults := status.RtlaResults{
+		MaxNoise:         31 * time.Microsecond,
+		NoiseOccurrences: &status.NoiseOccurrences{HW: 1, NMI: 0, IRQ: 118036, SoftIRQ: 2, Thread: 11},
+	}
+	assert.Equal(t, expectedResults, results)
+}
+
+func TestParseTimerlat(t *testing.T) {
+	results, err := rtla.ParseTimerlat(timerlatOutput)
+	assert.NoError(t, err)
+
+	expectedResults := status.RtlaResults{
+		IRQMaxLatency:    14 * time.Microsecond,
+		ThreadMaxLatency: 17 * time.Microsecond,
+	}
+	assert.Equal(t, expectedResults, results)
+}
+
+func TestParseShouldFailWithoutSummaryRows(t *testing.T) {
+	const output = "rtla osnoise top --cpus 2-3 --duration 60s --quiet\r\n" +
+		"-bash: rtla: command not found\r\n" +
+		"[root@realtime-vmi-under-test-abcde ~]# "
+
+	_, err := rtla.ParseOsnoise(output)
+	assert.ErrorIs(t, err, rtla.ErrNoResults)
+
+	_, err = rtla.ParseTimerlat(output)
+	assert.ErrorIs(t, err, rtla.ErrNoResults)
+
+	_, err = rtla.ParseTimerlat(osnoiseOutput)
+	assert.ErrorIs(t, err, rtla.ErrNoResults)
+}
@@ -0,0 +1,162 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package goldenbaseline
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+// DataKey is the golden baseline ConfigMap key holding the baseline document.
+const DataKey = "baseline"
+
+// DefaultTolerancePercent is the latency increase over the expected values allowed when the document sets none.
+const DefaultTolerancePercent = 10
+
+var ErrInvalidBaseline = errors.New("invalid golden baseline")
+
+// Baseline is the approved oslat results of a hardware model, the latencies given in microseconds.
+// Percentiles are keyed by percent, e.g. "99.999", and the per core max latencies by CPU, e.g. "2".
+type Baseline struct {
+	HardwareModel    string           `json:"hardwareModel,omitempty"`
+	MaxLatency       int64            `json:"maxLatencyMicroSeconds,omitempty"`
+	Percentiles      map[string]int64 `json:"percentilesMicroSeconds,omitempty"`
+	CoreMaxLatencies map[string]int64 `json:"coreMaxLatenciesMicroSeconds,omitempty"`
+	TolerancePercent *int             `json:"tolerancePercent,omitempty"`
+}
+
+// Parse parses and validates the baseline document.
+func Parse(document string) (Baseline, error) {
+	var baseline Baseline
+	if err := json.Unmarshal([]byte(document), &baseline); err != nil {
+		return Baseline{}, fmt.Errorf("%w: %v", ErrInvalidBaseline, err)
+	}
+
+	if baseline.MaxLatency == 0 && len(baseline.Percentiles) == 0 && len(baseline.CoreMaxLatencies) == 0 {
+		return Baseline{}, fmt.Errorf("%w: expects no latency", ErrInvalidBaseline)
+	}
+
+	if baseline.MaxLatency < 0 {
+		return Baseline{}, fmt.Errorf("%w: negative max latency", ErrInvalidBaseline)
+	}
+
+	// The keys are normalized, so that e.g. "99.990" matches the reported 99.99 percentile.
+	percentiles := map[string]int64{}
+	for percent, latency := range baseline.Percentiles {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil || value <= 0 || value > 100 || latency < 0 {
+			return Baseline{}, fmt.Errorf("%w: invalid percentile %q", ErrInvalidBaseline, percent)
+		}
+		percentiles[strconv.FormatFloat(value, 'f', -1, 64)] = latency
+	}
+	baseline.Percentiles = percentiles
+
+	coreMaxLatencies := map[string]int64{}
+	for cpu, latency := range baseline.CoreMaxLatencies {
+		value, err := strconv.Atoi(cpu)
+		if err != nil || value < 0 || latency < 0 {
+			return Baseline{}, fmt.Errorf("%w: invalid core %q", ErrInvalidBaseline, cpu)
+		}
+		coreMaxLatencies[strconv.Itoa(value)] = latency
+	}
+	baseline.CoreMaxLatencies = coreMaxLatencies
+
+	if baseline.TolerancePercent == nil {
+		tolerancePercent := DefaultTolerancePercent
+		baseline.TolerancePercent = &tolerancePercent
+	} else if *baseline.TolerancePercent < 0 {
+		return Baseline{}, fmt.Errorf("%w: negative tolerance", ErrInvalidBaseline)
+	}
+
+	return baseline, nil
+}
+
+// Compare compares the results to the baseline, metric by metric: the max latency ("max"),
+// the percentiles (e.g. "p99.999") and the per core max latencies (e.g. "core2").
+func (b Baseline) Compare(results status.Results) []status.MetricComparison {
+	var comparisons []status.MetricComparison
+
+	if b.MaxLatency > 0 {
+		comparisons = append(comparisons, b.compare("max", b.MaxLatency, results.OslatMaxLatency, true))
+	}
+
+	for _, percent := range sortedKeys(b.Percentiles) {
+		measured, isMeasured := percentileLatency(results.OslatPercentiles, percent)
+		comparisons = append(comparisons, b.compare("p"+percent, b.Percentiles[percent], measured, isMeasured))
+	}
+
+	for _, cpu := range sortedKeys(b.CoreMaxLatencies) {
+		measured, isMeasured := coreMaxLatency(results.OslatCoreMaxLatencies, cpu)
+		comparisons = append(comparisons, b.compare("core"+cpu, b.CoreMaxLatencies[cpu], measured, isMeasured))
+	}
+
+	return comparisons
+}
+
+func (b Baseline) compare(metric string, expectedMicroSeconds int64, measured time.Duration, isMeasured bool) status.MetricComparison {
+	const hundredPercent = 100
+	allowed := time.Duration(expectedMicroSeconds) * time.Microsecond * time.Duration(hundredPercent+*b.TolerancePercent) / hundredPercent
+
+	return status.MetricComparison{
+		Metric:     metric,
+		Measured:   measured,
+		IsMeasured: isMeasured,
+		Allowed:    allowed,
+		Passed:     isMeasured && measured <= allowed,
+	}
+}
+
+func percentileLatency(percentiles []status.LatencyPercentile, percent string) (time.Duration, bool) {
+	for _, percentile := range percentiles {
+		if strconv.FormatFloat(percentile.Percent, 'f', -1, 64) == percent {
+			return percentile.Latency, true
+		}
+	}
+	return 0, false
+}
+
+func coreMaxLatency(coreLatencies []status.CoreLatency, cpu string) (time.Duration, bool) {
+	for _, coreLatency := range coreLatencies {
+		if strconv.Itoa(coreLatency.CPU) == cpu {
+			return coreLatency.MaxLatency, true
+		}
+	}
+	return 0, false
+}
+
+// sortedKeys sorts the keys numerically, as they were validated to be numbers.
+func sortedKeys(values map[string]int64) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		left, _ := strconv.ParseFloat(keys[i], 64)
+		right, _ := strconv.ParseFloat(keys[j], 64)
+		return left < right
+	})
+	return keys
+}
@@ -0,0 +1,107 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package goldenbaseline_test
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/goldenbaseline"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+const us = time.Microsecond
+
+func TestParseShouldSucceed(t *testing.T) {
+	baseline, err := goldenbaseline.Parse(`{
+		"hardwareModel": "PowerEdge R750",
+		"maxLatencyMicroSeconds": 20,
+		"percentilesMicroSeconds": {"99.990": 5},
+		"coreMaxLatenciesMicroSeconds": {"02": 15},
+		"tolerancePercent": 0
+	}`)
+	assert.NoError(t, err)
+
+	tolerancePercent := 0
+	expectedBaseline := goldenbaseline.Baseline{
+		HardwareModel:    "PowerEdge R750",
+		MaxLatency:       20,
+		Percentiles:      map[string]int64{"99.99": 5},
+		CoreMaxLatencies: map[string]int64{"2": 15},
+		TolerancePercent: &tolerancePercent,
+	}
+	assert.Equal(t, expectedBaseline, baseline)
+}
+
+func TestParseShouldDefaultTheTolerance(t *testing.T) {
+	baseline, err := goldenbaseline.Parse(`{"maxLatencyMicroSeconds": 20}`)
+	assert.NoError(t, err)
+	assert.Equal(t, goldenbaseline.DefaultTolerancePercent, *baseline.TolerancePercent)
+}
+
+func TestParseShouldFailWhen(t *testing.T) {
+	testCases := map[string]string{
+		"the document is malformed":      `{"maxLatencyMicroSeconds": `,
+		"no latency is expected":         `{"hardwareModel": "PowerEdge R750"}`,
+		"a percentile is invalid":        `{"percentilesMicroSeconds": {"101": 5}}`,
+		"a core is invalid":              `{"coreMaxLatenciesMicroSeconds": {"two": 15}}`,
+		"the tolerance is negative":      `{"maxLatencyMicroSeconds": 20, "tolerancePercent": -5}`,
+		"the max latency is negative":    `{"maxLatencyMicroSeconds": -20}`,
+		"a core max latency is negative": `{"coreMaxLatenciesMicroSeconds": {"2": -15}}`,
+	}
+
+	for description, document := range testCases {
+		t.Run(description, func(t *testing.T) {
+			_, err := goldenbaseline.Parse(document)
+			assert.ErrorIs(t, err, goldenbaseline.ErrInvalidBaseline)
+		})
+	}
+}
+
+func TestCompare(t *testing.T) {
+	baseline, err := goldenbaseline.Parse(`{
+		"maxLatencyMicroSeconds": 12,
+		"percentilesMicroSeconds": {"99.9999": 10, "99.99": 2},
+		"coreMaxLatenciesMicroSeconds": {"3": 10, "2": 15, "5": 10},
+		"tolerancePercent": 25
+	}`)
+	assert.NoError(t, err)
+
+	results := status.Results{
+		OslatMaxLatency:       13 * us,
+		OslatCoreMaxLatencies: []status.CoreLatency{{CPU: 2, MaxLatency: 13 * us}, {CPU: 3, MaxLatency: 13 * us}},
+		OslatPercentiles: []status.LatencyPercentile{
+			{Percent: 99.99, Latency: 2 * us},
+			{Percent: 99.9999, Latency: 11 * us},
+		},
+	}
+
+	expectedComparisons := []status.MetricComparison{
+		{Metric: "max", Measured: 13 * us, IsMeasured: true, Allowed: 15 * us, Passed: true},
+		{Metric: "p99.99", Measured: 2 * us, IsMeasured: true, Allowed: 2500 * time.Nanosecond, Passed: true},
+		{Metric: "p99.9999", Measured: 11 * us, IsMeasured: true, Allowed: 12500 * time.Nanosecond, Passed: true},
+		{Metric: "core2", Measured: 13 * us, IsMeasured: true, Allowed: 18750 * time.Nanosecond, Passed: true},
+		{Metric: "core3", Measured: 13 * us, IsMeasured: true, Allowed: 12500 * time.Nanosecond, Passed: false},
+		{Metric: "core5", Allowed: 12500 * time.Nanosecond, Passed: false},
+	}
+	assert.Equal(t, expectedComparisons, baseline.Compare(results))
+}
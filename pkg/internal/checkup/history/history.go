@@ -0,0 +1,139 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+// DataKey is the history ConfigMap key holding the run entries, one JSON object per line, the oldest first.
+const DataKey = "history"
+
+// Entry is the compact summary of a single checkup run.
+type Entry struct {
+	Timestamp               time.Time        `json:"timestamp"`
+	Node                    string           `json:"node"`
+	GuestKernelVersion      string           `json:"guestKernelVersion,omitempty"`
+	HostKernelVersion       string           `json:"hostKernelVersion,omitempty"`
+	MaxLatencyMicroSeconds  int64            `json:"maxLatencyMicroSeconds"`
+	PercentilesMicroSeconds map[string]int64 `json:"percentilesMicroSeconds,omitempty"`
+	Succeeded               bool             `json:"succeeded"`
+}
+
+// NewEntry summarizes the run results.
+func NewEntry(timestamp time.Time, results status.Results, succeeded bool) Entry {
+	entry := Entry{
+		Timestamp:              timestamp.UTC().Truncate(time.Second),
+		Node:                   results.VMUnderTestActualNodeName,
+		GuestKernelVersion:     results.GuestKernelVersion,
+		HostKernelVersion:      results.HostKernelVersion,
+		MaxLatencyMicroSeconds: results.OslatMaxLatency.Microseconds(),
+		Succeeded:              succeeded,
+	}
+
+	if len(results.OslatPercentiles) > 0 {
+		entry.PercentilesMicroSeconds = map[string]int64{}
+		for _, percentile := range results.OslatPercentiles {
+			entry.PercentilesMicroSeconds[strconv.FormatFloat(percentile.Percent, 'f', -1, 64)] = percentile.Latency.Microseconds()
+		}
+	}
+
+	return entry
+}
+
+// Decode parses the entries out of the history ConfigMap data.
+func Decode(data string) ([]Entry, error) {
+	var entries []Entry
+	for i, line := range strings.Split(data, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse history entry %d: %w", i+1, err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// Encode formats the entries as the history ConfigMap data.
+func Encode(entries []Entry) (string, error) {
+	sb := strings.Builder{}
+	for _, entry := range entries {
+		rawEntry, err := json.Marshal(entry)
+		if err != nil {
+			return "", err
+		}
+		sb.Write(rawEntry)
+		sb.WriteString("\n")
+	}
+
+	return sb.String(), nil
+}
+
+// Append adds the entry to the history, dropping the oldest entries beyond the given size.
+func Append(entries []Entry, entry Entry, size int) []Entry {
+	entries = append(entries, entry)
+	if len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+
+	return entries
+}
+
+// DetectRegression compares the entry max latency to the average max latency of up to the given number of
+// the latest previous runs on the same node. It is a regression when the increase exceeds the given percentage.
+// It returns nil when there are no previous runs on the node.
+func DetectRegression(entries []Entry, entry Entry, window, thresholdPercent int) *status.Regression {
+	var previousRuns int
+	var previousMaxLatenciesSum int64
+	for i := len(entries) - 1; i >= 0 && previousRuns < window; i-- {
+		if entries[i].Node != entry.Node {
+			continue
+		}
+		previousRuns++
+		previousMaxLatenciesSum += entries[i].MaxLatencyMicroSeconds
+	}
+	if previousRuns == 0 {
+		return nil
+	}
+
+	previousAverage := float64(previousMaxLatenciesSum) / float64(previousRuns)
+	regression := &status.Regression{
+		PreviousRuns:              previousRuns,
+		PreviousAverageMaxLatency: time.Duration(previousAverage * float64(time.Microsecond)),
+	}
+	if previousAverage > 0 {
+		const hundredPercent = 100
+		regression.IncreasePercent = (float64(entry.MaxLatencyMicroSeconds) - previousAverage) / previousAverage * hundredPercent
+		regression.Detected = regression.IncreasePercent > float64(thresholdPercent)
+	}
+
+	return regression
+}
@@ -0,0 +1,132 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package history_test
+
+import (
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/history"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
+)
+
+const (
+	testNode      = "worker-1"
+	testOtherNode = "worker-2"
+)
+
+func TestNewEntryShouldSummarizeTheResults(t *testing.T) {
+	results := status.Results{
+		VMUnderTestActualNodeName: testNode,
+		OslatMaxLatency:           13 * time.Microsecond,
+		OslatPercentiles: []status.LatencyPercentile{
+			{Percent: 99.99, Latency: 2 * time.Microsecond},
+			{Percent: 99.999, Latency: 9 * time.Microsecond},
+		},
+		GuestKernelVersion: "4.18.0-477.10.1.rt7.274.el8_8.x86_64",
+		HostKernelVersion:  "5.14.0-284.25.1.rt14.310.el9_2.x86_64",
+	}
+	timestamp := time.Date(2023, time.June, 6, 10, 12, 49, 500, time.UTC)
+
+	expectedEntry := history.Entry{
+		Timestamp:               time.Date(2023, time.June, 6, 10, 12, 49, 0, time.UTC),
+		Node:                    testNode,
+		GuestKernelVersion:      "4.18.0-477.10.1.rt7.274.el8_8.x86_64",
+		HostKernelVersion:       "5.14.0-284.25.1.rt14.310.el9_2.x86_64",
+		MaxLatencyMicroSeconds:  13,
+		PercentilesMicroSeconds: map[string]int64{"99.99": 2, "99.999": 9},
+		Succeeded:               true,
+	}
+	assert.Equal(t, expectedEntry, history.NewEntry(timestamp, results, true))
+}
+
+func TestEncodeAndDecode(t *testing.T) {
+	entries := []history.Entry{
+		newEntry(testNode, 10),
+		newEntry(testOtherNode, 12),
+	}
+
+	data, err := history.Encode(entries)
+	assert.NoError(t, err)
+
+	decodedEntries, err := history.Decode(data)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, decodedEntries)
+
+	decodedEntries, err = history.Decode("")
+	assert.NoError(t, err)
+	assert.Empty(t, decodedEntries)
+
+	_, err = history.Decode("{\"node\": ")
+	assert.Error(t, err)
+}
+
+func TestAppendShouldDropTheOldestEntriesBeyondTheSize(t *testing.T) {
+	entries := []history.Entry{newEntry(testNode, 10), newEntry(testNode, 11)}
+
+	assert.Equal(t, []history.Entry{newEntry(testNode, 11), newEntry(testNode, 12)},
+		history.Append(entries, newEntry(testNode, 12), 2))
+	assert.Len(t, history.Append(entries, newEntry(testNode, 12), 5), 3)
+}
+
+func TestDetectRegression(t *testing.T) {
+	entries := []history.Entry{
+		newEntry(testNode, 40),
+		newEntry(testNode, 10),
+		newEntry(testOtherNode, 30),
+		newEntry(testNode, 10),
+	}
+
+	t.Run("when the increase exceeds the threshold", func(t *testing.T) {
+		const window = 2
+		expectedRegression := &status.Regression{
+			Detected:                  true,
+			PreviousRuns:              2,
+			PreviousAverageMaxLatency: 10 * time.Microsecond,
+			IncreasePercent:           30,
+		}
+		assert.Equal(t, expectedRegression, history.DetectRegression(entries, newEntry(testNode, 13), window, 20))
+	})
+
+	t.Run("when the increase is within the threshold", func(t *testing.T) {
+		const window = 3
+		expectedRegression := &status.Regression{
+			PreviousRuns:              3,
+			PreviousAverageMaxLatency: 20 * time.Microsecond,
+			IncreasePercent:           -35,
+		}
+		assert.Equal(t, expectedRegression, history.DetectRegression(entries, newEntry(testNode, 13), window, 20))
+	})
+
+	t.Run("when there are no previous runs on the node", func(t *testing.T) {
+		assert.Nil(t, history.DetectRegression(entries, newEntry("worker-3", 13), 5, 20))
+	})
+}
+
+func newEntry(node string, maxLatencyMicroSeconds int64) history.Entry {
+	return history.Entry{
+		Timestamp:              time.Date(2023, time.June, 6, 10, 12, 49, 0, time.UTC),
+		Node:                   node,
+		MaxLatencyMicroSeconds: maxLatencyMicroSeconds,
+		Succeeded:              true,
+	}
+}
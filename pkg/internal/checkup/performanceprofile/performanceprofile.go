@@ -0,0 +1,259 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package performanceprofile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor/interrupts"
+)
+
+const (
+	// TunedNamespace holds the tuned Profile of each node, named after the node, rendered by the Node Tuning Operator.
+	TunedNamespace = "openshift-cluster-node-tuning-operator"
+	// TunedBootcmdlineAnnotation holds the kernel args tuned renders for the node out of its performance profile.
+	TunedBootcmdlineAnnotation = "tuned.openshift.io/bootcmdline"
+)
+
+var (
+	ErrNoMatchingProfile = errors.New("no performance profile matches the node")
+	ErrNodeMismatch      = errors.New("node does not match the performance profile")
+
+	// realtimeKernelRegex matches the release of a kernel built with PREEMPT_RT, e.g. "5.14.0-284.25.1.rt14.310.el9_2.x86_64".
+	realtimeKernelRegex = regexp.MustCompile(`\.rt\d*(\.|$)|PREEMPT_RT`)
+)
+
+// Profile holds the performance profile settings the checkup depends on.
+// Besides the hugepage size, they are only used for validation: KubeVirt runs the VM under test with its default runtime class,
+// which the checkup verifies, and the checkup pins the VM under test CPUs itself.
+type Profile struct {
+	Name         string
+	NodeSelector map[string]string
+	// RuntimeClass is reported by the profile status, and is empty until the profile is applied.
+	RuntimeClass string
+	IsolatedCPUs string
+	ReservedCPUs string
+	// HugepageSize is given in the kernel notation, e.g. "1G".
+	HugepageSize   string
+	RealtimeKernel bool
+	KernelArgs     []string
+}
+
+// Requirements are the node resources the VM under test needs.
+type Requirements struct {
+	CPUs   int
+	Memory string
+}
+
+type client interface {
+	GetNode(ctx context.Context, name string) (*corev1.Node, error)
+	ListPerformanceProfiles(ctx context.Context) ([]unstructured.Unstructured, error)
+	GetTunedProfile(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error)
+}
+
+// Discover returns the performance profile matching the given node, once validated against the node.
+func Discover(ctx context.Context, c client, nodeName string, requirements Requirements) (Profile, error) {
+	node, err := c.GetNode(ctx, nodeName)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	rawProfiles, err := c.ListPerformanceProfiles(ctx)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	var profiles []Profile
+	for _, rawProfile := range rawProfiles {
+		profile, err := New(rawProfile)
+		if err != nil {
+			return Profile{}, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	profile, err := Match(profiles, node.Labels)
+	if err != nil {
+		return Profile{}, fmt.Errorf("%w: %q", err, nodeName)
+	}
+
+	if err := Validate(profile, node, requirements); err != nil {
+		return Profile{}, err
+	}
+
+	tunedProfile, err := c.GetTunedProfile(ctx, TunedNamespace, nodeName)
+	if k8serrors.IsNotFound(err) {
+		log.Printf("Node %q has no tuned Profile, skipping its kernel args validation", nodeName)
+		return profile, nil
+	}
+	if err != nil {
+		return Profile{}, err
+	}
+
+	if err := ValidateKernelArgs(profile, strings.Fields(tunedProfile.GetAnnotations()[TunedBootcmdlineAnnotation])); err != nil {
+		return Profile{}, err
+	}
+
+	return profile, nil
+}
+
+// New parses a performance.openshift.io/v2 PerformanceProfile.
+func New(rawProfile unstructured.Unstructured) (Profile, error) {
+	profile := Profile{Name: rawProfile.GetName()}
+
+	var err error
+	if profile.NodeSelector, _, err = unstructured.NestedStringMap(rawProfile.Object, "spec", "nodeSelector"); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse performance profile %q: %w", profile.Name, err)
+	}
+	if profile.IsolatedCPUs, _, err = unstructured.NestedString(rawProfile.Object, "spec", "cpu", "isolated"); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse performance profile %q: %w", profile.Name, err)
+	}
+	if profile.ReservedCPUs, _, err = unstructured.NestedString(rawProfile.Object, "spec", "cpu", "reserved"); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse performance profile %q: %w", profile.Name, err)
+	}
+	if profile.RealtimeKernel, _, err = unstructured.NestedBool(rawProfile.Object, "spec", "realTimeKernel", "enabled"); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse performance profile %q: %w", profile.Name, err)
+	}
+	if profile.KernelArgs, _, err = unstructured.NestedStringSlice(rawProfile.Object, "spec", "additionalKernelArgs"); err != nil {
+		return Profile{}, fmt.Errorf("failed to parse performance profile %q: %w", profile.Name, err)
+	}
+
+	profile.HugepageSize, _, _ = unstructured.NestedString(rawProfile.Object, "spec", "hugepages", "defaultHugepagesSize")
+	if profile.HugepageSize == "" {
+		pages, _, _ := unstructured.NestedSlice(rawProfile.Object, "spec", "hugepages", "pages")
+		if len(pages) > 0 {
+			if page, ok := pages[0].(map[string]interface{}); ok {
+				profile.HugepageSize, _, _ = unstructured.NestedString(page, "size")
+			}
+		}
+	}
+
+	profile.RuntimeClass, _, _ = unstructured.NestedString(rawProfile.Object, "status", "runtimeClass")
+
+	return profile, nil
+}
+
+// Match returns the only profile whose node selector matches the given node labels.
+func Match(profiles []Profile, nodeLabels map[string]string) (Profile, error) {
+	var matching []Profile
+	for _, profile := range profiles {
+		if selectorMatches(profile.NodeSelector, nodeLabels) {
+			matching = append(matching, profile)
+		}
+	}
+
+	switch len(matching) {
+	case 0:
+		return Profile{}, ErrNoMatchingProfile
+	case 1:
+		return matching[0], nil
+	}
+
+	var names []string
+	for _, profile := range matching {
+		names = append(names, profile.Name)
+	}
+	return Profile{}, fmt.Errorf("multiple performance profiles match the node %v", names)
+}
+
+func selectorMatches(selector, labels map[string]string) bool {
+	if len(selector) == 0 {
+		return false
+	}
+
+	for key, value := range selector {
+		if labelValue, exists := labels[key]; !exists || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// ExpectedKernelArgs returns the node kernel args the profile implies.
+func (p Profile) ExpectedKernelArgs() []string {
+	var kernelArgs []string
+	if p.IsolatedCPUs != "" {
+		kernelArgs = append(kernelArgs, "nohz_full="+p.IsolatedCPUs)
+	}
+	if p.HugepageSize != "" {
+		kernelArgs = append(kernelArgs, "default_hugepagesz="+p.HugepageSize, "hugepagesz="+p.HugepageSize)
+	}
+	return append(kernelArgs, p.KernelArgs...)
+}
+
+// VMIHugepageSize returns the hugepage size in the Kubernetes quantity notation, e.g. "1Gi".
+func (p Profile) VMIHugepageSize() string {
+	if p.HugepageSize == "" {
+		return ""
+	}
+	return strings.TrimSuffix(p.HugepageSize, "B") + "i"
+}
+
+// Validate checks the node runs the profile kernel, and has enough resources for the VM under test.
+func Validate(profile Profile, node *corev1.Node, requirements Requirements) error {
+	if profile.RealtimeKernel && !realtimeKernelRegex.MatchString(node.Status.NodeInfo.KernelVersion) {
+		return fmt.Errorf("%w: kernel %q is not a realtime kernel", ErrNodeMismatch, node.Status.NodeInfo.KernelVersion)
+	}
+
+	isolatedCPUs, err := interrupts.ParseCPUList(profile.IsolatedCPUs)
+	if err != nil {
+		return fmt.Errorf("%w: invalid isolated CPUs %q: %v", ErrNodeMismatch, profile.IsolatedCPUs, err)
+	}
+	if len(isolatedCPUs) < requirements.CPUs {
+		return fmt.Errorf("%w: %d isolated CPUs are fewer than the required %d", ErrNodeMismatch, len(isolatedCPUs), requirements.CPUs)
+	}
+
+	if hugepageSize := profile.VMIHugepageSize(); hugepageSize != "" {
+		hugepagesResource := corev1.ResourceName(corev1.ResourceHugePagesPrefix + hugepageSize)
+		allocatable := node.Status.Allocatable[hugepagesResource]
+		if required := resource.MustParse(requirements.Memory); allocatable.Cmp(required) < 0 {
+			return fmt.Errorf("%w: allocatable %s %s is less than the required %s",
+				ErrNodeMismatch, hugepagesResource, allocatable.String(), required.String())
+		}
+	}
+
+	return nil
+}
+
+// ValidateKernelArgs checks the node kernel args, as rendered by tuned, include the ones the profile implies.
+func ValidateKernelArgs(profile Profile, nodeKernelArgs []string) error {
+	var missingKernelArgs []string
+	for _, kernelArg := range profile.ExpectedKernelArgs() {
+		if !slices.Contains(nodeKernelArgs, kernelArg) {
+			missingKernelArgs = append(missingKernelArgs, kernelArg)
+		}
+	}
+	if len(missingKernelArgs) > 0 {
+		return fmt.Errorf("%w: missing kernel args %v", ErrNodeMismatch, missingKernelArgs)
+	}
+
+	return nil
+}
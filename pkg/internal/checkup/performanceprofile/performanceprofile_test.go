@@ -0,0 +1,214 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package performanceprofile_test
+
+import (
+	"context"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/performanceprofile"
+)
+
+const testProfileName = "cnf-profile"
+
+var testRequirements = performanceprofile.Requirements{CPUs: 4, Memory: "4Gi"}
+
+func TestNewShouldParseThePerformanceProfile(t *testing.T) {
+	profile, err := performanceprofile.New(newRawProfile())
+	assert.NoError(t, err)
+
+	expectedProfile := performanceprofile.Profile{
+		Name:           testProfileName,
+		NodeSelector:   map[string]string{"node-role.kubernetes.io/worker-cnf": ""},
+		IsolatedCPUs:   "4-11",
+		ReservedCPUs:   "0-3",
+		HugepageSize:   "1G",
+		RealtimeKernel: true,
+		KernelArgs:     []string{"nmi_watchdog=0"},
+	}
+	assert.Equal(t, expectedProfile, profile)
+	assert.Equal(t, "1Gi", profile.VMIHugepageSize())
+	assert.Equal(t, []string{"nohz_full=4-11", "default_hugepagesz=1G", "hugepagesz=1G", "nmi_watchdog=0"},
+		profile.ExpectedKernelArgs())
+}
+
+func TestNewShouldReadTheRuntimeClassOfTheStatus(t *testing.T) {
+	rawProfile := newRawProfile()
+	assert.NoError(t, unstructured.SetNestedField(rawProfile.Object, "rt-class", "status", "runtimeClass"))
+
+	profile, err := performanceprofile.New(rawProfile)
+	assert.NoError(t, err)
+	assert.Equal(t, "rt-class", profile.RuntimeClass)
+}
+
+func TestMatch(t *testing.T) {
+	workerProfile := performanceprofile.Profile{Name: "worker", NodeSelector: map[string]string{"role": "worker"}}
+	cnfProfile := performanceprofile.Profile{Name: "cnf", NodeSelector: map[string]string{"role": "worker", "cnf": "true"}}
+	profiles := []performanceprofile.Profile{workerProfile, cnfProfile}
+
+	t.Run("should return the only matching profile", func(t *testing.T) {
+		profile, err := performanceprofile.Match(profiles, map[string]string{"role": "worker"})
+		assert.NoError(t, err)
+		assert.Equal(t, workerProfile, profile)
+	})
+
+	t.Run("should fail when no profile matches", func(t *testing.T) {
+		_, err := performanceprofile.Match(profiles, map[string]string{"role": "master"})
+		assert.ErrorIs(t, err, performanceprofile.ErrNoMatchingProfile)
+	})
+
+	t.Run("should fail when multiple profiles match", func(t *testing.T) {
+		_, err := performanceprofile.Match(profiles, map[string]string{"role": "worker", "cnf": "true"})
+		assert.ErrorContains(t, err, "multiple performance profiles match the node [worker cnf]")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	profile, err := performanceprofile.New(newRawProfile())
+	assert.NoError(t, err)
+
+	t.Run("should pass when the node matches the profile", func(t *testing.T) {
+		assert.NoError(t, performanceprofile.Validate(profile, newNode("5.14.0-284.rt14.285.el9_2.x86_64", "8Gi"), testRequirements))
+	})
+
+	t.Run("should fail when the node does not run a realtime kernel", func(t *testing.T) {
+		for _, kernelVersion := range []string{"5.14.0-284.el9_2.x86_64", "6.2.0-rt-virt.x86_64", "5.14.0-284.el9_2.x86_64+debug-rtc"} {
+			err := performanceprofile.Validate(profile, newNode(kernelVersion, "8Gi"), testRequirements)
+			assert.ErrorIs(t, err, performanceprofile.ErrNodeMismatch)
+			assert.ErrorContains(t, err, "is not a realtime kernel")
+		}
+	})
+
+	t.Run("should fail when the node lacks hugepages", func(t *testing.T) {
+		err := performanceprofile.Validate(profile, newNode("5.14.0-284.rt14.285.el9_2.x86_64", "2Gi"), testRequirements)
+		assert.ErrorIs(t, err, performanceprofile.ErrNodeMismatch)
+		assert.ErrorContains(t, err, "allocatable hugepages-1Gi 2Gi is less than the required 4Gi")
+	})
+
+	t.Run("should fail when there are too few isolated CPUs", func(t *testing.T) {
+		requirements := performanceprofile.Requirements{CPUs: 10, Memory: "4Gi"}
+		err := performanceprofile.Validate(profile, newNode("5.14.0-284.rt14.285.el9_2.x86_64", "8Gi"), requirements)
+		assert.ErrorIs(t, err, performanceprofile.ErrNodeMismatch)
+		assert.ErrorContains(t, err, "8 isolated CPUs are fewer than the required 10")
+	})
+}
+
+func TestValidateKernelArgs(t *testing.T) {
+	profile, err := performanceprofile.New(newRawProfile())
+	assert.NoError(t, err)
+
+	t.Run("should pass when the node has all the expected kernel args", func(t *testing.T) {
+		nodeKernelArgs := []string{"skew_tick=1", "nohz_full=4-11", "default_hugepagesz=1G", "hugepagesz=1G", "nmi_watchdog=0"}
+		assert.NoError(t, performanceprofile.ValidateKernelArgs(profile, nodeKernelArgs))
+	})
+
+	t.Run("should fail listing the missing kernel args", func(t *testing.T) {
+		err := performanceprofile.ValidateKernelArgs(profile, []string{"nohz_full=4-11", "hugepagesz=1G"})
+		assert.ErrorIs(t, err, performanceprofile.ErrNodeMismatch)
+		assert.ErrorContains(t, err, "missing kernel args [default_hugepagesz=1G nmi_watchdog=0]")
+	})
+}
+
+func TestDiscoverShouldValidateTheKernelArgsRenderedByTuned(t *testing.T) {
+	const testNodeName = "worker-cnf-0"
+
+	t.Run("should pass when tuned renders the expected kernel args", func(t *testing.T) {
+		c := &clientStub{bootcmdline: "skew_tick=1 nohz_full=4-11 default_hugepagesz=1G hugepagesz=1G nmi_watchdog=0"}
+		profile, err := performanceprofile.Discover(context.Background(), c, testNodeName, testRequirements)
+		assert.NoError(t, err)
+		assert.Equal(t, testProfileName, profile.Name)
+		assert.Equal(t, performanceprofile.TunedNamespace+"/"+testNodeName, c.requestedTunedProfile)
+	})
+
+	t.Run("should fail when tuned does not render the expected kernel args", func(t *testing.T) {
+		c := &clientStub{bootcmdline: "skew_tick=1 nohz_full=4-11"}
+		_, err := performanceprofile.Discover(context.Background(), c, testNodeName, testRequirements)
+		assert.ErrorIs(t, err, performanceprofile.ErrNodeMismatch)
+		assert.ErrorContains(t, err, "missing kernel args [default_hugepagesz=1G hugepagesz=1G nmi_watchdog=0]")
+	})
+
+	t.Run("should skip the kernel args validation when the node has no tuned profile", func(t *testing.T) {
+		c := &clientStub{tunedProfileAbsent: true}
+		_, err := performanceprofile.Discover(context.Background(), c, testNodeName, testRequirements)
+		assert.NoError(t, err)
+	})
+}
+
+type clientStub struct {
+	bootcmdline           string
+	tunedProfileAbsent    bool
+	requestedTunedProfile string
+}
+
+func (cs *clientStub) GetNode(_ context.Context, name string) (*corev1.Node, error) {
+	node := newNode("5.14.0-284.rt14.285.el9_2.x86_64", "8Gi")
+	node.Name = name
+	node.Labels = map[string]string{"node-role.kubernetes.io/worker-cnf": ""}
+	return node, nil
+}
+
+func (cs *clientStub) ListPerformanceProfiles(_ context.Context) ([]unstructured.Unstructured, error) {
+	return []unstructured.Unstructured{newRawProfile()}, nil
+}
+
+func (cs *clientStub) GetTunedProfile(_ context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	cs.requestedTunedProfile = namespace + "/" + name
+	if cs.tunedProfileAbsent {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Group: "tuned.openshift.io", Resource: "profiles"}, name)
+	}
+
+	tunedProfile := &unstructured.Unstructured{}
+	tunedProfile.SetNamespace(namespace)
+	tunedProfile.SetName(name)
+	tunedProfile.SetAnnotations(map[string]string{performanceprofile.TunedBootcmdlineAnnotation: cs.bootcmdline})
+	return tunedProfile, nil
+}
+
+func newRawProfile() unstructured.Unstructured {
+	return unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "performance.openshift.io/v2",
+		"kind":       "PerformanceProfile",
+		"metadata":   map[string]interface{}{"name": testProfileName},
+		"spec": map[string]interface{}{
+			"cpu":                  map[string]interface{}{"isolated": "4-11", "reserved": "0-3"},
+			"hugepages":            map[string]interface{}{"defaultHugepagesSize": "1G"},
+			"nodeSelector":         map[string]interface{}{"node-role.kubernetes.io/worker-cnf": ""},
+			"realTimeKernel":       map[string]interface{}{"enabled": true},
+			"additionalKernelArgs": []interface{}{"nmi_watchdog=0"},
+		},
+	}}
+}
+
+func newNode(kernelVersion, hugepages string) *corev1.Node {
+	return &corev1.Node{
+		Status: corev1.NodeStatus{
+			NodeInfo:    corev1.NodeSystemInfo{KernelVersion: kernelVersion},
+			Allocatable: corev1.ResourceList{"hugepages-1Gi": resource.MustParse(hugepages)},
+		},
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package pod
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type Option func(pod *corev1.Pod)
+
+func New(name string, options ...Option) *corev1.Pod {
+	newPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+		},
+	}
+
+	for _, f := range options {
+		f(newPod)
+	}
+
+	return newPod
+}
+
+func WithOwnerReference(ownerName, ownerUID string) Option {
+	return func(pod *corev1.Pod) {
+		if ownerUID != "" && ownerName != "" {
+			pod.ObjectMeta.OwnerReferences = append(pod.ObjectMeta.OwnerReferences, metav1.OwnerReference{
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Name:       ownerName,
+				UID:        types.UID(ownerUID),
+			})
+		}
+	}
+}
+
+func WithLabel(key, value string) Option {
+	return func(pod *corev1.Pod) {
+		if pod.ObjectMeta.Labels == nil {
+			pod.ObjectMeta.Labels = map[string]string{}
+		}
+
+		pod.ObjectMeta.Labels[key] = value
+	}
+}
+
+func WithAnnotation(key, value string) Option {
+	return func(pod *corev1.Pod) {
+		if pod.ObjectMeta.Annotations == nil {
+			pod.ObjectMeta.Annotations = map[string]string{}
+		}
+
+		pod.ObjectMeta.Annotations[key] = value
+	}
+}
+
+func WithNodeSelector(nodeName string) Option {
+	return func(pod *corev1.Pod) {
+		if nodeName == "" {
+			return
+		}
+
+		if pod.Spec.NodeSelector == nil {
+			pod.Spec.NodeSelector = map[string]string{}
+		}
+
+		pod.Spec.NodeSelector[corev1.LabelHostname] = nodeName
+	}
+}
+
+func WithRuntimeClassName(runtimeClassName string) Option {
+	return func(pod *corev1.Pod) {
+		if runtimeClassName != "" {
+			pod.Spec.RuntimeClassName = pointer(runtimeClassName)
+		}
+	}
+}
+
+func WithZeroTerminationGracePeriodSeconds() Option {
+	return func(pod *corev1.Pod) {
+		pod.Spec.TerminationGracePeriodSeconds = pointer(int64(0))
+	}
+}
+
+// WithContainer adds an unprivileged container.
+func WithContainer(name, image string, command, args []string) Option {
+	return func(pod *corev1.Pod) {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{
+			Name:    name,
+			Image:   image,
+			Command: command,
+			Args:    args,
+			SecurityContext: &corev1.SecurityContext{
+				AllowPrivilegeEscalation: pointer(false),
+				Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+				SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+			},
+		})
+	}
+}
+
+// WithEmptyDirVolume adds an emptyDir volume, mounted on all the containers at the given path.
+func WithEmptyDirVolume(name, mountPath string) Option {
+	return func(pod *corev1.Pod) {
+		pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+
+		for i := range pod.Spec.Containers {
+			pod.Spec.Containers[i].VolumeMounts = append(pod.Spec.Containers[i].VolumeMounts,
+				corev1.VolumeMount{Name: name, MountPath: mountPath})
+		}
+	}
+}
+
+// WithGuaranteedResources sets equal CPU and memory requests and limits on all the containers,
+// placing the pod in the guaranteed QoS class. Integer CPUs are allocated exclusively by a static CPU manager.
+func WithGuaranteedResources(cpu, memory string) Option {
+	return func(pod *corev1.Pod) {
+		resources := corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse(cpu),
+			corev1.ResourceMemory: resource.MustParse(memory),
+		}
+
+		for i := range pod.Spec.Containers {
+			pod.Spec.Containers[i].Resources = corev1.ResourceRequirements{
+				Requests: resources.DeepCopy(),
+				Limits:   resources.DeepCopy(),
+			}
+		}
+	}
+}
+
+// WithCapabilities adds the given capabilities to all the containers.
+func WithCapabilities(capabilities ...corev1.Capability) Option {
+	return func(pod *corev1.Pod) {
+		for i := range pod.Spec.Containers {
+			securityContext := pod.Spec.Containers[i].SecurityContext
+			if securityContext == nil {
+				securityContext = &corev1.SecurityContext{}
+				pod.Spec.Containers[i].SecurityContext = securityContext
+			}
+			if securityContext.Capabilities == nil {
+				securityContext.Capabilities = &corev1.Capabilities{}
+			}
+			securityContext.Capabilities.Add = append(securityContext.Capabilities.Add, capabilities...)
+		}
+	}
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
@@ -23,20 +23,37 @@ import (
 	"context"
 	"time"
 
+	authv1 "k8s.io/api/authorization/v1"
 	k8scorev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
 
 	kvcorev1 "kubevirt.io/api/core/v1"
 	"kubevirt.io/client-go/kubecli"
 )
 
+var (
+	performanceProfilesResource = schema.GroupVersionResource{
+		Group: "performance.openshift.io", Version: "v2", Resource: "performanceprofiles",
+	}
+	tunedProfilesResource = schema.GroupVersionResource{
+		Group: "tuned.openshift.io", Version: "v1", Resource: "profiles",
+	}
+)
+
 type Client struct {
 	kubecli.KubevirtClient
 }
 
-func New() (*Client, error) {
-	config, err := rest.InClusterConfig()
+// New creates a client using the in-cluster configuration,
+// or the given kubeconfig file in case its path is not empty.
+func New(kubeconfigPath string) (*Client, error) {
+	config, err := restConfig(kubeconfigPath)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +66,14 @@ func New() (*Client, error) {
 	return &Client{client}, nil
 }
 
+func restConfig(kubeconfigPath string) (*rest.Config, error) {
+	if kubeconfigPath == "" {
+		return rest.InClusterConfig()
+	}
+
+	return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+}
+
 func (c *Client) CreateVirtualMachineInstance(ctx context.Context,
 	namespace string,
 	vmi *kvcorev1.VirtualMachineInstance) (*kvcorev1.VirtualMachineInstance, error) {
@@ -59,6 +84,13 @@ func (c *Client) GetVirtualMachineInstance(ctx context.Context, namespace, name
 	return c.KubevirtClient.VirtualMachineInstance(namespace).Get(ctx, name, &metav1.GetOptions{})
 }
 
+// WatchVirtualMachineInstance watches the given VMI, starting with its current state.
+func (c *Client) WatchVirtualMachineInstance(ctx context.Context, namespace, name string) (watch.Interface, error) {
+	return c.KubevirtClient.VirtualMachineInstance(namespace).Watch(ctx, metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("metadata.name", name).String(),
+	})
+}
+
 func (c *Client) DeleteVirtualMachineInstance(ctx context.Context, namespace, name string) error {
 	return c.KubevirtClient.VirtualMachineInstance(namespace).Delete(ctx, name, &metav1.DeleteOptions{})
 }
@@ -74,6 +106,55 @@ func (c *Client) CreateConfigMap(ctx context.Context, namespace string, configMa
 	return c.CoreV1().ConfigMaps(namespace).Create(ctx, configMap, metav1.CreateOptions{})
 }
 
+func (c *Client) GetConfigMap(ctx context.Context, namespace, name string) (*k8scorev1.ConfigMap, error) {
+	return c.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *Client) UpdateConfigMap(ctx context.Context, namespace string, configMap *k8scorev1.ConfigMap) (*k8scorev1.ConfigMap, error) {
+	return c.CoreV1().ConfigMaps(namespace).Update(ctx, configMap, metav1.UpdateOptions{})
+}
+
 func (c *Client) DeleteConfigMap(ctx context.Context, namespace, name string) error {
 	return c.CoreV1().ConfigMaps(namespace).Delete(ctx, name, metav1.DeleteOptions{})
 }
+
+func (c *Client) CreatePod(ctx context.Context, namespace string, pod *k8scorev1.Pod) (*k8scorev1.Pod, error) {
+	return c.CoreV1().Pods(namespace).Create(ctx, pod, metav1.CreateOptions{})
+}
+
+func (c *Client) GetPod(ctx context.Context, namespace, name string) (*k8scorev1.Pod, error) {
+	return c.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *Client) DeletePod(ctx context.Context, namespace, name string) error {
+	return c.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{})
+}
+
+func (c *Client) ListPods(ctx context.Context, namespace, labelSelector string) ([]k8scorev1.Pod, error) {
+	pods, err := c.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		return nil, err
+	}
+	return pods.Items, nil
+}
+
+func (c *Client) GetNode(ctx context.Context, name string) (*k8scorev1.Node, error) {
+	return c.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *Client) ListPerformanceProfiles(ctx context.Context) ([]unstructured.Unstructured, error) {
+	performanceProfiles, err := c.DynamicClient().Resource(performanceProfilesResource).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return performanceProfiles.Items, nil
+}
+
+func (c *Client) GetTunedProfile(ctx context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	return c.DynamicClient().Resource(tunedProfilesResource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+}
+
+func (c *Client) CreateSelfSubjectAccessReview(ctx context.Context,
+	review *authv1.SelfSubjectAccessReview) (*authv1.SelfSubjectAccessReview, error) {
+	return c.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, review, metav1.CreateOptions{})
+}
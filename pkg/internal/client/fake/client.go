@@ -0,0 +1,467 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+// Package fake provides an in-memory implementation of the clients used by the checkup,
+// allowing the checkup flow to be exercised without a cluster.
+package fake
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+
+	kvcorev1 "kubevirt.io/api/core/v1"
+	"kubevirt.io/client-go/kubecli"
+)
+
+const NodeName = "fake-node"
+
+var (
+	vmisResource          = schema.GroupResource{Group: kvcorev1.SchemeGroupVersion.Group, Resource: "virtualmachineinstances"}
+	configMapsResource    = schema.GroupResource{Resource: "configmaps"}
+	podsResource          = schema.GroupResource{Resource: "pods"}
+	nodesResource         = schema.GroupResource{Resource: "nodes"}
+	tunedProfilesResource = schema.GroupResource{Group: "tuned.openshift.io", Resource: "profiles"}
+)
+
+// Client keeps the created objects in memory.
+// Created VMIs are immediately scheduled to NodeName and ready, each with its own scripted serial console.
+// Created pods are immediately running on NodeName, except for the ones running oslat,
+// which immediately succeed, reporting the recorded baseline oslat transcript.
+// Each VMI is run by a virt-launcher pod, listed along with the created pods, using the default runtime class.
+type Client struct {
+	mu                  sync.Mutex
+	vmis                map[string]*kvcorev1.VirtualMachineInstance
+	configMaps          map[string]*corev1.ConfigMap
+	pods                map[string]*corev1.Pod
+	consoles            map[string]*SerialConsole
+	consoleOpts         []ConsoleOption
+	nextConsoleOpts     []ConsoleOption
+	nodes               map[string]*corev1.Node
+	performanceProfiles []unstructured.Unstructured
+	tunedProfiles       map[string]*unstructured.Unstructured
+	defaultRuntimeClass string
+	// nextPodRejectionReason fails the next created pod, as if the node rejected it.
+	nextPodRejectionReason string
+}
+
+// NewClient returns a Client whose VMIs' serial consoles are configured with the given options.
+func NewClient(consoleOpts ...ConsoleOption) *Client {
+	return &Client{
+		vmis:          map[string]*kvcorev1.VirtualMachineInstance{},
+		configMaps:    map[string]*corev1.ConfigMap{},
+		pods:          map[string]*corev1.Pod{},
+		consoles:      map[string]*SerialConsole{},
+		consoleOpts:   consoleOpts,
+		nodes:         map[string]*corev1.Node{},
+		tunedProfiles: map[string]*unstructured.Unstructured{},
+	}
+}
+
+func (c *Client) CreateVirtualMachineInstance(_ context.Context,
+	namespace string,
+	vmi *kvcorev1.VirtualMachineInstance) (*kvcorev1.VirtualMachineInstance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, vmi.Name)
+	if _, exists := c.vmis[key]; exists {
+		return nil, k8serrors.NewAlreadyExists(vmisResource, vmi.Name)
+	}
+
+	createdVMI := vmi.DeepCopy()
+	createdVMI.Namespace = namespace
+	createdVMI.UID = types.UID("uid-" + vmi.Name)
+	createdVMI.Status.NodeName = NodeName
+	createdVMI.Status.Phase = kvcorev1.Running
+	createdVMI.Status.Conditions = append(createdVMI.Status.Conditions, kvcorev1.VirtualMachineInstanceCondition{
+		Type:   kvcorev1.VirtualMachineInstanceReady,
+		Status: corev1.ConditionTrue,
+	})
+
+	c.vmis[key] = createdVMI
+	c.consoles[key] = NewSerialConsole(vmi.Name, append(c.consoleOpts, c.nextConsoleOpts...)...)
+	c.nextConsoleOpts = nil
+
+	return createdVMI.DeepCopy(), nil
+}
+
+func (c *Client) GetVirtualMachineInstance(_ context.Context, namespace, name string) (*kvcorev1.VirtualMachineInstance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	vmi, exists := c.vmis[objectKey(namespace, name)]
+	if !exists {
+		return nil, k8serrors.NewNotFound(vmisResource, name)
+	}
+
+	return vmi.DeepCopy(), nil
+}
+
+// WatchVirtualMachineInstance returns a watch reporting the current state of the VMI, as VMIs never change.
+func (c *Client) WatchVirtualMachineInstance(_ context.Context, namespace, name string) (watch.Interface, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	const chanSize = 1
+	watcher := watch.NewFakeWithChanSize(chanSize, false)
+	if vmi, exists := c.vmis[objectKey(namespace, name)]; exists {
+		watcher.Add(vmi.DeepCopy())
+	}
+
+	return watcher, nil
+}
+
+func (c *Client) DeleteVirtualMachineInstance(_ context.Context, namespace, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, name)
+	if _, exists := c.vmis[key]; !exists {
+		return k8serrors.NewNotFound(vmisResource, name)
+	}
+
+	delete(c.vmis, key)
+	delete(c.consoles, key)
+
+	return nil
+}
+
+func (c *Client) VMISerialConsole(namespace, name string, _ time.Duration) (kubecli.StreamInterface, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	serialConsole, exists := c.consoles[objectKey(namespace, name)]
+	if !exists {
+		return nil, k8serrors.NewNotFound(vmisResource, name)
+	}
+
+	return serialConsole, nil
+}
+
+func (c *Client) CreateConfigMap(_ context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, configMap.Name)
+	if _, exists := c.configMaps[key]; exists {
+		return nil, k8serrors.NewAlreadyExists(configMapsResource, configMap.Name)
+	}
+
+	createdConfigMap := configMap.DeepCopy()
+	createdConfigMap.Namespace = namespace
+	c.configMaps[key] = createdConfigMap
+
+	return createdConfigMap.DeepCopy(), nil
+}
+
+func (c *Client) GetConfigMap(_ context.Context, namespace, name string) (*corev1.ConfigMap, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	configMap, exists := c.configMaps[objectKey(namespace, name)]
+	if !exists {
+		return nil, k8serrors.NewNotFound(configMapsResource, name)
+	}
+
+	return configMap.DeepCopy(), nil
+}
+
+func (c *Client) UpdateConfigMap(_ context.Context, namespace string, configMap *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, configMap.Name)
+	if _, exists := c.configMaps[key]; !exists {
+		return nil, k8serrors.NewNotFound(configMapsResource, configMap.Name)
+	}
+
+	updatedConfigMap := configMap.DeepCopy()
+	updatedConfigMap.Namespace = namespace
+	c.configMaps[key] = updatedConfigMap
+
+	return updatedConfigMap.DeepCopy(), nil
+}
+
+func (c *Client) DeleteConfigMap(_ context.Context, namespace, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, name)
+	if _, exists := c.configMaps[key]; !exists {
+		return k8serrors.NewNotFound(configMapsResource, name)
+	}
+
+	delete(c.configMaps, key)
+
+	return nil
+}
+
+func (c *Client) CreatePod(_ context.Context, namespace string, pod *corev1.Pod) (*corev1.Pod, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, pod.Name)
+	if _, exists := c.pods[key]; exists {
+		return nil, k8serrors.NewAlreadyExists(podsResource, pod.Name)
+	}
+
+	createdPod := pod.DeepCopy()
+	createdPod.Namespace = namespace
+	createdPod.Spec.NodeName = NodeName
+	createdPod.Status.Phase = corev1.PodRunning
+	if c.nextPodRejectionReason != "" {
+		createdPod.Status.Phase = corev1.PodFailed
+		createdPod.Status.Reason = c.nextPodRejectionReason
+		createdPod.Status.Message = "Pod was rejected: Node didn't have enough resource"
+		c.nextPodRejectionReason = ""
+	} else if runsOslat(createdPod) {
+		createdPod.Status.Phase = corev1.PodSucceeded
+		createdPod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+			Name: createdPod.Spec.Containers[0].Name,
+			State: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Message: baselineOslatTranscript},
+			},
+		}}
+	}
+	c.pods[key] = createdPod
+
+	return createdPod.DeepCopy(), nil
+}
+
+func (c *Client) GetPod(_ context.Context, namespace, name string) (*corev1.Pod, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pod, exists := c.pods[objectKey(namespace, name)]
+	if !exists {
+		return nil, k8serrors.NewNotFound(podsResource, name)
+	}
+
+	return pod.DeepCopy(), nil
+}
+
+func (c *Client) DeletePod(_ context.Context, namespace, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := objectKey(namespace, name)
+	if _, exists := c.pods[key]; !exists {
+		return k8serrors.NewNotFound(podsResource, name)
+	}
+
+	delete(c.pods, key)
+
+	return nil
+}
+
+func runsOslat(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		if strings.Contains(strings.Join(append(container.Command, container.Args...), " "), "oslat ") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Client) ListPods(_ context.Context, namespace, labelSelector string) ([]corev1.Pod, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	selector, err := labels.Parse(labelSelector)
+	if err != nil {
+		return nil, err
+	}
+
+	pods := c.launcherPods()
+	for _, pod := range c.pods {
+		pods = append(pods, pod)
+	}
+
+	var matchingPods []corev1.Pod
+	for _, pod := range pods {
+		if pod.Namespace == namespace && selector.Matches(labels.Set(pod.Labels)) {
+			matchingPods = append(matchingPods, *pod.DeepCopy())
+		}
+	}
+
+	return matchingPods, nil
+}
+
+func (c *Client) launcherPods() []*corev1.Pod {
+	var launcherPods []*corev1.Pod
+	for _, vmi := range c.vmis {
+		launcherPod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "virt-launcher-" + vmi.Name,
+				Namespace: vmi.Namespace,
+				Labels:    map[string]string{"kubevirt.io/created-by": string(vmi.UID)},
+			},
+			Spec:   corev1.PodSpec{NodeName: NodeName},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+		if c.defaultRuntimeClass != "" {
+			launcherPod.Spec.RuntimeClassName = &c.defaultRuntimeClass
+		}
+		launcherPods = append(launcherPods, launcherPod)
+	}
+	return launcherPods
+}
+
+func (c *Client) GetNode(_ context.Context, name string) (*corev1.Node, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	node, exists := c.nodes[name]
+	if !exists {
+		return nil, k8serrors.NewNotFound(nodesResource, name)
+	}
+
+	return node.DeepCopy(), nil
+}
+
+func (c *Client) ListPerformanceProfiles(_ context.Context) ([]unstructured.Unstructured, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var performanceProfiles []unstructured.Unstructured
+	for _, performanceProfile := range c.performanceProfiles {
+		performanceProfiles = append(performanceProfiles, *performanceProfile.DeepCopy())
+	}
+
+	return performanceProfiles, nil
+}
+
+func (c *Client) GetTunedProfile(_ context.Context, namespace, name string) (*unstructured.Unstructured, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tunedProfile, exists := c.tunedProfiles[namespace+"/"+name]
+	if !exists {
+		return nil, k8serrors.NewNotFound(tunedProfilesResource, name)
+	}
+
+	return tunedProfile.DeepCopy(), nil
+}
+
+// SetNextConsoleOptions configures the serial console of the next created VMI only, on top of the client console options.
+func (c *Client) SetNextConsoleOptions(consoleOpts ...ConsoleOption) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextConsoleOpts = consoleOpts
+}
+
+// RejectNextPod makes the node reject the next created pod with the given reason, the way the kubelet fails pods it cannot admit.
+func (c *Client) RejectNextPod(reason string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextPodRejectionReason = reason
+}
+
+// AddNode adds a node, which VMIs and pods are not scheduled to.
+func (c *Client) AddNode(node *corev1.Node) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nodes[node.Name] = node.DeepCopy()
+}
+
+func (c *Client) AddPerformanceProfile(performanceProfile *unstructured.Unstructured) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.performanceProfiles = append(c.performanceProfiles, *performanceProfile.DeepCopy())
+}
+
+func (c *Client) AddTunedProfile(tunedProfile *unstructured.Unstructured) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.tunedProfiles[tunedProfile.GetNamespace()+"/"+tunedProfile.GetName()] = tunedProfile.DeepCopy()
+}
+
+// SetDefaultRuntimeClass sets the runtime class the virt-launcher pods run with, as the KubeVirt default one does.
+func (c *Client) SetDefaultRuntimeClass(runtimeClassName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.defaultRuntimeClass = runtimeClassName
+}
+
+// VirtualMachineInstanceNames returns the "namespace/name" of the existing VMIs.
+func (c *Client) VirtualMachineInstanceNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return sortedKeys(c.vmis)
+}
+
+// ConfigMapNames returns the "namespace/name" of the existing ConfigMaps.
+func (c *Client) ConfigMapNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return sortedKeys(c.configMaps)
+}
+
+// PodNames returns the "namespace/name" of the existing pods.
+func (c *Client) PodNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return sortedKeys(c.pods)
+}
+
+// ConfigMap returns the existing ConfigMap with the given "namespace/name", or nil if there is none.
+func (c *Client) ConfigMap(fullName string) *corev1.ConfigMap {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if configMap, exists := c.configMaps[fullName]; exists {
+		return configMap.DeepCopy()
+	}
+	return nil
+}
+
+func sortedKeys[T any](objects map[string]T) []string {
+	keys := make([]string, 0, len(objects))
+	for key := range objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func objectKey(namespace, name string) string {
+	return namespace + "/" + name
+}
@@ -0,0 +1,400 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package fake
+
+import (
+	"bufio"
+	_ "embed"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"kubevirt.io/client-go/kubecli"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+)
+
+var (
+	//go:embed transcripts/boot.txt
+	bootTranscript string
+	//go:embed transcripts/boot-timing.txt
+	bootTimingTranscript string
+	//go:embed transcripts/cmdline.txt
+	cmdlineTranscript string
+	//go:embed transcripts/oslat.txt
+	oslatTranscript string
+	//go:embed transcripts/baseline-oslat.txt
+	baselineOslatTranscript string
+	//go:embed transcripts/trace.txt
+	traceTranscript string
+	//go:embed transcripts/interrupts-before.txt
+	interruptsBeforeTranscript string
+	//go:embed transcripts/interrupts-after.txt
+	interruptsAfterTranscript string
+	//go:embed transcripts/softirqs-before.txt
+	softIRQsBeforeTranscript string
+	//go:embed transcripts/softirqs-after.txt
+	softIRQsAfterTranscript string
+	//go:embed transcripts/schedstat-before.txt
+	schedStatBeforeTranscript string
+	//go:embed transcripts/schedstat-after.txt
+	schedStatAfterTranscript string
+	//go:embed transcripts/rtla-osnoise.txt
+	rtlaOsnoiseTranscript string
+	//go:embed transcripts/rtla-timerlat.txt
+	rtlaTimerlatTranscript string
+)
+
+var ErrDisconnected = errors.New("websocket: close 1006 (abnormal closure): unexpected EOF")
+
+var (
+	// detachedCommandRegex matches a command run in the background, writing its output and exit code to the given files.
+	detachedCommandRegex = regexp.MustCompile(`^\(setsid sh -c '(.+) > (\S+) 2>&1; echo \$\? > (\S+)' > /dev/null 2>&1 < /dev/null &\)$`)
+	// sentinelCommandRegex matches a command run by a console session, between its output sentinels.
+	sentinelCommandRegex = regexp.MustCompile(`^echo '(\w+)''(\w+)'; (.+); echo "(\w+):\$\?"$`)
+	catFileRegex         = regexp.MustCompile(`^cat (\S+)( 2>/dev/null \|\| true)?$`)
+	// windowsCommandRegex matches the oslat measurement windows loop, along with its optional shorter last window.
+	windowsCommandRegex = regexp.MustCompile(`^\(for i in \$\(seq (\d+)\); do echo "([^$"]+) \$\(date -u \+%s\)"; (.+) \|\| exit \$\?; done` +
+		`(?:; echo "[^$"]+ \$\(date -u \+%s\)"; (.+))?\)$`)
+)
+
+const (
+	OslatCommandPrefix = "taskset -c 2-3 oslat "
+	GuestKernelVersion = "4.18.0-477.10.1.rt7.274.el8_8.x86_64"
+
+	commandNotFoundExitCode = 127
+
+	// promptRedrawInterval is the time during which repeated empty lines at the login prompt
+	// draw a single prompt, the way a getty does while it is still redrawing the prompt.
+	promptRedrawInterval = 500 * time.Millisecond
+)
+
+type consoleState int
+
+const (
+	booting consoleState = iota
+	loginPrompt
+	passwordPrompt
+	loggedIn
+)
+
+type command struct {
+	prefix   string
+	output   string
+	exitCode int
+	// laterOutputs replace the output on the following runs of the command, the last one repeating.
+	laterOutputs []string
+	runs         int
+}
+
+type ConsoleOption func(*SerialConsole)
+
+// WithLoggedInUser skips the boot and login, as if the guest was already logged into as root.
+func WithLoggedInUser() ConsoleOption {
+	return func(s *SerialConsole) {
+		s.state = loggedIn
+	}
+}
+
+// WithCommandOutput scripts the output and exit code of commands starting with the given prefix.
+// It takes precedence over the recorded transcripts.
+func WithCommandOutput(commandPrefix, output string, exitCode int) ConsoleOption {
+	return func(s *SerialConsole) {
+		s.commands = append([]command{{prefix: commandPrefix, output: output, exitCode: exitCode}}, s.commands...)
+	}
+}
+
+// WithDisconnectOn drops the console connection once a command starting with the given prefix is entered.
+func WithDisconnectOn(commandPrefix string) ConsoleOption {
+	return func(s *SerialConsole) {
+		s.disconnectOn = commandPrefix
+	}
+}
+
+// SerialConsole is a scripted VMI serial console.
+// On the first connection it replays the recorded boot transcript, ending with a login prompt
+// garbled by late boot messages. Once logged in, it echoes the entered commands and replies
+// with their scripted output, followed by a shell prompt.
+// Commands run in the background complete immediately, their output and exit code kept in files which outlive the connection.
+type SerialConsole struct {
+	mu           sync.Mutex
+	hostname     string
+	password     string
+	state        consoleState
+	username     string
+	lastExitCode int
+	lastPromptAt time.Time
+	commands     []command
+	disconnectOn string
+	files        map[string]string
+}
+
+func NewSerialConsole(hostname string, opts ...ConsoleOption) *SerialConsole {
+	s := &SerialConsole{
+		hostname: hostname,
+		password: config.VMIPassword,
+		state:    booting,
+		files:    map[string]string{},
+		commands: []command{
+			{prefix: "stty "},
+			{prefix: "dmesg "},
+			{prefix: "cat /proc/cmdline", output: cmdlineTranscript},
+			{prefix: "uname -r", output: GuestKernelVersion},
+			{prefix: "cat " + config.BootScriptTimingFileFullPath, output: bootTimingTranscript},
+			{prefix: OslatCommandPrefix, output: oslatTranscript},
+			{prefix: "tail -n 1000 " + config.GuestTracingDirectory + "/trace", output: traceTranscript},
+			{prefix: "cat /proc/interrupts", output: interruptsBeforeTranscript, laterOutputs: []string{interruptsAfterTranscript}},
+			{prefix: "cat /proc/softirqs", output: softIRQsBeforeTranscript, laterOutputs: []string{softIRQsAfterTranscript}},
+			{prefix: "cat /proc/schedstat", output: schedStatBeforeTranscript, laterOutputs: []string{schedStatAfterTranscript}},
+			{prefix: "rtla osnoise top ", output: rtlaOsnoiseTranscript},
+			{prefix: "rtla timerlat top ", output: rtlaTimerlatTranscript},
+			{prefix: "stress-ng ", output: "[1] 1342"},
+			{prefix: "sleep 1; pgrep -x stress-ng"},
+			{prefix: "pkill -x stress-ng"},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Stream serves a single console connection, until either the client closes its input or the connection is dropped.
+func (s *SerialConsole) Stream(options kubecli.StreamOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.state == booting {
+		if err := write(options.Out, bootTranscript); err != nil {
+			return nil
+		}
+		s.state = loginPrompt
+	}
+
+	in := bufio.NewReader(options.In)
+	for {
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+
+		if err := s.handleLine(options.Out, strings.TrimSuffix(line, "\n")); err != nil {
+			if errors.Is(err, ErrDisconnected) {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+func (s *SerialConsole) AsConn() net.Conn {
+	return nil
+}
+
+func (s *SerialConsole) handleLine(out io.Writer, line string) error {
+	switch s.state {
+	case loginPrompt:
+		return s.handleUsername(out, line)
+	case passwordPrompt:
+		return s.handlePassword(out, line)
+	case loggedIn:
+		return s.handleCommand(out, line)
+	}
+
+	return nil
+}
+
+func (s *SerialConsole) handleUsername(out io.Writer, username string) error {
+	if username == "" {
+		if time.Since(s.lastPromptAt) < promptRedrawInterval {
+			return nil
+		}
+		s.lastPromptAt = time.Now()
+		return write(out, "\n"+s.loginPrompt())
+	}
+
+	s.username = username
+	s.state = passwordPrompt
+	return write(out, username+"\nPassword: ")
+}
+
+func (s *SerialConsole) handlePassword(out io.Writer, password string) error {
+	if s.username != "root" || password != s.password {
+		s.state = loginPrompt
+		s.lastPromptAt = time.Now()
+		return write(out, "\n\nLogin incorrect\n\n"+s.loginPrompt())
+	}
+
+	s.state = loggedIn
+	return write(out, "\nLast login: Tue Jun  6 10:12:49 on ttyS0\n"+s.shellPrompt())
+}
+
+func (s *SerialConsole) handleCommand(out io.Writer, commandLine string) error {
+	if err := write(out, commandLine+"\n"); err != nil {
+		return err
+	}
+
+	if s.disconnectOn != "" && strings.HasPrefix(foregroundCommand(commandLine), s.disconnectOn) {
+		return ErrDisconnected
+	}
+
+	if commandLine == "" {
+		return write(out, s.shellPrompt())
+	}
+
+	output, exitCode := s.run(commandLine)
+	s.lastExitCode = exitCode
+	if output != "" && !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+
+	return write(out, output+s.shellPrompt())
+}
+
+func (s *SerialConsole) run(commandLine string) (output string, exitCode int) {
+	switch commandLine {
+	case "echo $?":
+		return fmt.Sprintf("%d", s.lastExitCode), 0
+	case "date -u +%s":
+		return fmt.Sprintf("%d", time.Now().Unix()), 0
+	}
+
+	if matches := sentinelCommandRegex.FindStringSubmatch(commandLine); matches != nil {
+		output, exitCode := s.run(matches[3])
+		if output != "" && !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		return fmt.Sprintf("%s%s\n%s%s:%d", matches[1], matches[2], output, matches[4], exitCode), 0
+	}
+
+	if matches := detachedCommandRegex.FindStringSubmatch(commandLine); matches != nil {
+		output, exitCode := s.run(matches[1])
+		s.files[matches[2]] = output
+		s.files[matches[3]] = fmt.Sprintf("%d\n", exitCode)
+		return "", 0
+	}
+
+	if matches := windowsCommandRegex.FindStringSubmatch(commandLine); matches != nil {
+		return s.runWindows(matches)
+	}
+
+	for i := range s.commands {
+		cmd := &s.commands[i]
+		if strings.HasPrefix(commandLine, cmd.prefix) {
+			output = cmd.output
+			if cmd.runs > 0 && len(cmd.laterOutputs) > 0 {
+				output = cmd.laterOutputs[min(cmd.runs, len(cmd.laterOutputs))-1]
+			}
+			cmd.runs++
+			return output, cmd.exitCode
+		}
+	}
+
+	if output, exitCode, handled := s.runFileCommand(commandLine); handled {
+		return output, exitCode
+	}
+
+	return fmt.Sprintf("-bash: %s: command not found", strings.Fields(commandLine)[0]), commandNotFoundExitCode
+}
+
+// runWindows runs the windows of the oslat measurement windows loop one after the other,
+// stopping on the first failing window.
+func (s *SerialConsole) runWindows(matches []string) (output string, exitCode int) {
+	windowsCount, _ := strconv.Atoi(matches[1])
+	windowCommands := make([]string, windowsCount, windowsCount+1)
+	for i := range windowCommands {
+		windowCommands[i] = matches[3]
+	}
+	if matches[4] != "" {
+		windowCommands = append(windowCommands, matches[4])
+	}
+
+	sb := strings.Builder{}
+	for _, windowCommand := range windowCommands {
+		windowOutput, windowExitCode := s.run(windowCommand)
+		sb.WriteString(fmt.Sprintf("%s %d\n%s", matches[2], time.Now().Unix(), windowOutput))
+		if windowOutput != "" && !strings.HasSuffix(windowOutput, "\n") {
+			sb.WriteString("\n")
+		}
+		if windowExitCode != 0 {
+			return sb.String(), windowExitCode
+		}
+	}
+
+	return sb.String(), 0
+}
+
+// runFileCommand reads and removes the files written by the commands run in the background.
+func (s *SerialConsole) runFileCommand(commandLine string) (output string, exitCode int, handled bool) {
+	if strings.HasPrefix(commandLine, "rm -f ") {
+		for _, path := range strings.Fields(commandLine)[2:] {
+			delete(s.files, path)
+		}
+		return "", 0, true
+	}
+
+	matches := catFileRegex.FindStringSubmatch(commandLine)
+	if matches == nil {
+		return "", 0, false
+	}
+
+	content, exists := s.files[matches[1]]
+	switch {
+	case exists:
+		return content, 0, true
+	case matches[2] != "":
+		return "", 0, true
+	default:
+		return fmt.Sprintf("cat: %s: No such file or directory", matches[1]), 1, true
+	}
+}
+
+// foregroundCommand returns the command a background or a sentinel delimited command line runs, or the command line itself.
+func foregroundCommand(commandLine string) string {
+	if matches := sentinelCommandRegex.FindStringSubmatch(commandLine); matches != nil {
+		return foregroundCommand(matches[3])
+	}
+	if matches := detachedCommandRegex.FindStringSubmatch(commandLine); matches != nil {
+		return matches[1]
+	}
+	return commandLine
+}
+
+func (s *SerialConsole) loginPrompt() string {
+	return s.hostname + " login: "
+}
+
+func (s *SerialConsole) shellPrompt() string {
+	return fmt.Sprintf("[root@%s ~]# ", s.hostname)
+}
+
+// write translates line feeds the way a terminal does.
+func write(out io.Writer, text string) error {
+	_, err := io.WriteString(out, strings.ReplaceAll(text, "\n", "\r\n"))
+	return err
+}
@@ -21,7 +21,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	kconfig "github.com/kiagnose/kiagnose/kiagnose/config"
@@ -32,6 +35,28 @@ const (
 	VMUnderTestContainerDiskImageParamName = "vmUnderTestContainerDiskImage"
 	OslatDurationParamName                 = "oslatDuration"
 	OslatLatencyThresholdParamName         = "oslatLatencyThresholdMicroSeconds"
+	OslatMeasurementWindowParamName        = "oslatMeasurementWindow"
+	OslatTraceThresholdParamName           = "oslatTraceThresholdMicroSeconds"
+	FailOnUnexpectedInterruptsParamName    = "failOnUnexpectedInterrupts"
+	RtlaModeParamName                      = "rtlaMode"
+	RtlaDurationParamName                  = "rtlaDuration"
+	StressWorkloadsParamName               = "stressWorkloads"
+	StressImageParamName                   = "stressImage"
+	GuestHousekeepingLoadParamName         = "guestHousekeepingLoad"
+	GuestHousekeepingLoadWorkersParamName  = "guestHousekeepingLoadWorkers"
+	BaselineEnabledParamName               = "baselineEnabled"
+	BaselineImageParamName                 = "baselineImage"
+	BaselineMaxOverheadParamName           = "baselineMaxOverheadMicroSeconds"
+	VMUnderTestRuntimeClassNameParamName   = "vmUnderTestRuntimeClassName"
+	PerformanceProfileDiscoveryParamName   = "performanceProfileDiscovery"
+	HistoryConfigMapNameParamName          = "historyConfigMapName"
+	HistorySizeParamName                   = "historySize"
+	RegressionWindowParamName              = "regressionWindow"
+	RegressionThresholdParamName           = "regressionThresholdPercent"
+	GoldenBaselineConfigMapNameParamName   = "goldenBaselineConfigMapName"
+	InfraFailureRetriesParamName           = "infraFailureRetries"
+	SetupTimeoutParamName                  = "setupTimeout"
+	TeardownTimeoutParamName               = "teardownTimeout"
 )
 
 const (
@@ -40,16 +65,109 @@ const (
 	OslatDefaultDuration         = 5 * time.Minute
 	OslatDefaultLatencyThreshold = 40 * time.Microsecond
 
+	// OslatMaxMeasurementWindows bounds the number of measurement windows, and with it the size of the reported time series.
+	OslatMaxMeasurementWindows = 1000
+
+	DefaultSetupTimeout    = 10 * time.Minute
+	DefaultTeardownTimeout = 2 * time.Minute
+
+	// OslatTimeoutGrace is the time given to oslat to complete, on top of its configured duration.
+	OslatTimeoutGrace = 5 * time.Minute
+	// OslatWindowOverhead is the time each oslat measurement window takes on top of its duration, to start and preheat the cores.
+	OslatWindowOverhead = 2 * time.Second
+
+	// VMIExpectedBootDuration is the time the VM under test is expected to take to boot.
+	// The setup includes two boots: the initial boot and the reboot following the tuned profile configuration.
+	VMIExpectedBootDuration = 90 * time.Second
+
+	// RtlaOsnoiseMode and RtlaTimerlatMode are the supported rtla tools.
+	RtlaOsnoiseMode  = "osnoise"
+	RtlaTimerlatMode = "timerlat"
+
+	RtlaDefaultDuration = time.Minute
+
+	// RtlaTimeoutGrace is the time given to rtla to complete, on top of its configured duration.
+	RtlaTimeoutGrace = time.Minute
+
+	// The stress workloads, run as noisy neighbors of the VM under test.
+	StressCPUWorkload     = "cpu"
+	StressMemoryWorkload  = "memory"
+	StressIOWorkload      = "io"
+	StressNetworkWorkload = "network"
+
+	StressDefaultImage = "docker.io/colinianking/stress-ng:latest"
+
+	// The loads run on the VM under test housekeeping vCPUs, exercising the cross-vCPU interference.
+	GuestCPULoad    = "cpu"
+	GuestMemoryLoad = "memory"
+	GuestTLBLoad    = "tlb"
+
+	GuestHousekeepingLoadDefaultWorkers = 2
+	GuestHousekeepingLoadMaxWorkers     = 16
+
+	BaselineDefaultImage = "quay.io/container-perf-tools/oslat:latest"
+
+	// HistoryDefaultSize is the number of runs kept in the history ConfigMap, the oldest being dropped first.
+	HistoryDefaultSize = 20
+	HistoryMaxSize     = 100
+
+	// RegressionDefaultWindow is the number of previous runs on the same node the current run is compared against.
+	RegressionDefaultWindow = 5
+
+	// RegressionDefaultThresholdPercent is the max latency increase over the previous runs average, which is not a regression.
+	RegressionDefaultThresholdPercent = 20
+
+	// InfraFailureMaxRetries bounds the fresh attempts made on infrastructure failures, each requiring its own timeout.
+	InfraFailureMaxRetries = 3
+
+	// VMUnderTestHousekeepingCPUs are the VM under test vCPUs left for the operating system housekeeping.
+	VMUnderTestHousekeepingCPUs = "0-1"
+
+	// VMUnderTestIsolatedCPUs are the VM under test vCPUs isolated by the tuned profile, on which oslat measures.
+	VMUnderTestIsolatedCPUs = "2-3"
+
+	// GuestTracingDirectory is where tracefs is mounted in the VM under test.
+	GuestTracingDirectory = "/sys/kernel/tracing"
+
 	BootScriptName                          = "realtime-checkup-boot.sh"
 	BootScriptBinDirectory                  = "/usr/bin/"
 	BootScriptTunedAdmSetMarkerFileFullPath = "/var/realtime-checkup-tuned-adm-set-marker"
 	BootScriptReadinessMarkerFileFullPath   = "/tmp/realtime-checkup-ready-marker"
+	// BootScriptTimingFileFullPath is where the boot script records the time of its steps, as "<step> <epoch seconds>" lines.
+	BootScriptTimingFileFullPath = "/var/realtime-checkup-timing"
+	// BootScriptStatusFileFullPath is where the boot script records the outcome of its last step.
+	BootScriptStatusFileFullPath = "/var/realtime-checkup-boot-status"
+	// BootScriptTraceFileFullPath is where the boot script traces the commands it runs, over both boots.
+	BootScriptTraceFileFullPath = "/var/realtime-checkup-boot-trace.log"
+
+	BootStepGuestBooted   = "guestBooted"
+	BootStepTunedApplied  = "tunedApplied"
+	BootStepGuestRebooted = "guestRebooted"
 )
 
 var (
-	ErrInvalidVMContainerDiskImage  = errors.New("invalid VM container disk image")
-	ErrInvalidOslatDuration         = errors.New("invalid oslat duration")
-	ErrInvalidOslatLatencyThreshold = errors.New("invalid oslat latency threshold")
+	ErrInvalidVMContainerDiskImage         = errors.New("invalid VM container disk image")
+	ErrInvalidOslatDuration                = errors.New("invalid oslat duration")
+	ErrInvalidOslatLatencyThreshold        = errors.New("invalid oslat latency threshold")
+	ErrInvalidOslatMeasurementWindow       = errors.New("invalid oslat measurement window")
+	ErrInvalidOslatTraceThreshold          = errors.New("invalid oslat trace threshold")
+	ErrInvalidFailOnUnexpectedInterrupts   = errors.New("invalid fail on unexpected interrupts")
+	ErrInvalidRtlaMode                     = errors.New("invalid rtla mode")
+	ErrInvalidRtlaDuration                 = errors.New("invalid rtla duration")
+	ErrInvalidStressWorkloads              = errors.New("invalid stress workloads")
+	ErrInvalidGuestHousekeepingLoad        = errors.New("invalid guest housekeeping load")
+	ErrInvalidGuestHousekeepingLoadWorkers = errors.New("invalid guest housekeeping load workers")
+	ErrInvalidBaselineEnabled              = errors.New("invalid baseline enabled")
+	ErrInvalidBaselineMaxOverhead          = errors.New("invalid baseline max overhead")
+	ErrInvalidPerformanceProfileDiscovery  = errors.New("invalid performance profile discovery")
+	ErrInvalidHistorySize                  = errors.New("invalid history size")
+	ErrInvalidRegressionWindow             = errors.New("invalid regression window")
+	ErrInvalidRegressionThreshold          = errors.New("invalid regression threshold")
+	ErrInvalidGoldenBaselineConfigMapName  = errors.New("invalid golden baseline ConfigMap name")
+	ErrInvalidInfraFailureRetries          = errors.New("invalid infrastructure failure retries")
+	ErrInvalidSetupTimeout                 = errors.New("invalid setup timeout")
+	ErrInvalidTeardownTimeout              = errors.New("invalid teardown timeout")
+	ErrInsufficientTimeout                 = errors.New("insufficient timeout")
 )
 
 type Config struct {
@@ -59,6 +177,28 @@ type Config struct {
 	VMUnderTestContainerDiskImage string
 	OslatDuration                 time.Duration
 	OslatLatencyThreshold         time.Duration
+	OslatMeasurementWindow        time.Duration
+	OslatTraceThreshold           time.Duration
+	FailOnUnexpectedInterrupts    bool
+	RtlaMode                      string
+	RtlaDuration                  time.Duration
+	StressWorkloads               []string
+	StressImage                   string
+	GuestHousekeepingLoad         string
+	GuestHousekeepingLoadWorkers  int
+	BaselineEnabled               bool
+	BaselineImage                 string
+	BaselineMaxOverhead           time.Duration
+	VMUnderTestRuntimeClassName   string
+	PerformanceProfileDiscovery   bool
+	HistoryConfigMapName          string
+	HistorySize                   int
+	RegressionWindow              int
+	RegressionThresholdPercent    int
+	GoldenBaselineConfigMapName   string
+	InfraFailureRetries           int
+	SetupTimeout                  time.Duration
+	TeardownTimeout               time.Duration
 }
 
 func New(baseConfig kconfig.Config) (Config, error) {
@@ -67,29 +207,420 @@ func New(baseConfig kconfig.Config) (Config, error) {
 		PodUID:                        baseConfig.PodUID,
 		VMUnderTestTargetNodeName:     baseConfig.Params[VMUnderTestTargetNodeNameParamName],
 		VMUnderTestContainerDiskImage: baseConfig.Params[VMUnderTestContainerDiskImageParamName],
+		VMUnderTestRuntimeClassName:   baseConfig.Params[VMUnderTestRuntimeClassNameParamName],
 		OslatDuration:                 OslatDefaultDuration,
 		OslatLatencyThreshold:         OslatDefaultLatencyThreshold,
+		SetupTimeout:                  DefaultSetupTimeout,
+		TeardownTimeout:               DefaultTeardownTimeout,
 	}
 
 	if newConfig.VMUnderTestContainerDiskImage == "" {
 		return Config{}, ErrInvalidVMContainerDiskImage
 	}
 
-	if rawOslatDuration := baseConfig.Params[OslatDurationParamName]; rawOslatDuration != "" {
+	if err := newConfig.setOslatParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setFailOnUnexpectedInterruptsParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setRtlaParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setStressParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setGuestHousekeepingLoadParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setBaselineParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setPerformanceProfileDiscoveryParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setHistoryParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setGoldenBaselineParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setInfraFailureRetriesParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.setStageTimeoutParams(baseConfig.Params); err != nil {
+		return Config{}, err
+	}
+
+	if err := newConfig.validateTimeout(baseConfig.Timeout); err != nil {
+		return Config{}, err
+	}
+
+	return newConfig, nil
+}
+
+// NewForAnalysis returns a Config holding only the parameters needed to analyze oslat results,
+// such as when re-analyzing a saved oslat transcript.
+func NewForAnalysis(params map[string]string) (Config, error) {
+	newConfig := Config{
+		OslatDuration:         OslatDefaultDuration,
+		OslatLatencyThreshold: OslatDefaultLatencyThreshold,
+	}
+
+	if err := newConfig.setOslatParams(params); err != nil {
+		return Config{}, err
+	}
+
+	return newConfig, nil
+}
+
+func (c *Config) setOslatParams(params map[string]string) error {
+	if rawOslatDuration := params[OslatDurationParamName]; rawOslatDuration != "" {
 		oslatDuration, err := time.ParseDuration(rawOslatDuration)
 		if err != nil {
-			return Config{}, ErrInvalidOslatDuration
+			return ErrInvalidOslatDuration
 		}
-		newConfig.OslatDuration = oslatDuration
+		c.OslatDuration = oslatDuration
 	}
 
-	if rawOslatLatencyThreshold := baseConfig.Params[OslatLatencyThresholdParamName]; rawOslatLatencyThreshold != "" {
+	if rawOslatLatencyThreshold := params[OslatLatencyThresholdParamName]; rawOslatLatencyThreshold != "" {
 		oslatLatencyThresholdMicroSeconds, err := strconv.Atoi(rawOslatLatencyThreshold)
 		if err != nil {
-			return Config{}, ErrInvalidOslatLatencyThreshold
+			return ErrInvalidOslatLatencyThreshold
 		}
-		newConfig.OslatLatencyThreshold = time.Duration(oslatLatencyThresholdMicroSeconds) * time.Microsecond
+		c.OslatLatencyThreshold = time.Duration(oslatLatencyThresholdMicroSeconds) * time.Microsecond
 	}
 
-	return newConfig, nil
+	if rawOslatMeasurementWindow := params[OslatMeasurementWindowParamName]; rawOslatMeasurementWindow != "" {
+		oslatMeasurementWindow, err := time.ParseDuration(rawOslatMeasurementWindow)
+		if err != nil || oslatMeasurementWindow <= 0 || oslatMeasurementWindow > c.OslatDuration {
+			return ErrInvalidOslatMeasurementWindow
+		}
+		if windows := c.OslatDuration / oslatMeasurementWindow; windows > OslatMaxMeasurementWindows {
+			return fmt.Errorf("%w: splits the oslat duration into %d windows, more than the maximum of %d",
+				ErrInvalidOslatMeasurementWindow, windows, OslatMaxMeasurementWindows)
+		}
+		c.OslatMeasurementWindow = oslatMeasurementWindow
+	}
+
+	if rawOslatTraceThreshold := params[OslatTraceThresholdParamName]; rawOslatTraceThreshold != "" {
+		oslatTraceThresholdMicroSeconds, err := strconv.Atoi(rawOslatTraceThreshold)
+		if err != nil || oslatTraceThresholdMicroSeconds <= 0 {
+			return ErrInvalidOslatTraceThreshold
+		}
+		c.OslatTraceThreshold = time.Duration(oslatTraceThresholdMicroSeconds) * time.Microsecond
+	}
+
+	return nil
+}
+
+// setRtlaParams enables the rtla phase when a mode is given, running for the given or default duration.
+func (c *Config) setRtlaParams(params map[string]string) error {
+	rawRtlaDuration := params[RtlaDurationParamName]
+
+	switch rtlaMode := params[RtlaModeParamName]; rtlaMode {
+	case "":
+		if rawRtlaDuration != "" {
+			return fmt.Errorf("%w: requires the %q parameter", ErrInvalidRtlaDuration, RtlaModeParamName)
+		}
+		return nil
+	case RtlaOsnoiseMode, RtlaTimerlatMode:
+		c.RtlaMode = rtlaMode
+	default:
+		return fmt.Errorf("%w: %q, should be either %q or %q", ErrInvalidRtlaMode, rtlaMode, RtlaOsnoiseMode, RtlaTimerlatMode)
+	}
+
+	c.RtlaDuration = RtlaDefaultDuration
+	if rawRtlaDuration != "" {
+		rtlaDuration, err := time.ParseDuration(rawRtlaDuration)
+		if err != nil || rtlaDuration < time.Second {
+			return ErrInvalidRtlaDuration
+		}
+		c.RtlaDuration = rtlaDuration
+	}
+
+	return nil
+}
+
+// setStressParams enables the stress workloads given as a comma separated list, e.g. "cpu,memory".
+// They are pinned to the VM under test node, which should therefore be given.
+func (c *Config) setStressParams(params map[string]string) error {
+	rawStressWorkloads := params[StressWorkloadsParamName]
+	if rawStressWorkloads == "" {
+		return nil
+	}
+
+	if c.VMUnderTestTargetNodeName == "" {
+		return fmt.Errorf("%w: requires the %q parameter", ErrInvalidStressWorkloads, VMUnderTestTargetNodeNameParamName)
+	}
+
+	for _, workload := range strings.Split(rawStressWorkloads, ",") {
+		workload = strings.TrimSpace(workload)
+		switch workload {
+		case StressCPUWorkload, StressMemoryWorkload, StressIOWorkload, StressNetworkWorkload:
+		default:
+			return fmt.Errorf("%w: unknown workload %q, should be one of %q, %q, %q or %q", ErrInvalidStressWorkloads,
+				workload, StressCPUWorkload, StressMemoryWorkload, StressIOWorkload, StressNetworkWorkload)
+		}
+		if slices.Contains(c.StressWorkloads, workload) {
+			return fmt.Errorf("%w: workload %q is given more than once", ErrInvalidStressWorkloads, workload)
+		}
+		c.StressWorkloads = append(c.StressWorkloads, workload)
+	}
+
+	c.StressImage = StressDefaultImage
+	if stressImage := params[StressImageParamName]; stressImage != "" {
+		c.StressImage = stressImage
+	}
+
+	return nil
+}
+
+func (c *Config) setGuestHousekeepingLoadParams(params map[string]string) error {
+	rawGuestHousekeepingLoadWorkers := params[GuestHousekeepingLoadWorkersParamName]
+
+	switch guestHousekeepingLoad := params[GuestHousekeepingLoadParamName]; guestHousekeepingLoad {
+	case "":
+		if rawGuestHousekeepingLoadWorkers != "" {
+			return fmt.Errorf("%w: requires the %q parameter", ErrInvalidGuestHousekeepingLoadWorkers, GuestHousekeepingLoadParamName)
+		}
+		return nil
+	case GuestCPULoad, GuestMemoryLoad, GuestTLBLoad:
+		c.GuestHousekeepingLoad = guestHousekeepingLoad
+	default:
+		return fmt.Errorf("%w: %q, should be one of %q, %q or %q", ErrInvalidGuestHousekeepingLoad,
+			guestHousekeepingLoad, GuestCPULoad, GuestMemoryLoad, GuestTLBLoad)
+	}
+
+	c.GuestHousekeepingLoadWorkers = GuestHousekeepingLoadDefaultWorkers
+	if rawGuestHousekeepingLoadWorkers != "" {
+		workers, err := strconv.Atoi(rawGuestHousekeepingLoadWorkers)
+		if err != nil || workers < 1 || workers > GuestHousekeepingLoadMaxWorkers {
+			return fmt.Errorf("%w: should be between 1 and %d", ErrInvalidGuestHousekeepingLoadWorkers, GuestHousekeepingLoadMaxWorkers)
+		}
+		c.GuestHousekeepingLoadWorkers = workers
+	}
+
+	return nil
+}
+
+// setBaselineParams enables the bare-metal baseline, which runs oslat in a pod on the VM under test node.
+// The node should therefore be given.
+func (c *Config) setBaselineParams(params map[string]string) error {
+	rawBaselineMaxOverhead := params[BaselineMaxOverheadParamName]
+
+	if rawBaselineEnabled := params[BaselineEnabledParamName]; rawBaselineEnabled != "" {
+		baselineEnabled, err := strconv.ParseBool(rawBaselineEnabled)
+		if err != nil {
+			return ErrInvalidBaselineEnabled
+		}
+		c.BaselineEnabled = baselineEnabled
+	}
+
+	if !c.BaselineEnabled {
+		if rawBaselineMaxOverhead != "" {
+			return fmt.Errorf("%w: requires the %q parameter", ErrInvalidBaselineMaxOverhead, BaselineEnabledParamName)
+		}
+		return nil
+	}
+
+	if c.VMUnderTestTargetNodeName == "" {
+		return fmt.Errorf("%w: requires the %q parameter", ErrInvalidBaselineEnabled, VMUnderTestTargetNodeNameParamName)
+	}
+
+	c.BaselineImage = BaselineDefaultImage
+	if baselineImage := params[BaselineImageParamName]; baselineImage != "" {
+		c.BaselineImage = baselineImage
+	}
+
+	if rawBaselineMaxOverhead != "" {
+		baselineMaxOverheadMicroSeconds, err := strconv.Atoi(rawBaselineMaxOverhead)
+		if err != nil || baselineMaxOverheadMicroSeconds <= 0 {
+			return ErrInvalidBaselineMaxOverhead
+		}
+		c.BaselineMaxOverhead = time.Duration(baselineMaxOverheadMicroSeconds) * time.Microsecond
+	}
+
+	return nil
+}
+
+// setHistoryParams enables the run history when a ConfigMap name is given.
+// The ConfigMap is created in the checkup namespace on the first run, and kept across runs.
+func (c *Config) setHistoryParams(params map[string]string) error {
+	c.HistoryConfigMapName = params[HistoryConfigMapNameParamName]
+	if c.HistoryConfigMapName == "" {
+		historyParamErrors := map[string]error{
+			HistorySizeParamName:         ErrInvalidHistorySize,
+			RegressionWindowParamName:    ErrInvalidRegressionWindow,
+			RegressionThresholdParamName: ErrInvalidRegressionThreshold,
+		}
+		for paramName, paramErr := range historyParamErrors {
+			if params[paramName] != "" {
+				return fmt.Errorf("%w: requires the %q parameter", paramErr, HistoryConfigMapNameParamName)
+			}
+		}
+		return nil
+	}
+
+	c.HistorySize = HistoryDefaultSize
+	if rawHistorySize := params[HistorySizeParamName]; rawHistorySize != "" {
+		historySize, err := strconv.Atoi(rawHistorySize)
+		if err != nil || historySize < 1 || historySize > HistoryMaxSize {
+			return fmt.Errorf("%w: should be between 1 and %d", ErrInvalidHistorySize, HistoryMaxSize)
+		}
+		c.HistorySize = historySize
+	}
+
+	c.RegressionWindow = min(RegressionDefaultWindow, c.HistorySize)
+	if rawRegressionWindow := params[RegressionWindowParamName]; rawRegressionWindow != "" {
+		regressionWindow, err := strconv.Atoi(rawRegressionWindow)
+		if err != nil || regressionWindow < 1 || regressionWindow > c.HistorySize {
+			return fmt.Errorf("%w: should be between 1 and the history size %d", ErrInvalidRegressionWindow, c.HistorySize)
+		}
+		c.RegressionWindow = regressionWindow
+	}
+
+	c.RegressionThresholdPercent = RegressionDefaultThresholdPercent
+	if rawRegressionThreshold := params[RegressionThresholdParamName]; rawRegressionThreshold != "" {
+		regressionThresholdPercent, err := strconv.Atoi(rawRegressionThreshold)
+		if err != nil || regressionThresholdPercent <= 0 {
+			return ErrInvalidRegressionThreshold
+		}
+		c.RegressionThresholdPercent = regressionThresholdPercent
+	}
+
+	return nil
+}
+
+func (c *Config) setFailOnUnexpectedInterruptsParams(params map[string]string) error {
+	if rawFailOnUnexpectedInterrupts := params[FailOnUnexpectedInterruptsParamName]; rawFailOnUnexpectedInterrupts != "" {
+		failOnUnexpectedInterrupts, err := strconv.ParseBool(rawFailOnUnexpectedInterrupts)
+		if err != nil {
+			return ErrInvalidFailOnUnexpectedInterrupts
+		}
+		c.FailOnUnexpectedInterrupts = failOnUnexpectedInterrupts
+	}
+
+	return nil
+}
+
+// setPerformanceProfileDiscoveryParams enables discovering the performance profile of the VM under test node,
+// which should therefore be given.
+func (c *Config) setPerformanceProfileDiscoveryParams(params map[string]string) error {
+	rawPerformanceProfileDiscovery := params[PerformanceProfileDiscoveryParamName]
+	if rawPerformanceProfileDiscovery == "" {
+		return nil
+	}
+
+	performanceProfileDiscovery, err := strconv.ParseBool(rawPerformanceProfileDiscovery)
+	if err != nil {
+		return ErrInvalidPerformanceProfileDiscovery
+	}
+	if performanceProfileDiscovery && c.VMUnderTestTargetNodeName == "" {
+		return fmt.Errorf("%w: requires the %q parameter", ErrInvalidPerformanceProfileDiscovery, VMUnderTestTargetNodeNameParamName)
+	}
+	c.PerformanceProfileDiscovery = performanceProfileDiscovery
+
+	return nil
+}
+
+// setGoldenBaselineParams enables the comparison against the golden baseline held by the given ConfigMap.
+// The per core max latencies and the percentiles the golden baseline may expect are only measured in a single window.
+func (c *Config) setGoldenBaselineParams(params map[string]string) error {
+	c.GoldenBaselineConfigMapName = params[GoldenBaselineConfigMapNameParamName]
+	if c.GoldenBaselineConfigMapName != "" && c.OslatMeasurementWindow > 0 {
+		return fmt.Errorf("%w: cannot be used along with the %q parameter",
+			ErrInvalidGoldenBaselineConfigMapName, OslatMeasurementWindowParamName)
+	}
+
+	return nil
+}
+
+func (c *Config) setInfraFailureRetriesParams(params map[string]string) error {
+	if rawInfraFailureRetries := params[InfraFailureRetriesParamName]; rawInfraFailureRetries != "" {
+		infraFailureRetries, err := strconv.Atoi(rawInfraFailureRetries)
+		if err != nil || infraFailureRetries < 0 || infraFailureRetries > InfraFailureMaxRetries {
+			return fmt.Errorf("%w: should be between 0 and %d", ErrInvalidInfraFailureRetries, InfraFailureMaxRetries)
+		}
+		c.InfraFailureRetries = infraFailureRetries
+	}
+
+	return nil
+}
+
+// setStageTimeoutParams sets the setup and teardown timeouts, the setup one accommodating the VM under test boot and reboot.
+func (c *Config) setStageTimeoutParams(params map[string]string) error {
+	if rawSetupTimeout := params[SetupTimeoutParamName]; rawSetupTimeout != "" {
+		setupTimeout, err := time.ParseDuration(rawSetupTimeout)
+		if err != nil {
+			return ErrInvalidSetupTimeout
+		}
+		c.SetupTimeout = setupTimeout
+	}
+
+	if minSetupTimeout := 2 * VMIExpectedBootDuration; c.SetupTimeout < minSetupTimeout {
+		return fmt.Errorf("%w: %s is shorter than the VM under test expected boot and reboot time %s",
+			ErrInvalidSetupTimeout, c.SetupTimeout, minSetupTimeout)
+	}
+
+	if rawTeardownTimeout := params[TeardownTimeoutParamName]; rawTeardownTimeout != "" {
+		teardownTimeout, err := time.ParseDuration(rawTeardownTimeout)
+		if err != nil || teardownTimeout <= 0 {
+			return ErrInvalidTeardownTimeout
+		}
+		c.TeardownTimeout = teardownTimeout
+	}
+
+	return nil
+}
+
+// validateTimeout checks the checkup timeout accommodates all of the checkup stages of every attempt,
+// whether their timeouts were set explicitly or left to their defaults.
+func (c *Config) validateTimeout(timeout time.Duration) error {
+	bootDuration := 2 * VMIExpectedBootDuration
+	attemptTimeout := c.SetupTimeout + bootDuration + c.oslatRunDuration() + OslatTimeoutGrace + c.rtlaRequiredTimeout() +
+		c.baselineRequiredTimeout() + c.TeardownTimeout
+	if requiredTimeout := time.Duration(c.InfraFailureRetries+1) * attemptTimeout; timeout < requiredTimeout {
+		return fmt.Errorf("%w: %s is shorter than the required %s "+
+			"(setup timeout %s + VM under test boot and reboot %s + oslat duration %s + oslat grace %s + "+
+			"rtla duration and grace %s + baseline duration and grace %s + teardown timeout %s, for each of the %d attempts)",
+			ErrInsufficientTimeout, timeout, requiredTimeout,
+			c.SetupTimeout, bootDuration, c.oslatRunDuration(), OslatTimeoutGrace,
+			c.rtlaRequiredTimeout(), c.baselineRequiredTimeout(), c.TeardownTimeout, c.InfraFailureRetries+1)
+	}
+
+	return nil
+}
+
+// oslatRunDuration returns the oslat duration, along with the overhead of its measurement windows.
+func (c Config) oslatRunDuration() time.Duration {
+	if c.OslatMeasurementWindow == 0 {
+		return c.OslatDuration
+	}
+	windows := int64((c.OslatDuration + c.OslatMeasurementWindow - 1) / c.OslatMeasurementWindow)
+	return c.OslatDuration + time.Duration(windows)*OslatWindowOverhead
+}
+
+func (c Config) rtlaRequiredTimeout() time.Duration {
+	if c.RtlaMode == "" {
+		return 0
+	}
+	return c.RtlaDuration + RtlaTimeoutGrace
+}
+
+func (c Config) baselineRequiredTimeout() time.Duration {
+	if !c.BaselineEnabled {
+		return 0
+	}
+	return c.OslatDuration + OslatTimeoutGrace
 }
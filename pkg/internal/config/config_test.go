@@ -37,12 +37,18 @@ const (
 	testVMContainerDiskImage              = "quay.io/myorg/kubevirt-realtime-checkup-vm:latest"
 	testOslatDuration                     = "1h"
 	testOslatLatencyThresholdMicroSeconds = "50"
+	testOslatMeasurementWindow            = "1m"
+	testOslatTraceThresholdMicroSeconds   = "30"
+	testSetupTimeout                      = "15m"
+	testTeardownTimeout                   = "3m"
+	testTimeout                           = 6 * time.Hour
 )
 
 func TestNewShouldApplyDefaultsWhenOptionalFieldsAreMissing(t *testing.T) {
 	baseConfig := kconfig.Config{
 		PodName: testPodName,
 		PodUID:  testPodUID,
+		Timeout: testTimeout,
 		Params: map[string]string{
 			config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
 		},
@@ -58,6 +64,8 @@ func TestNewShouldApplyDefaultsWhenOptionalFieldsAreMissing(t *testing.T) {
 		VMUnderTestContainerDiskImage: testVMContainerDiskImage,
 		OslatDuration:                 config.OslatDefaultDuration,
 		OslatLatencyThreshold:         config.OslatDefaultLatencyThreshold,
+		SetupTimeout:                  config.DefaultSetupTimeout,
+		TeardownTimeout:               config.DefaultTeardownTimeout,
 	}
 	assert.Equal(t, expectedConfig, actualConfig)
 }
@@ -66,11 +74,31 @@ func TestNewShouldApplyUserConfig(t *testing.T) {
 	baseConfig := kconfig.Config{
 		PodName: testPodName,
 		PodUID:  testPodUID,
+		Timeout: testTimeout,
 		Params: map[string]string{
 			config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
 			config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
 			config.OslatDurationParamName:                 testOslatDuration,
 			config.OslatLatencyThresholdParamName:         testOslatLatencyThresholdMicroSeconds,
+			config.OslatMeasurementWindowParamName:        testOslatMeasurementWindow,
+			config.OslatTraceThresholdParamName:           testOslatTraceThresholdMicroSeconds,
+			config.FailOnUnexpectedInterruptsParamName:    "true",
+			config.RtlaModeParamName:                      config.RtlaTimerlatMode,
+			config.RtlaDurationParamName:                  "2m",
+			config.StressWorkloadsParamName:               "cpu, network",
+			config.GuestHousekeepingLoadParamName:         config.GuestTLBLoad,
+			config.GuestHousekeepingLoadWorkersParamName:  "4",
+			config.BaselineEnabledParamName:               "true",
+			config.BaselineMaxOverheadParamName:           "20",
+			config.VMUnderTestRuntimeClassNameParamName:   "performance-cnf",
+			config.PerformanceProfileDiscoveryParamName:   "true",
+			config.HistoryConfigMapNameParamName:          "realtime-checkup-history",
+			config.HistorySizeParamName:                   "50",
+			config.RegressionWindowParamName:              "10",
+			config.RegressionThresholdParamName:           "30",
+			config.InfraFailureRetriesParamName:           "1",
+			config.SetupTimeoutParamName:                  testSetupTimeout,
+			config.TeardownTimeoutParamName:               testTeardownTimeout,
 		},
 	}
 
@@ -84,6 +112,27 @@ func TestNewShouldApplyUserConfig(t *testing.T) {
 		VMUnderTestContainerDiskImage: testVMContainerDiskImage,
 		OslatDuration:                 time.Hour,
 		OslatLatencyThreshold:         50 * time.Microsecond,
+		OslatMeasurementWindow:        time.Minute,
+		OslatTraceThreshold:           30 * time.Microsecond,
+		FailOnUnexpectedInterrupts:    true,
+		RtlaMode:                      config.RtlaTimerlatMode,
+		RtlaDuration:                  2 * time.Minute,
+		StressWorkloads:               []string{config.StressCPUWorkload, config.StressNetworkWorkload},
+		StressImage:                   config.StressDefaultImage,
+		GuestHousekeepingLoad:         config.GuestTLBLoad,
+		GuestHousekeepingLoadWorkers:  4,
+		BaselineEnabled:               true,
+		BaselineImage:                 config.BaselineDefaultImage,
+		BaselineMaxOverhead:           20 * time.Microsecond,
+		VMUnderTestRuntimeClassName:   "performance-cnf",
+		PerformanceProfileDiscovery:   true,
+		HistoryConfigMapName:          "realtime-checkup-history",
+		HistorySize:                   50,
+		RegressionWindow:              10,
+		RegressionThresholdPercent:    30,
+		InfraFailureRetries:           1,
+		SetupTimeout:                  15 * time.Minute,
+		TeardownTimeout:               3 * time.Minute,
 	}
 	assert.Equal(t, expectedConfig, actualConfig)
 }
@@ -92,6 +141,7 @@ func TestNewShouldFailWhen(t *testing.T) {
 	type failureTestCase struct {
 		description    string
 		userParameters map[string]string
+		timeout        time.Duration
 		expectedError  error
 	}
 
@@ -121,13 +171,355 @@ func TestNewShouldFailWhen(t *testing.T) {
 			},
 			expectedError: config.ErrInvalidOslatLatencyThreshold,
 		},
+		{
+			description: "oslatMeasurementWindow is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatMeasurementWindowParamName:        "wrongValue",
+			},
+			expectedError: config.ErrInvalidOslatMeasurementWindow,
+		},
+		{
+			description: "oslatMeasurementWindow is longer than the oslat duration",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 "10m",
+				config.OslatMeasurementWindowParamName:        "15m",
+			},
+			expectedError: config.ErrInvalidOslatMeasurementWindow,
+		},
+		{
+			description: "oslatMeasurementWindow splits the oslat duration into too many windows",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 testOslatDuration,
+				config.OslatMeasurementWindowParamName:        "1s",
+			},
+			expectedError: config.ErrInvalidOslatMeasurementWindow,
+		},
+		{
+			description: "oslatTraceThresholdMicroSeconds is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatTraceThresholdParamName:           "wrongValue",
+			},
+			expectedError: config.ErrInvalidOslatTraceThreshold,
+		},
+		{
+			description: "oslatTraceThresholdMicroSeconds is not positive",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatTraceThresholdParamName:           "0",
+			},
+			expectedError: config.ErrInvalidOslatTraceThreshold,
+		},
+		{
+			description: "failOnUnexpectedInterrupts is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.FailOnUnexpectedInterruptsParamName:    "wrongValue",
+			},
+			expectedError: config.ErrInvalidFailOnUnexpectedInterrupts,
+		},
+		{
+			description: "rtlaMode is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.RtlaModeParamName:                      "hwnoise",
+			},
+			expectedError: config.ErrInvalidRtlaMode,
+		},
+		{
+			description: "rtlaDuration is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.RtlaModeParamName:                      config.RtlaOsnoiseMode,
+				config.RtlaDurationParamName:                  "wrongValue",
+			},
+			expectedError: config.ErrInvalidRtlaDuration,
+		},
+		{
+			description: "rtlaDuration is given without rtlaMode",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.RtlaDurationParamName:                  "1m",
+			},
+			expectedError: config.ErrInvalidRtlaDuration,
+		},
+		{
+			description: "stressWorkloads has an unknown workload",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
+				config.StressWorkloadsParamName:               "cpu,gpu",
+			},
+			expectedError: config.ErrInvalidStressWorkloads,
+		},
+		{
+			description: "stressWorkloads has a repeated workload",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
+				config.StressWorkloadsParamName:               "io,io",
+			},
+			expectedError: config.ErrInvalidStressWorkloads,
+		},
+		{
+			description: "stressWorkloads is given without vmUnderTestTargetNodeName",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.StressWorkloadsParamName:               "cpu",
+			},
+			expectedError: config.ErrInvalidStressWorkloads,
+		},
+		{
+			description: "guestHousekeepingLoad is unknown",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.GuestHousekeepingLoadParamName:         "gpu",
+			},
+			expectedError: config.ErrInvalidGuestHousekeepingLoad,
+		},
+		{
+			description: "guestHousekeepingLoadWorkers is out of range",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.GuestHousekeepingLoadParamName:         config.GuestCPULoad,
+				config.GuestHousekeepingLoadWorkersParamName:  "0",
+			},
+			expectedError: config.ErrInvalidGuestHousekeepingLoadWorkers,
+		},
+		{
+			description: "guestHousekeepingLoadWorkers is given without guestHousekeepingLoad",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.GuestHousekeepingLoadWorkersParamName:  "2",
+			},
+			expectedError: config.ErrInvalidGuestHousekeepingLoadWorkers,
+		},
+		{
+			description: "baselineEnabled is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
+				config.BaselineEnabledParamName:               "sometimes",
+			},
+			expectedError: config.ErrInvalidBaselineEnabled,
+		},
+		{
+			description: "baselineEnabled is given without vmUnderTestTargetNodeName",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.BaselineEnabledParamName:               "true",
+			},
+			expectedError: config.ErrInvalidBaselineEnabled,
+		},
+		{
+			description: "baselineMaxOverheadMicroSeconds is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
+				config.BaselineEnabledParamName:               "true",
+				config.BaselineMaxOverheadParamName:           "-5",
+			},
+			expectedError: config.ErrInvalidBaselineMaxOverhead,
+		},
+		{
+			description: "baselineMaxOverheadMicroSeconds is given without baselineEnabled",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.BaselineMaxOverheadParamName:           "5",
+			},
+			expectedError: config.ErrInvalidBaselineMaxOverhead,
+		},
+		{
+			description: "performanceProfileDiscovery is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.PerformanceProfileDiscoveryParamName:   "maybe",
+			},
+			expectedError: config.ErrInvalidPerformanceProfileDiscovery,
+		},
+		{
+			description: "performanceProfileDiscovery is given without vmUnderTestTargetNodeName",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.PerformanceProfileDiscoveryParamName:   "true",
+			},
+			expectedError: config.ErrInvalidPerformanceProfileDiscovery,
+		},
+		{
+			description: "historySize is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.HistoryConfigMapNameParamName:          "realtime-checkup-history",
+				config.HistorySizeParamName:                   "101",
+			},
+			expectedError: config.ErrInvalidHistorySize,
+		},
+		{
+			description: "historySize is given without historyConfigMapName",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.HistorySizeParamName:                   "10",
+			},
+			expectedError: config.ErrInvalidHistorySize,
+		},
+		{
+			description: "regressionWindow exceeds the history size",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.HistoryConfigMapNameParamName:          "realtime-checkup-history",
+				config.HistorySizeParamName:                   "3",
+				config.RegressionWindowParamName:              "4",
+			},
+			expectedError: config.ErrInvalidRegressionWindow,
+		},
+		{
+			description: "regressionThresholdPercent is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.HistoryConfigMapNameParamName:          "realtime-checkup-history",
+				config.RegressionThresholdParamName:           "0",
+			},
+			expectedError: config.ErrInvalidRegressionThreshold,
+		},
+		{
+			description: "regressionThresholdPercent is given without historyConfigMapName",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.RegressionThresholdParamName:           "10",
+			},
+			expectedError: config.ErrInvalidRegressionThreshold,
+		},
+		{
+			description: "goldenBaselineConfigMapName is given along with oslatMeasurementWindow",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatMeasurementWindowParamName:        "1m",
+				config.GoldenBaselineConfigMapNameParamName:   "golden-baseline",
+			},
+			expectedError: config.ErrInvalidGoldenBaselineConfigMapName,
+		},
+		{
+			description: "infraFailureRetries is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.InfraFailureRetriesParamName:           "wrongValue",
+			},
+			expectedError: config.ErrInvalidInfraFailureRetries,
+		},
+		{
+			description: "infraFailureRetries exceeds the maximum",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.InfraFailureRetriesParamName:           "4",
+			},
+			expectedError: config.ErrInvalidInfraFailureRetries,
+		},
+		{
+			description: "setupTimeout is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.SetupTimeoutParamName:                  "wrongValue",
+			},
+			expectedError: config.ErrInvalidSetupTimeout,
+		},
+		{
+			description: "setupTimeout is shorter than the VM under test expected boot and reboot time",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.SetupTimeoutParamName:                  "1m",
+			},
+			expectedError: config.ErrInvalidSetupTimeout,
+		},
+		{
+			description: "teardownTimeout is invalid",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.TeardownTimeoutParamName:               "wrongValue",
+			},
+			expectedError: config.ErrInvalidTeardownTimeout,
+		},
+		{
+			description: "timeout is shorter than the oslat duration",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 testOslatDuration,
+			},
+			timeout:       10 * time.Minute,
+			expectedError: config.ErrInsufficientTimeout,
+		},
+		{
+			description: "timeout does not accommodate the default setup and teardown timeouts",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 "10m",
+			},
+			timeout:       29 * time.Minute,
+			expectedError: config.ErrInsufficientTimeout,
+		},
+		{
+			description: "timeout does not accommodate all the checkup stages",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 testOslatDuration,
+				config.SetupTimeoutParamName:                  testSetupTimeout,
+				config.TeardownTimeoutParamName:               testTeardownTimeout,
+			},
+			timeout:       time.Hour + 20*time.Minute,
+			expectedError: config.ErrInsufficientTimeout,
+		},
+		{
+			description: "timeout does not accommodate the rtla run",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 testOslatDuration,
+				config.SetupTimeoutParamName:                  testSetupTimeout,
+				config.TeardownTimeoutParamName:               testTeardownTimeout,
+				config.RtlaModeParamName:                      config.RtlaOsnoiseMode,
+				config.RtlaDurationParamName:                  "10m",
+			},
+			timeout:       time.Hour + 30*time.Minute,
+			expectedError: config.ErrInsufficientTimeout,
+		},
+		{
+			description: "timeout does not accommodate the baseline run",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.VMUnderTestTargetNodeNameParamName:     testVMUnderTestTargetNodeName,
+				config.OslatDurationParamName:                 testOslatDuration,
+				config.SetupTimeoutParamName:                  testSetupTimeout,
+				config.TeardownTimeoutParamName:               testTeardownTimeout,
+				config.BaselineEnabledParamName:               "true",
+			},
+			timeout:       2 * time.Hour,
+			expectedError: config.ErrInsufficientTimeout,
+		},
+		{
+			description: "timeout does not accommodate the retries",
+			userParameters: map[string]string{
+				config.VMUnderTestContainerDiskImageParamName: testVMContainerDiskImage,
+				config.OslatDurationParamName:                 testOslatDuration,
+				config.SetupTimeoutParamName:                  testSetupTimeout,
+				config.TeardownTimeoutParamName:               testTeardownTimeout,
+				config.InfraFailureRetriesParamName:           "1",
+			},
+			timeout:       2 * time.Hour,
+			expectedError: config.ErrInsufficientTimeout,
+		},
 	}
 
 	for _, testCase := range testCases {
 		t.Run(testCase.description, func(t *testing.T) {
+			timeout := testTimeout
+			if testCase.timeout != 0 {
+				timeout = testCase.timeout
+			}
 			baseConfig := kconfig.Config{
 				PodName: testPodName,
 				PodUID:  testPodUID,
+				Timeout: timeout,
 				Params:  testCase.userParameters,
 			}
 
@@ -136,3 +528,35 @@ func TestNewShouldFailWhen(t *testing.T) {
 		})
 	}
 }
+
+func TestNewForAnalysis(t *testing.T) {
+	t.Run("should apply defaults", func(t *testing.T) {
+		actualConfig, err := config.NewForAnalysis(map[string]string{})
+		assert.NoError(t, err)
+
+		expectedConfig := config.Config{
+			OslatDuration:         config.OslatDefaultDuration,
+			OslatLatencyThreshold: config.OslatDefaultLatencyThreshold,
+		}
+		assert.Equal(t, expectedConfig, actualConfig)
+	})
+
+	t.Run("should apply user config", func(t *testing.T) {
+		actualConfig, err := config.NewForAnalysis(map[string]string{
+			config.OslatDurationParamName:         testOslatDuration,
+			config.OslatLatencyThresholdParamName: testOslatLatencyThresholdMicroSeconds,
+		})
+		assert.NoError(t, err)
+
+		expectedConfig := config.Config{
+			OslatDuration:         time.Hour,
+			OslatLatencyThreshold: 50 * time.Microsecond,
+		}
+		assert.Equal(t, expectedConfig, actualConfig)
+	})
+
+	t.Run("should fail when the latency threshold is invalid", func(t *testing.T) {
+		_, err := config.NewForAnalysis(map[string]string{config.OslatLatencyThresholdParamName: "wrongValue"})
+		assert.ErrorIs(t, err, config.ErrInvalidOslatLatencyThreshold)
+	})
+}
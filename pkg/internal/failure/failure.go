@@ -0,0 +1,115 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package failure
+
+import "errors"
+
+// Code classifies a checkup failure, allowing automation to act on it without parsing the failure reason.
+type Code string
+
+const (
+	Unclassified             Code = "Unclassified"
+	ConfigInvalid            Code = "ConfigInvalid"
+	PermissionDenied         Code = "PermissionDenied"
+	SchedulingFailed         Code = "SchedulingFailed"
+	VMIBootTimeout           Code = "VMIBootTimeout"
+	VMIFailed                Code = "VMIFailed"
+	GuestLoginFailed         Code = "GuestLoginFailed"
+	ToolExecutionFailed      Code = "ToolExecutionFailed"
+	ResultParseFailed        Code = "ResultParseFailed"
+	LatencyThresholdExceeded Code = "LatencyThresholdExceeded"
+	UnexpectedInterrupts     Code = "UnexpectedInterrupts"
+	TeardownFailed           Code = "TeardownFailed"
+	Aborted                  Code = "Aborted"
+	ConsoleDisconnected      Code = "ConsoleDisconnected"
+)
+
+// exitCodes are the distinct process exit codes of the failure codes, 1 being left for unclassified failures.
+var exitCodes = map[Code]int{
+	Unclassified:             1,
+	ConfigInvalid:            2,
+	PermissionDenied:         3,
+	SchedulingFailed:         4,
+	VMIBootTimeout:           5,
+	GuestLoginFailed:         6,
+	ToolExecutionFailed:      7,
+	ResultParseFailed:        8,
+	LatencyThresholdExceeded: 9,
+	UnexpectedInterrupts:     10,
+	TeardownFailed:           11,
+	Aborted:                  12,
+	VMIFailed:                13,
+	ConsoleDisconnected:      14,
+}
+
+// Infrastructure reports whether the code classifies an infrastructure failure, which a fresh attempt may not hit again.
+// Failures of the measurements themselves, such as a latency threshold breach or a failing tool, are never considered as such.
+func (c Code) Infrastructure() bool {
+	switch c {
+	case SchedulingFailed, VMIBootTimeout, VMIFailed, GuestLoginFailed, ConsoleDisconnected:
+		return true
+	default:
+		return false
+	}
+}
+
+// ExitCode returns the process exit code of the failure code.
+func (c Code) ExitCode() int {
+	if exitCode, exists := exitCodes[c]; exists {
+		return exitCode
+	}
+	return exitCodes[Unclassified]
+}
+
+// Error is an error classified by a failure code.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New classifies the error with the given code, unless it is nil or was already classified by a more specific cause.
+func New(code Code, err error) error {
+	if err == nil || CodeOf(err) != Unclassified {
+		return err
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// CodeOf returns the failure code of the error, which is Unclassified when it was not classified.
+// It is empty for a nil error.
+func CodeOf(err error) Code {
+	if err == nil {
+		return ""
+	}
+
+	var classifiedErr *Error
+	if errors.As(err, &classifiedErr) {
+		return classifiedErr.Code
+	}
+	return Unclassified
+}
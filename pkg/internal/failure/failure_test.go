@@ -0,0 +1,83 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package failure_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
+)
+
+func TestCodeOf(t *testing.T) {
+	err := errors.New("oslat test failed with exit code: 1")
+
+	assert.Empty(t, failure.CodeOf(nil))
+	assert.Equal(t, failure.Unclassified, failure.CodeOf(err))
+
+	classifiedErr := failure.New(failure.ToolExecutionFailed, err)
+	assert.Equal(t, failure.ToolExecutionFailed, failure.CodeOf(classifiedErr))
+	assert.Equal(t, failure.ToolExecutionFailed, failure.CodeOf(fmt.Errorf("failed to run Oslat: %w", classifiedErr)))
+	assert.ErrorIs(t, classifiedErr, err)
+	assert.Equal(t, err.Error(), classifiedErr.Error())
+}
+
+func TestNewShouldKeepTheMoreSpecificCode(t *testing.T) {
+	parseErr := failure.New(failure.ResultParseFailed, errors.New("failed parsing maximum latency from oslat results"))
+	err := failure.New(failure.ToolExecutionFailed, fmt.Errorf("failed to run Oslat: %w", parseErr))
+
+	assert.Equal(t, failure.ResultParseFailed, failure.CodeOf(err))
+	assert.NoError(t, failure.New(failure.ToolExecutionFailed, nil))
+}
+
+func TestInfrastructure(t *testing.T) {
+	for _, code := range []failure.Code{
+		failure.SchedulingFailed, failure.VMIBootTimeout, failure.VMIFailed, failure.GuestLoginFailed, failure.ConsoleDisconnected,
+	} {
+		assert.True(t, code.Infrastructure(), code)
+	}
+
+	for _, code := range []failure.Code{
+		failure.Unclassified, failure.ConfigInvalid, failure.PermissionDenied, failure.ToolExecutionFailed, failure.ResultParseFailed,
+		failure.LatencyThresholdExceeded, failure.UnexpectedInterrupts, failure.TeardownFailed, failure.Aborted,
+	} {
+		assert.False(t, code.Infrastructure(), code)
+	}
+}
+
+func TestExitCodesShouldBeDistinct(t *testing.T) {
+	codes := []failure.Code{
+		failure.Unclassified, failure.ConfigInvalid, failure.PermissionDenied, failure.SchedulingFailed, failure.VMIBootTimeout,
+		failure.GuestLoginFailed, failure.ToolExecutionFailed, failure.ResultParseFailed, failure.LatencyThresholdExceeded,
+		failure.UnexpectedInterrupts, failure.TeardownFailed, failure.Aborted, failure.VMIFailed, failure.ConsoleDisconnected,
+	}
+
+	exitCodes := map[int]failure.Code{}
+	for _, code := range codes {
+		exitCode := code.ExitCode()
+		assert.NotZero(t, exitCode)
+		assert.NotContains(t, exitCodes, exitCode, "%s shares its exit code with %s", code, exitCodes[exitCode])
+		exitCodes[exitCode] = code
+	}
+	assert.Equal(t, 1, failure.Code("Unknown").ExitCode())
+}
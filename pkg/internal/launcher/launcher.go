@@ -22,9 +22,11 @@ package launcher
 import (
 	"context"
 	"errors"
+	"log"
 	"strings"
 	"time"
 
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
@@ -33,6 +35,8 @@ type checkup interface {
 	Run(ctx context.Context) error
 	Teardown(ctx context.Context) error
 	Results() status.Results
+	// Reset prepares the checkup for a fresh attempt, once the previous one was torn down.
+	Reset()
 }
 
 type reporter interface {
@@ -42,13 +46,29 @@ type reporter interface {
 type Launcher struct {
 	checkup  checkup
 	reporter reporter
+	retries  int
 }
 
-func New(checkup checkup, reporter reporter) Launcher {
-	return Launcher{
+type Option func(*Launcher)
+
+// WithRetries makes up to the given number of fresh attempts when the checkup fails on an infrastructure failure.
+func WithRetries(retries int) Option {
+	return func(l *Launcher) {
+		l.retries = retries
+	}
+}
+
+func New(checkup checkup, reporter reporter, options ...Option) Launcher {
+	l := Launcher{
 		checkup:  checkup,
 		reporter: reporter,
 	}
+
+	for _, option := range options {
+		option(&l)
+	}
+
+	return l
 }
 
 func (l Launcher) Run(ctx context.Context) (runErr error) {
@@ -60,36 +80,98 @@ func (l Launcher) Run(ctx context.Context) (runErr error) {
 	}
 
 	defer func() {
+		if abortReason := abortReason(ctx); abortReason != nil {
+			runStatus.FailureReason = append(runStatus.FailureReason, abortReason.Error())
+			runStatus.FailureCode = string(failure.Aborted)
+		}
 		runStatus.CompletionTimestamp = time.Now()
 		runStatus.Results = l.checkup.Results()
 		if err := l.reporter.Report(runStatus); err != nil {
-			runStatus.FailureReason = append(runStatus.FailureReason, err.Error())
+			recordFailure(&runStatus, err)
 		}
 		runErr = failureReason(runStatus)
 	}()
 
+	for attempt := 1; ; attempt++ {
+		attemptStatus, tornDown := l.runAttempt(ctx)
+		runStatus.FailureReason = attemptStatus.FailureReason
+		runStatus.FailureCode = attemptStatus.FailureCode
+		if l.retries > 0 {
+			runStatus.Attempts = append(runStatus.Attempts, status.Attempt{FailureCode: attemptStatus.FailureCode})
+		}
+
+		if attempt > l.retries || ctx.Err() != nil || !tornDown || !failure.Code(attemptStatus.FailureCode).Infrastructure() {
+			return nil
+		}
+
+		log.Printf("Attempt %d failed on an infrastructure failure (%s): %s",
+			attempt, attemptStatus.FailureCode, strings.Join(attemptStatus.FailureReason, ", "))
+		log.Printf("Retrying, attempt %d out of %d...", attempt+1, l.retries+1)
+		l.checkup.Reset()
+	}
+}
+
+// runAttempt runs a single Setup, Run and Teardown cycle, returning its failures and whether it was torn down.
+// The teardown runs even when the setup fails or is aborted, removing whatever the setup had created.
+func (l Launcher) runAttempt(ctx context.Context) (status.Status, bool) {
+	var attemptStatus status.Status
 	if err := l.checkup.Setup(ctx); err != nil {
-		runStatus.FailureReason = append(runStatus.FailureReason, err.Error())
-		return err
+		recordFailure(&attemptStatus, err)
+	} else if err := l.checkup.Run(ctx); err != nil {
+		recordFailure(&attemptStatus, err)
 	}
 
-	defer func() {
-		if err := l.checkup.Teardown(ctx); err != nil {
-			runStatus.FailureReason = append(runStatus.FailureReason, err.Error())
-		}
-	}()
+	if err := l.teardown(ctx); err != nil {
+		recordFailure(&attemptStatus, failure.New(failure.TeardownFailed, err))
+		return attemptStatus, false
+	}
 
-	if err := l.checkup.Run(ctx); err != nil {
-		runStatus.FailureReason = append(runStatus.FailureReason, err.Error())
-		return err
+	return attemptStatus, true
+}
+
+func (l Launcher) teardown(ctx context.Context) error {
+	teardownCtx, cancel := teardownContext(ctx)
+	defer cancel()
+
+	return l.checkup.Teardown(teardownCtx)
+}
+
+// teardownContext returns a context for the teardown to use.
+// In case the run context is already done (e.g. the checkup was aborted), the teardown
+// is given a short grace period to fit into the pod's termination grace period.
+func teardownContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return context.WithCancel(ctx)
+	}
+
+	const abortedTeardownTimeout = 20 * time.Second
+	return context.WithTimeout(context.WithoutCancel(ctx), abortedTeardownTimeout)
+}
+
+// abortReason returns the cause of the run context cancellation, in case it was explicitly given one.
+func abortReason(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	if cause := context.Cause(ctx); !errors.Is(cause, ctx.Err()) {
+		return cause
 	}
 
 	return nil
 }
 
+// recordFailure appends the error to the failure reason, keeping the code of the first failure.
+func recordFailure(sts *status.Status, err error) {
+	sts.FailureReason = append(sts.FailureReason, err.Error())
+	if sts.FailureCode == "" {
+		sts.FailureCode = string(failure.CodeOf(err))
+	}
+}
+
 func failureReason(sts status.Status) error {
 	if len(sts.FailureReason) > 0 {
-		return errors.New(strings.Join(sts.FailureReason, ", "))
+		return failure.New(failure.Code(sts.FailureCode), errors.New(strings.Join(sts.FailureReason, ", ")))
 	}
 	return nil
 }
@@ -26,6 +26,7 @@ import (
 
 	assert "github.com/stretchr/testify/require"
 
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/launcher"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
@@ -35,11 +36,14 @@ var (
 	errSetup    = errors.New("setup error")
 	errRun      = errors.New("run error")
 	errTeardown = errors.New("teardown error")
+	errAborted  = errors.New("aborted by signal: terminated")
 )
 
 func TestLauncherRunShouldSucceed(t *testing.T) {
-	testLauncher := launcher.New(checkupStub{}, &reporterStub{})
+	testReporter := &reporterStub{}
+	testLauncher := launcher.New(checkupStub{}, testReporter)
 	assert.NoError(t, testLauncher.Run(context.Background()))
+	assert.Empty(t, testReporter.lastReportedStatus.FailureCode)
 }
 
 func TestLauncherRunShouldFailWhen(t *testing.T) {
@@ -105,6 +109,142 @@ func TestLauncherRunShouldFailWhen(t *testing.T) {
 	})
 }
 
+func TestLauncherRunShouldTearDownWhenSetupFails(t *testing.T) {
+	testCheckup := &attemptsCheckupStub{setupErrors: []error{errSetup}}
+	testLauncher := launcher.New(testCheckup, &reporterStub{})
+
+	assert.ErrorContains(t, testLauncher.Run(context.Background()), errSetup.Error())
+	assert.Equal(t, 1, testCheckup.teardowns)
+}
+
+func TestLauncherRunShouldReportTheFirstFailureCode(t *testing.T) {
+	t.Run("run and teardown fail", func(t *testing.T) {
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(
+			checkupStub{failRun: failure.New(failure.ToolExecutionFailed, errRun), failTeardown: errTeardown},
+			testReporter,
+		)
+
+		err := testLauncher.Run(context.Background())
+		assert.Equal(t, failure.ToolExecutionFailed, failure.CodeOf(err))
+		assert.Equal(t, string(failure.ToolExecutionFailed), testReporter.lastReportedStatus.FailureCode)
+	})
+
+	t.Run("teardown fails", func(t *testing.T) {
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(checkupStub{failTeardown: errTeardown}, testReporter)
+
+		err := testLauncher.Run(context.Background())
+		assert.Equal(t, failure.TeardownFailed, failure.CodeOf(err))
+		assert.Equal(t, string(failure.TeardownFailed), testReporter.lastReportedStatus.FailureCode)
+	})
+
+	t.Run("run fails with an unclassified error", func(t *testing.T) {
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(checkupStub{failRun: errRun}, testReporter)
+
+		assert.Equal(t, failure.Unclassified, failure.CodeOf(testLauncher.Run(context.Background())))
+		assert.Equal(t, string(failure.Unclassified), testReporter.lastReportedStatus.FailureCode)
+	})
+}
+
+func TestLauncherRunShouldRetryInfrastructureFailures(t *testing.T) {
+	errLogin := failure.New(failure.GuestLoginFailed, errors.New("failed to login to VMI"))
+	errBoot := failure.New(failure.VMIBootTimeout, errors.New("failed to wait for VMI be ready"))
+
+	t.Run("until an attempt succeeds", func(t *testing.T) {
+		testCheckup := &attemptsCheckupStub{setupErrors: []error{errBoot}, runErrors: []error{nil, errLogin}}
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(testCheckup, testReporter, launcher.WithRetries(2))
+
+		assert.NoError(t, testLauncher.Run(context.Background()))
+		assert.Equal(t, 2, testCheckup.resets)
+		assert.Equal(t, 3, testCheckup.teardowns)
+		assert.Empty(t, testReporter.lastReportedStatus.FailureReason)
+		assert.Equal(t, []status.Attempt{
+			{FailureCode: string(failure.VMIBootTimeout)},
+			{FailureCode: string(failure.GuestLoginFailed)},
+			{},
+		}, testReporter.lastReportedStatus.Attempts)
+	})
+
+	t.Run("up to the given number of retries", func(t *testing.T) {
+		testCheckup := &attemptsCheckupStub{runErrors: []error{errLogin, errLogin, errLogin}}
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(testCheckup, testReporter, launcher.WithRetries(1))
+
+		err := testLauncher.Run(context.Background())
+		assert.ErrorContains(t, err, errLogin.Error())
+		assert.Equal(t, failure.GuestLoginFailed, failure.CodeOf(err))
+		assert.Equal(t, 1, testCheckup.resets)
+		assert.Len(t, testReporter.lastReportedStatus.Attempts, 2)
+	})
+
+	t.Run("but not latency failures", func(t *testing.T) {
+		errLatency := failure.New(failure.LatencyThresholdExceeded,
+			errors.New("oslat Max Latency measured 13µs exceeded the given threshold 10µs"))
+		testCheckup := &attemptsCheckupStub{runErrors: []error{errLatency}}
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(testCheckup, testReporter, launcher.WithRetries(2))
+
+		err := testLauncher.Run(context.Background())
+		assert.Equal(t, failure.LatencyThresholdExceeded, failure.CodeOf(err))
+		assert.Zero(t, testCheckup.resets)
+		assert.Equal(t, []status.Attempt{{FailureCode: string(failure.LatencyThresholdExceeded)}},
+			testReporter.lastReportedStatus.Attempts)
+	})
+
+	t.Run("unless the failed attempt was not torn down", func(t *testing.T) {
+		testCheckup := &attemptsCheckupStub{runErrors: []error{errLogin}, teardownErrors: []error{errTeardown}}
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(testCheckup, testReporter, launcher.WithRetries(2))
+
+		assert.ErrorContains(t, testLauncher.Run(context.Background()), errLogin.Error())
+		assert.Zero(t, testCheckup.resets)
+		assert.Equal(t, 1, testCheckup.teardowns)
+	})
+
+	t.Run("unless retries are disabled", func(t *testing.T) {
+		testCheckup := &attemptsCheckupStub{runErrors: []error{errLogin}}
+		testReporter := &reporterStub{}
+		testLauncher := launcher.New(testCheckup, testReporter)
+
+		assert.ErrorContains(t, testLauncher.Run(context.Background()), errLogin.Error())
+		assert.Zero(t, testCheckup.resets)
+		assert.Empty(t, testReporter.lastReportedStatus.Attempts)
+	})
+}
+
+func TestLauncherRunShouldReportAbortReasonWhenContextIsCanceledWithCause(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(errAborted)
+
+	testReporter := &reporterStub{}
+	testLauncher := launcher.New(checkupStub{}, testReporter)
+
+	err := testLauncher.Run(ctx)
+	assert.ErrorContains(t, err, errAborted.Error())
+	assert.NotContains(t, err.Error(), context.Canceled.Error(), "teardown should not use the canceled context")
+	assert.Equal(t, 2, testReporter.reportCalls)
+	assert.Contains(t, testReporter.lastReportedStatus.FailureReason, errAborted.Error())
+	assert.Equal(t, string(failure.Aborted), testReporter.lastReportedStatus.FailureCode)
+}
+
+func TestLauncherRunShouldTearDownWhenAbortedDuringSetup(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	testCheckup := &abortedSetupCheckupStub{abort: cancel}
+	testReporter := &reporterStub{}
+	testLauncher := launcher.New(testCheckup, testReporter, launcher.WithRetries(1))
+
+	err := testLauncher.Run(ctx)
+	assert.ErrorContains(t, err, errAborted.Error())
+	assert.True(t, testCheckup.tornDown)
+	assert.Equal(t, string(failure.Aborted), testReporter.lastReportedStatus.FailureCode)
+	assert.Len(t, testReporter.lastReportedStatus.Attempts, 1)
+}
+
 type checkupStub struct {
 	failSetup    error
 	failRun      error
@@ -119,14 +259,88 @@ func (cs checkupStub) Run(_ context.Context) error {
 	return cs.failRun
 }
 
-func (cs checkupStub) Teardown(_ context.Context) error {
-	return cs.failTeardown
+func (cs checkupStub) Teardown(ctx context.Context) error {
+	if cs.failTeardown != nil {
+		return cs.failTeardown
+	}
+	return ctx.Err()
 }
 
 func (cs checkupStub) Results() status.Results {
 	return status.Results{}
 }
 
+func (cs checkupStub) Reset() {}
+
+// attemptsCheckupStub fails each attempt with the next of the given errors, succeeding once they run out.
+type attemptsCheckupStub struct {
+	setupErrors    []error
+	runErrors      []error
+	teardownErrors []error
+	attempt        int
+	teardowns      int
+	resets         int
+}
+
+func (cs *attemptsCheckupStub) Setup(_ context.Context) error {
+	cs.attempt++
+	return attemptError(cs.setupErrors, cs.attempt)
+}
+
+func (cs *attemptsCheckupStub) Run(_ context.Context) error {
+	return attemptError(cs.runErrors, cs.attempt)
+}
+
+func (cs *attemptsCheckupStub) Teardown(_ context.Context) error {
+	cs.teardowns++
+	return attemptError(cs.teardownErrors, cs.attempt)
+}
+
+func (cs *attemptsCheckupStub) Results() status.Results {
+	return status.Results{}
+}
+
+func (cs *attemptsCheckupStub) Reset() {
+	cs.resets++
+}
+
+func attemptError(errs []error, attempt int) error {
+	if attempt > len(errs) {
+		return nil
+	}
+	return errs[attempt-1]
+}
+
+// abortedSetupCheckupStub aborts the run while setting up, the way a signal received during the setup would.
+type abortedSetupCheckupStub struct {
+	abort    context.CancelCauseFunc
+	tornDown bool
+}
+
+func (cs *abortedSetupCheckupStub) Setup(ctx context.Context) error {
+	cs.abort(errAborted)
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (cs *abortedSetupCheckupStub) Run(_ context.Context) error {
+	return nil
+}
+
+func (cs *abortedSetupCheckupStub) Teardown(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cs.tornDown = true
+	return nil
+}
+
+func (cs *abortedSetupCheckupStub) Results() status.Results {
+	return status.Results{}
+}
+
+func (cs *abortedSetupCheckupStub) Reset() {}
+
 type reporterStub struct {
 	reportCalls int
 	failReport  error
@@ -134,10 +348,12 @@ type reporterStub struct {
 	// then to update the checkup results.
 	// Use this flag to cause the second report to fail.
 	failOnSecondReport bool
+	lastReportedStatus status.Status
 }
 
-func (rs *reporterStub) Report(_ status.Status) error {
+func (rs *reporterStub) Report(sts status.Status) error {
 	rs.reportCalls++
+	rs.lastReportedStatus = sts
 	if rs.failOnSecondReport && rs.reportCalls == 2 {
 		return rs.failReport
 	} else if !rs.failOnSecondReport {
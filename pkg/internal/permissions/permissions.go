@@ -0,0 +1,99 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package permissions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	authv1 "k8s.io/api/authorization/v1"
+)
+
+var ErrMissingPermissions = errors.New("missing permissions")
+
+type Permission struct {
+	Namespace   string
+	Group       string
+	Resource    string
+	Subresource string
+	Verb        string
+}
+
+func (p Permission) String() string {
+	resource := p.Resource
+	if p.Subresource != "" {
+		resource += "/" + p.Subresource
+	}
+
+	sb := strings.Builder{}
+	sb.WriteString(fmt.Sprintf("%q on %q", p.Verb, resource))
+	if p.Group != "" {
+		sb.WriteString(fmt.Sprintf(" in API group %q", p.Group))
+	}
+	if p.Namespace != "" {
+		sb.WriteString(fmt.Sprintf(" in namespace %q", p.Namespace))
+	}
+
+	return sb.String()
+}
+
+type accessReviewClient interface {
+	CreateSelfSubjectAccessReview(ctx context.Context,
+		review *authv1.SelfSubjectAccessReview) (*authv1.SelfSubjectAccessReview, error)
+}
+
+// Verify checks the checkup is allowed to perform all the given actions, using SelfSubjectAccessReviews.
+// It fails listing all the missing permissions.
+func Verify(ctx context.Context, client accessReviewClient, required []Permission) error {
+	var missing []string
+
+	for _, permission := range required {
+		review, err := client.CreateSelfSubjectAccessReview(ctx, newSelfSubjectAccessReview(permission))
+		if err != nil {
+			return fmt.Errorf("failed to review permission %s: %w", permission, err)
+		}
+
+		if !review.Status.Allowed {
+			missing = append(missing, permission.String())
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %s", ErrMissingPermissions, strings.Join(missing, ", "))
+	}
+
+	return nil
+}
+
+func newSelfSubjectAccessReview(permission Permission) *authv1.SelfSubjectAccessReview {
+	return &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace:   permission.Namespace,
+				Verb:        permission.Verb,
+				Group:       permission.Group,
+				Resource:    permission.Resource,
+				Subresource: permission.Subresource,
+			},
+		},
+	}
+}
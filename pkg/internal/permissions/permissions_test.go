@@ -0,0 +1,123 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package permissions_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+
+	authv1 "k8s.io/api/authorization/v1"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/permissions"
+)
+
+const testNamespace = "target-ns"
+
+var (
+	vmiCreatePermission = permissions.Permission{
+		Namespace: testNamespace,
+		Group:     "kubevirt.io",
+		Resource:  "virtualmachineinstances",
+		Verb:      "create",
+	}
+	consoleGetPermission = permissions.Permission{
+		Namespace:   testNamespace,
+		Group:       "subresources.kubevirt.io",
+		Resource:    "virtualmachineinstances",
+		Subresource: "console",
+		Verb:        "get",
+	}
+	configMapDeletePermission = permissions.Permission{
+		Namespace: testNamespace,
+		Resource:  "configmaps",
+		Verb:      "delete",
+	}
+)
+
+func TestVerifyShouldSucceedWhenAllPermissionsAreGranted(t *testing.T) {
+	client := &accessReviewClientStub{allowed: []permissions.Permission{
+		vmiCreatePermission,
+		consoleGetPermission,
+		configMapDeletePermission,
+	}}
+
+	assert.NoError(t, permissions.Verify(context.Background(), client, []permissions.Permission{
+		vmiCreatePermission,
+		consoleGetPermission,
+		configMapDeletePermission,
+	}))
+}
+
+func TestVerifyShouldFailWhen(t *testing.T) {
+	t.Run("permissions are missing", func(t *testing.T) {
+		client := &accessReviewClientStub{allowed: []permissions.Permission{vmiCreatePermission}}
+
+		err := permissions.Verify(context.Background(), client, []permissions.Permission{
+			vmiCreatePermission,
+			consoleGetPermission,
+			configMapDeletePermission,
+		})
+		assert.ErrorIs(t, err, permissions.ErrMissingPermissions)
+		assert.ErrorContains(t, err,
+			`"get" on "virtualmachineinstances/console" in API group "subresources.kubevirt.io" in namespace "target-ns"`)
+		assert.ErrorContains(t, err, `"delete" on "configmaps" in namespace "target-ns"`)
+		assert.NotContains(t, err.Error(), `"create"`)
+	})
+
+	t.Run("access review creation fails", func(t *testing.T) {
+		expectedErr := errors.New("failed to create access review")
+		client := &accessReviewClientStub{createErr: expectedErr}
+
+		err := permissions.Verify(context.Background(), client, []permissions.Permission{vmiCreatePermission})
+		assert.ErrorIs(t, err, expectedErr)
+	})
+}
+
+type accessReviewClientStub struct {
+	allowed   []permissions.Permission
+	createErr error
+}
+
+func (cs *accessReviewClientStub) CreateSelfSubjectAccessReview(_ context.Context,
+	review *authv1.SelfSubjectAccessReview) (*authv1.SelfSubjectAccessReview, error) {
+	if cs.createErr != nil {
+		return nil, cs.createErr
+	}
+
+	attributes := review.Spec.ResourceAttributes
+	requested := permissions.Permission{
+		Namespace:   attributes.Namespace,
+		Group:       attributes.Group,
+		Resource:    attributes.Resource,
+		Subresource: attributes.Subresource,
+		Verb:        attributes.Verb,
+	}
+
+	for _, permission := range cs.allowed {
+		if permission == requested {
+			review.Status.Allowed = true
+		}
+	}
+
+	return review, nil
+}
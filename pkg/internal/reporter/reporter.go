@@ -21,26 +21,87 @@ package reporter
 
 import (
 	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"k8s.io/client-go/kubernetes"
 
+	kconfigmap "github.com/kiagnose/kiagnose/kiagnose/configmap"
 	kreporter "github.com/kiagnose/kiagnose/kiagnose/reporter"
+	"github.com/kiagnose/kiagnose/kiagnose/types"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/status"
 )
 
+// FailureCodeKey is the machine-readable classification of the checkup failure, reported next to its reason.
+const FailureCodeKey = "status.failureCode"
+
 const (
-	VMUnderTestActualNodeNameKey = "vmUnderTestActualNodeName"
-	OslatMaxLatencyKey           = "oslatMaxLatencyMicroSeconds"
+	VMUnderTestActualNodeNameKey    = "vmUnderTestActualNodeName"
+	OslatMaxLatencyKey              = "oslatMaxLatencyMicroSeconds"
+	OslatWindowsStartTimestampKey   = "oslatWindowsStartTimestamp"
+	OslatWindowsMaxLatenciesKey     = "oslatWindowMaxLatenciesMicroSeconds"
+	OslatSpikesIntervalKey          = "oslatPeriodicSpikesInterval"
+	OslatSpikesMinIntervalKey       = "oslatSpikesMinDetectableInterval"
+	OslatTraceConfigMapKey          = "oslatTraceConfigMap"
+	MeasuredCPUsInterruptsKey       = "measuredCPUsInterrupts"
+	MeasuredCPUsSoftIRQsKey         = "measuredCPUsSoftIRQs"
+	MeasuredCPUsContextSwitchesKey  = "measuredCPUsContextSwitches"
+	RtlaModeKey                     = "rtlaMode"
+	RtlaMaxNoiseKey                 = "rtlaMaxNoiseMicroSeconds"
+	RtlaNoiseOccurrencesKey         = "rtlaNoiseOccurrences"
+	RtlaIRQMaxLatencyKey            = "rtlaIRQMaxLatencyMicroSeconds"
+	RtlaThreadMaxLatencyKey         = "rtlaThreadMaxLatencyMicroSeconds"
+	StressWorkloadsKey              = "stressWorkloads"
+	GuestHousekeepingLoadKey        = "guestHousekeepingLoad"
+	GuestHousekeepingLoadWorkersKey = "guestHousekeepingLoadWorkers"
+	BaselineOslatMaxLatencyKey      = "baselineOslatMaxLatencyMicroSeconds"
+	OslatOverheadKey                = "oslatOverheadMicroSeconds"
+	PerformanceProfileKey           = "performanceProfile"
+	VMUnderTestRuntimeClassNameKey  = "vmUnderTestRuntimeClassName"
+	OslatPercentilesKey             = "oslatLatencyPercentilesMicroSeconds"
+	OslatCoreMaxLatenciesKey        = "oslatCoreMaxLatenciesMicroSeconds"
+	GoldenBaselineHardwareModelKey  = "goldenBaselineHardwareModel"
+	GoldenBaselineComparisonKey     = "goldenBaselineComparison"
+	GuestKernelVersionKey           = "guestKernelVersion"
+	HostKernelVersionKey            = "hostKernelVersion"
+	RegressionDetectedKey           = "regressionDetected"
+	RegressionPreviousRunsKey       = "regressionPreviousRuns"
+	RegressionPreviousMaxLatencyKey = "previousRunsMaxLatencyMicroSeconds"
+	RegressionIncreasePercentKey    = "regressionIncreasePercent"
+	AttemptsKey                     = "attempts"
+	TimingVMICreatedKey             = "timing.vmiCreated"
+	TimingVMIScheduledKey           = "timing.vmiScheduled"
+	TimingVirtLauncherRunningKey    = "timing.virtLauncherRunning"
+	TimingGuestBootedKey            = "timing.guestBooted"
+	TimingTunedAppliedKey           = "timing.tunedApplied"
+	TimingGuestRebootedKey          = "timing.guestRebooted"
+	TimingVMIReadyKey               = "timing.vmiReady"
+	TimingConsoleLoggedInKey        = "timing.consoleLoggedIn"
 )
 
+// AttemptSucceeded marks a successful attempt, in place of a failure code.
+const AttemptSucceeded = "Succeeded"
+
+// Reporter reports the checkup status using the kiagnose reporter, adding the failure code on completion.
 type Reporter struct {
 	kreporter.Reporter
+	client             kubernetes.Interface
+	configMapNamespace string
+	configMapName      string
 }
 
 func New(c kubernetes.Interface, configMapNamespace, configMapName string) *Reporter {
 	r := kreporter.New(c, configMapNamespace, configMapName)
-	return &Reporter{*r}
+	return &Reporter{
+		Reporter:           *r,
+		client:             c,
+		configMapNamespace: configMapNamespace,
+		configMapName:      configMapName,
+	}
 }
 
 func (r *Reporter) Report(checkupStatus status.Status) error {
@@ -52,19 +113,246 @@ func (r *Reporter) Report(checkupStatus status.Status) error {
 
 	checkupStatus.Status.Results = formatResults(checkupStatus)
 
-	return r.Reporter.Report(checkupStatus.Status)
+	if err := r.Reporter.Report(checkupStatus.Status); err != nil {
+		return err
+	}
+
+	if checkupStatus.CompletionTimestamp.IsZero() {
+		return nil
+	}
+
+	return r.reportFailureCode(checkupStatus.FailureCode)
+}
+
+// reportFailureCode adds the failure code next to the failure reason the kiagnose reporter has just reported.
+func (r *Reporter) reportFailureCode(failureCode string) error {
+	configMap, err := kconfigmap.Get(r.client, r.configMapNamespace, r.configMapName)
+	if err != nil {
+		return err
+	}
+
+	if configMap.Data == nil {
+		return kreporter.ErrConfigMapDataIsNil
+	}
+
+	configMap.Data[FailureCodeKey] = failureCode
+	_, err = kconfigmap.Update(r.client, configMap)
+	return err
+}
+
+// CompletedStatusData returns the status keys reported on checkup completion, excluding the timestamps.
+func CompletedStatusData(checkupStatus status.Status) map[string]string {
+	data := map[string]string{
+		types.SucceededKey:     strconv.FormatBool(len(checkupStatus.FailureReason) == 0),
+		types.FailureReasonKey: strings.Join(checkupStatus.FailureReason, ","),
+		FailureCodeKey:         checkupStatus.FailureCode,
+	}
+
+	for key, value := range formatResults(checkupStatus) {
+		data[types.ResultsPrefix+key] = value
+	}
+
+	return data
 }
 
 func formatResults(checkupStatus status.Status) map[string]string {
-	var emptyResults status.Results
-	if checkupStatus.Results == emptyResults {
-		return map[string]string{}
+	formattedResults := map[string]string{}
+
+	if attempts := checkupStatus.Attempts; len(attempts) > 0 {
+		formattedResults[AttemptsKey] = formatAttempts(attempts)
+	}
+
+	if reflect.DeepEqual(checkupStatus.Results, status.Results{}) {
+		return formattedResults
+	}
+
+	formattedResults[VMUnderTestActualNodeNameKey] = checkupStatus.Results.VMUnderTestActualNodeName
+	formattedResults[OslatMaxLatencyKey] = fmt.Sprintf("%d", checkupStatus.Results.OslatMaxLatency.Microseconds())
+
+	if percentiles := checkupStatus.Results.OslatPercentiles; len(percentiles) > 0 {
+		formattedResults[OslatPercentilesKey] = formatLatencyPercentiles(percentiles)
+	}
+
+	if coreMaxLatencies := checkupStatus.Results.OslatCoreMaxLatencies; len(coreMaxLatencies) > 0 {
+		formattedResults[OslatCoreMaxLatenciesKey] = formatCoreLatencies(coreMaxLatencies)
+	}
+
+	if windows := checkupStatus.Results.OslatWindows; len(windows) > 0 {
+		formattedResults[OslatWindowsStartTimestampKey] = windows[0].StartTimestamp.UTC().Format(time.RFC3339)
+		formattedResults[OslatWindowsMaxLatenciesKey] = formatLatencyWindows(windows)
+		formattedResults[OslatSpikesIntervalKey] = ""
+		if spikesInterval := checkupStatus.Results.OslatSpikesInterval; spikesInterval > 0 {
+			formattedResults[OslatSpikesIntervalKey] = spikesInterval.String()
+		}
+		if minSpikesInterval := checkupStatus.Results.OslatSpikesMinDetectableInterval; minSpikesInterval > 0 {
+			formattedResults[OslatSpikesMinIntervalKey] = minSpikesInterval.String()
+		}
+	}
+
+	if traceConfigMap := checkupStatus.Results.OslatTraceConfigMap; traceConfigMap != "" {
+		formattedResults[OslatTraceConfigMapKey] = traceConfigMap
+	}
+
+	if accounting := checkupStatus.Results.MeasuredCPUsInterrupts; accounting != nil {
+		formattedResults[MeasuredCPUsInterruptsKey] = formatInterruptCounts(accounting.Interrupts)
+		formattedResults[MeasuredCPUsSoftIRQsKey] = formatInterruptCounts(accounting.SoftIRQs)
+		formattedResults[MeasuredCPUsContextSwitchesKey] = formatContextSwitches(accounting.ContextSwitches)
+	}
+
+	if rtlaResults := checkupStatus.Results.Rtla; rtlaResults != nil {
+		formatRtlaResults(formattedResults, rtlaResults)
+	}
+
+	if stressWorkloads := checkupStatus.Results.StressWorkloads; len(stressWorkloads) > 0 {
+		formattedResults[StressWorkloadsKey] = strings.Join(stressWorkloads, ",")
+	}
+
+	if guestHousekeepingLoad := checkupStatus.Results.GuestHousekeepingLoad; guestHousekeepingLoad != "" {
+		formattedResults[GuestHousekeepingLoadKey] = guestHousekeepingLoad
+		formattedResults[GuestHousekeepingLoadWorkersKey] = strconv.Itoa(checkupStatus.Results.GuestHousekeepingLoadWorkers)
+	}
+
+	if baselineMaxLatency := checkupStatus.Results.BaselineOslatMaxLatency; baselineMaxLatency > 0 {
+		formattedResults[BaselineOslatMaxLatencyKey] = fmt.Sprintf("%d", baselineMaxLatency.Microseconds())
+		formattedResults[OslatOverheadKey] = fmt.Sprintf("%d", checkupStatus.Results.OslatOverhead.Microseconds())
 	}
 
-	formattedResults := map[string]string{
-		VMUnderTestActualNodeNameKey: checkupStatus.Results.VMUnderTestActualNodeName,
-		OslatMaxLatencyKey:           fmt.Sprintf("%d", checkupStatus.Results.OslatMaxLatency.Microseconds()),
+	if performanceProfile := checkupStatus.Results.PerformanceProfile; performanceProfile != "" {
+		formattedResults[PerformanceProfileKey] = performanceProfile
 	}
 
+	if runtimeClassName := checkupStatus.Results.VMUnderTestRuntimeClassName; runtimeClassName != "" {
+		formattedResults[VMUnderTestRuntimeClassNameKey] = runtimeClassName
+	}
+
+	if comparisons := checkupStatus.Results.GoldenBaselineComparisons; len(comparisons) > 0 {
+		formattedResults[GoldenBaselineHardwareModelKey] = checkupStatus.Results.GoldenBaselineHardwareModel
+		formattedResults[GoldenBaselineComparisonKey] = formatMetricComparisons(comparisons)
+	}
+
+	formatHistoryResults(formattedResults, checkupStatus.Results)
+	formatTiming(formattedResults, checkupStatus.Results.Timing)
+
 	return formattedResults
 }
+
+// formatTiming formats the observed VM under test lifecycle transitions as RFC 3339 timestamps.
+func formatTiming(formattedResults map[string]string, timing status.Timing) {
+	for key, timestamp := range map[string]time.Time{
+		TimingVMICreatedKey:          timing.VMICreated,
+		TimingVMIScheduledKey:        timing.VMIScheduled,
+		TimingVirtLauncherRunningKey: timing.VirtLauncherRunning,
+		TimingGuestBootedKey:         timing.GuestBooted,
+		TimingTunedAppliedKey:        timing.TunedApplied,
+		TimingGuestRebootedKey:       timing.GuestRebooted,
+		TimingVMIReadyKey:            timing.VMIReady,
+		TimingConsoleLoggedInKey:     timing.ConsoleLoggedIn,
+	} {
+		if !timestamp.IsZero() {
+			formattedResults[key] = timestamp.UTC().Format(time.RFC3339)
+		}
+	}
+}
+
+// formatHistoryResults formats the kernel versions recorded in the run history, and the comparison to the previous runs.
+func formatHistoryResults(formattedResults map[string]string, results status.Results) {
+	if results.GuestKernelVersion != "" {
+		formattedResults[GuestKernelVersionKey] = results.GuestKernelVersion
+	}
+
+	if results.HostKernelVersion != "" {
+		formattedResults[HostKernelVersionKey] = results.HostKernelVersion
+	}
+
+	if regression := results.Regression; regression != nil {
+		formattedResults[RegressionDetectedKey] = strconv.FormatBool(regression.Detected)
+		formattedResults[RegressionPreviousRunsKey] = strconv.Itoa(regression.PreviousRuns)
+		formattedResults[RegressionPreviousMaxLatencyKey] = fmt.Sprintf("%d", regression.PreviousAverageMaxLatency.Microseconds())
+		formattedResults[RegressionIncreasePercentKey] = strconv.FormatFloat(regression.IncreasePercent, 'f', 1, 64)
+	}
+}
+
+// formatAttempts formats the attempts outcomes as "<attempt>:<failure code>" entries, numbered from 1.
+func formatAttempts(attempts []status.Attempt) string {
+	entries := make([]string, 0, len(attempts))
+	for i, attempt := range attempts {
+		outcome := attempt.FailureCode
+		if outcome == "" {
+			outcome = AttemptSucceeded
+		}
+		entries = append(entries, fmt.Sprintf("%d:%s", i+1, outcome))
+	}
+	return strings.Join(entries, ",")
+}
+
+func formatCoreLatencies(coreLatencies []status.CoreLatency) string {
+	entries := make([]string, 0, len(coreLatencies))
+	for _, coreLatency := range coreLatencies {
+		entries = append(entries, fmt.Sprintf("%d:%d", coreLatency.CPU, coreLatency.MaxLatency.Microseconds()))
+	}
+	return strings.Join(entries, ",")
+}
+
+func formatMetricComparisons(comparisons []status.MetricComparison) string {
+	entries := make([]string, 0, len(comparisons))
+	for _, comparison := range comparisons {
+		entries = append(entries, comparison.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatLatencyPercentiles formats the percentiles as "<percent>:<latency microseconds>" entries.
+func formatLatencyPercentiles(percentiles []status.LatencyPercentile) string {
+	entries := make([]string, 0, len(percentiles))
+	for _, percentile := range percentiles {
+		entries = append(entries, fmt.Sprintf("%s:%d",
+			strconv.FormatFloat(percentile.Percent, 'f', -1, 64), percentile.Latency.Microseconds()))
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatLatencyWindows formats the windows as a compact time series of "<offset seconds>:<max latency microseconds>"
+// entries, where the offset is relative to the first window start.
+func formatLatencyWindows(windows []status.LatencyWindow) string {
+	entries := make([]string, 0, len(windows))
+	for _, window := range windows {
+		offset := window.StartTimestamp.Sub(windows[0].StartTimestamp)
+		entries = append(entries, fmt.Sprintf("%d:%d", int64(offset.Seconds()), window.MaxLatency.Microseconds()))
+	}
+	return strings.Join(entries, ",")
+}
+
+func formatInterruptCounts(counts []status.InterruptCount) string {
+	entries := make([]string, 0, len(counts))
+	for _, count := range counts {
+		entries = append(entries, count.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+// formatContextSwitches formats the per CPU context switches as "<cpu>:<count>" entries, sorted by CPU.
+func formatContextSwitches(contextSwitches map[int]uint64) string {
+	cpus := make([]int, 0, len(contextSwitches))
+	for cpu := range contextSwitches {
+		cpus = append(cpus, cpu)
+	}
+	sort.Ints(cpus)
+
+	entries := make([]string, 0, len(cpus))
+	for _, cpu := range cpus {
+		entries = append(entries, fmt.Sprintf("%d:%d", cpu, contextSwitches[cpu]))
+	}
+	return strings.Join(entries, ",")
+}
+
+func formatRtlaResults(formattedResults map[string]string, rtlaResults *status.RtlaResults) {
+	formattedResults[RtlaModeKey] = rtlaResults.Mode
+	if occurrences := rtlaResults.NoiseOccurrences; occurrences != nil {
+		formattedResults[RtlaMaxNoiseKey] = fmt.Sprintf("%d", rtlaResults.MaxNoise.Microseconds())
+		formattedResults[RtlaNoiseOccurrencesKey] = fmt.Sprintf("hw:%d,nmi:%d,irq:%d,softirq:%d,thread:%d",
+			occurrences.HW, occurrences.NMI, occurrences.IRQ, occurrences.SoftIRQ, occurrences.Thread)
+		return
+	}
+	formattedResults[RtlaIRQMaxLatencyKey] = fmt.Sprintf("%d", rtlaResults.IRQMaxLatency.Microseconds())
+	formattedResults[RtlaThreadMaxLatencyKey] = fmt.Sprintf("%d", rtlaResults.ThreadMaxLatency.Microseconds())
+}
@@ -58,6 +58,7 @@ func TestReportShouldSuccessfullyReportResults(t *testing.T) {
 	const (
 		failureReason1 = "some reason"
 		failureReason2 = "some other reason"
+		failureCode    = "ToolExecutionFailed"
 	)
 
 	t.Run("on checkup success", func(t *testing.T) {
@@ -80,6 +81,7 @@ func TestReportShouldSuccessfullyReportResults(t *testing.T) {
 		expectedReportData := map[string]string{
 			"status.succeeded":                          strconv.FormatBool(true),
 			"status.failureReason":                      "",
+			"status.failureCode":                        "",
 			"status.startTimestamp":                     timestamp(checkupStatus.StartTimestamp),
 			"status.completionTimestamp":                timestamp(checkupStatus.CompletionTimestamp),
 			"status.result.vmUnderTestActualNodeName":   checkupStatus.Results.VMUnderTestActualNodeName,
@@ -98,12 +100,14 @@ func TestReportShouldSuccessfullyReportResults(t *testing.T) {
 		assert.NoError(t, testReporter.Report(checkupStatus))
 
 		checkupStatus.FailureReason = []string{failureReason1}
+		checkupStatus.FailureCode = failureCode
 		checkupStatus.CompletionTimestamp = time.Now()
 		assert.NoError(t, testReporter.Report(checkupStatus))
 
 		expectedReportData := map[string]string{
 			"status.succeeded":           strconv.FormatBool(false),
 			"status.failureReason":       failureReason1,
+			"status.failureCode":         failureCode,
 			"status.startTimestamp":      timestamp(checkupStatus.StartTimestamp),
 			"status.completionTimestamp": timestamp(checkupStatus.CompletionTimestamp),
 		}
@@ -126,6 +130,7 @@ func TestReportShouldSuccessfullyReportResults(t *testing.T) {
 		expectedReportData := map[string]string{
 			"status.succeeded":           strconv.FormatBool(false),
 			"status.failureReason":       failureReason1 + "," + failureReason2,
+			"status.failureCode":         "",
 			"status.startTimestamp":      timestamp(checkupStatus.StartTimestamp),
 			"status.completionTimestamp": timestamp(checkupStatus.CompletionTimestamp),
 		}
@@ -134,6 +139,257 @@ func TestReportShouldSuccessfullyReportResults(t *testing.T) {
 	})
 }
 
+func TestCompletedStatusData(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.FailureReason = []string{"some reason", "some other reason"}
+	checkupStatus.FailureCode = "LatencyThresholdExceeded"
+	checkupStatus.Results = status.Results{OslatMaxLatency: 12 * time.Microsecond}
+
+	expectedData := map[string]string{
+		"status.succeeded":                          strconv.FormatBool(false),
+		"status.failureReason":                      "some reason,some other reason",
+		"status.failureCode":                        "LatencyThresholdExceeded",
+		"status.result.vmUnderTestActualNodeName":   "",
+		"status.result.oslatMaxLatencyMicroSeconds": "12",
+	}
+	assert.Equal(t, expectedData, reporter.CompletedStatusData(checkupStatus))
+}
+
+func TestCompletedStatusDataShouldFormatLatencyWindows(t *testing.T) {
+	startTimestamp := time.Date(2023, time.June, 6, 10, 14, 3, 0, time.UTC)
+
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 31 * time.Microsecond,
+		OslatWindows: []status.LatencyWindow{
+			{StartTimestamp: startTimestamp, MaxLatency: 12 * time.Microsecond},
+			{StartTimestamp: startTimestamp.Add(61 * time.Second), MaxLatency: 31 * time.Microsecond},
+			{StartTimestamp: startTimestamp.Add(122 * time.Second), MaxLatency: 9 * time.Microsecond},
+		},
+		OslatSpikesInterval:              2 * time.Minute,
+		OslatSpikesMinDetectableInterval: 2 * time.Minute,
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "2023-06-06T10:14:03Z", statusData["status.result.oslatWindowsStartTimestamp"])
+	assert.Equal(t, "0:12,61:31,122:9", statusData["status.result.oslatWindowMaxLatenciesMicroSeconds"])
+	assert.Equal(t, "2m0s", statusData["status.result.oslatPeriodicSpikesInterval"])
+	assert.Equal(t, "2m0s", statusData["status.result.oslatSpikesMinDetectableInterval"])
+}
+
+func TestCompletedStatusDataShouldReferenceTraceConfigMap(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency:     31 * time.Microsecond,
+		OslatTrace:          "some trace",
+		OslatTraceConfigMap: "default/realtime-checkup-trace-abcde",
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "default/realtime-checkup-trace-abcde", statusData["status.result.oslatTraceConfigMap"])
+	assert.NotContains(t, statusData, "status.result.oslatTrace")
+}
+
+func TestCompletedStatusDataShouldFormatInterruptAccounting(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 31 * time.Microsecond,
+		MeasuredCPUsInterrupts: &status.InterruptAccounting{
+			Interrupts: []status.InterruptCount{
+				{Source: "27-virtio0-input.0", CPU: 3, Count: 1},
+				{Source: "LOC", CPU: 2, Count: 60},
+			},
+			ContextSwitches: map[int]uint64{3: 5, 2: 4},
+		},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "27-virtio0-input.0@3:1,LOC@2:60", statusData["status.result.measuredCPUsInterrupts"])
+	assert.Equal(t, "", statusData["status.result.measuredCPUsSoftIRQs"])
+	assert.Equal(t, "2:4,3:5", statusData["status.result.measuredCPUsContextSwitches"])
+}
+
+func TestCompletedStatusDataShouldFormatRtlaResults(t *testing.T) {
+	t.Run("osnoise", func(t *testing.T) {
+		checkupStatus := status.Status{}
+		checkupStatus.Results = status.Results{
+			Rtla: &status.RtlaResults{
+				Mode:             "osnoise",
+				MaxNoise:         31 * time.Microsecond,
+				NoiseOccurrences: &status.NoiseOccurrences{HW: 1, IRQ: 118036, SoftIRQ: 2, Thread: 11},
+			},
+		}
+
+		statusData := reporter.CompletedStatusData(checkupStatus)
+		assert.Equal(t, "osnoise", statusData["status.result.rtlaMode"])
+		assert.Equal(t, "31", statusData["status.result.rtlaMaxNoiseMicroSeconds"])
+		assert.Equal(t, "hw:1,nmi:0,irq:118036,softirq:2,thread:11", statusData["status.result.rtlaNoiseOccurrences"])
+		assert.NotContains(t, statusData, "status.result.rtlaIRQMaxLatencyMicroSeconds")
+	})
+
+	t.Run("timerlat", func(t *testing.T) {
+		checkupStatus := status.Status{}
+		checkupStatus.Results = status.Results{
+			Rtla: &status.RtlaResults{
+				Mode:             "timerlat",
+				IRQMaxLatency:    14 * time.Microsecond,
+				ThreadMaxLatency: 17 * time.Microsecond,
+			},
+		}
+
+		statusData := reporter.CompletedStatusData(checkupStatus)
+		assert.Equal(t, "timerlat", statusData["status.result.rtlaMode"])
+		assert.Equal(t, "14", statusData["status.result.rtlaIRQMaxLatencyMicroSeconds"])
+		assert.Equal(t, "17", statusData["status.result.rtlaThreadMaxLatencyMicroSeconds"])
+		assert.NotContains(t, statusData, "status.result.rtlaNoiseOccurrences")
+	})
+}
+
+func TestCompletedStatusDataShouldReportStressWorkloads(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 31 * time.Microsecond,
+		StressWorkloads: []string{"cpu", "memory"},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "cpu,memory", statusData["status.result.stressWorkloads"])
+}
+
+func TestCompletedStatusDataShouldReportGuestHousekeepingLoad(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency:              31 * time.Microsecond,
+		GuestHousekeepingLoad:        "memory",
+		GuestHousekeepingLoadWorkers: 2,
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "memory", statusData["status.result.guestHousekeepingLoad"])
+	assert.Equal(t, "2", statusData["status.result.guestHousekeepingLoadWorkers"])
+}
+
+func TestCompletedStatusDataShouldReportBaseline(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency:         31 * time.Microsecond,
+		BaselineOslatMaxLatency: 9 * time.Microsecond,
+		OslatOverhead:           22 * time.Microsecond,
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "9", statusData["status.result.baselineOslatMaxLatencyMicroSeconds"])
+	assert.Equal(t, "22", statusData["status.result.oslatOverheadMicroSeconds"])
+}
+
+func TestCompletedStatusDataShouldReportPerformanceProfile(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency:             31 * time.Microsecond,
+		PerformanceProfile:          "cnf-profile",
+		VMUnderTestRuntimeClassName: "performance-cnf-profile",
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "cnf-profile", statusData["status.result.performanceProfile"])
+	assert.Equal(t, "performance-cnf-profile", statusData["status.result.vmUnderTestRuntimeClassName"])
+}
+
+func TestCompletedStatusDataShouldFormatLatencyPercentiles(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 13 * time.Microsecond,
+		OslatPercentiles: []status.LatencyPercentile{
+			{Percent: 99.99, Latency: 2 * time.Microsecond},
+			{Percent: 99.9999, Latency: 11 * time.Microsecond},
+		},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "99.99:2,99.9999:11", statusData["status.result.oslatLatencyPercentilesMicroSeconds"])
+}
+
+func TestCompletedStatusDataShouldReportGoldenBaselineComparison(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 13 * time.Microsecond,
+		OslatCoreMaxLatencies: []status.CoreLatency{
+			{CPU: 2, MaxLatency: 13 * time.Microsecond},
+			{CPU: 3, MaxLatency: 9 * time.Microsecond},
+		},
+		GoldenBaselineHardwareModel: "PowerEdge R750",
+		GoldenBaselineComparisons: []status.MetricComparison{
+			{Metric: "max", Measured: 13 * time.Microsecond, IsMeasured: true, Allowed: 22 * time.Microsecond, Passed: true},
+			{Metric: "core5", Allowed: 16 * time.Microsecond},
+		},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "2:13,3:9", statusData["status.result.oslatCoreMaxLatenciesMicroSeconds"])
+	assert.Equal(t, "PowerEdge R750", statusData["status.result.goldenBaselineHardwareModel"])
+	assert.Equal(t, "max:13/22:pass,core5:-/16:fail", statusData["status.result.goldenBaselineComparison"])
+}
+
+func TestCompletedStatusDataShouldReportHistory(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency:    13 * time.Microsecond,
+		GuestKernelVersion: "4.18.0-477.10.1.rt7.274.el8_8.x86_64",
+		HostKernelVersion:  "5.14.0-284.25.1.rt14.310.el9_2.x86_64",
+		Regression: &status.Regression{
+			Detected:                  true,
+			PreviousRuns:              3,
+			PreviousAverageMaxLatency: 10 * time.Microsecond,
+			IncreasePercent:           30,
+		},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "4.18.0-477.10.1.rt7.274.el8_8.x86_64", statusData["status.result.guestKernelVersion"])
+	assert.Equal(t, "5.14.0-284.25.1.rt14.310.el9_2.x86_64", statusData["status.result.hostKernelVersion"])
+	assert.Equal(t, "true", statusData["status.result.regressionDetected"])
+	assert.Equal(t, "3", statusData["status.result.regressionPreviousRuns"])
+	assert.Equal(t, "10", statusData["status.result.previousRunsMaxLatencyMicroSeconds"])
+	assert.Equal(t, "30.0", statusData["status.result.regressionIncreasePercent"])
+}
+
+func TestCompletedStatusDataShouldReportTiming(t *testing.T) {
+	vmiCreated := time.Date(2023, time.June, 6, 9, 51, 0, 0, time.UTC)
+	checkupStatus := status.Status{}
+	checkupStatus.Results = status.Results{
+		OslatMaxLatency: 13 * time.Microsecond,
+		Timing: status.Timing{
+			VMICreated:      vmiCreated,
+			GuestRebooted:   vmiCreated.Add(2 * time.Minute),
+			ConsoleLoggedIn: vmiCreated.Add(3*time.Minute + 500*time.Millisecond),
+		},
+	}
+
+	statusData := reporter.CompletedStatusData(checkupStatus)
+	assert.Equal(t, "2023-06-06T09:51:00Z", statusData["status.result.timing.vmiCreated"])
+	assert.Equal(t, "2023-06-06T09:53:00Z", statusData["status.result.timing.guestRebooted"])
+	assert.Equal(t, "2023-06-06T09:54:00Z", statusData["status.result.timing.consoleLoggedIn"])
+	assert.NotContains(t, statusData, "status.result.timing.vmiScheduled")
+}
+
+func TestCompletedStatusDataShouldReportAttempts(t *testing.T) {
+	checkupStatus := status.Status{}
+	checkupStatus.FailureReason = []string{"failed to wait for VMI be ready"}
+	checkupStatus.FailureCode = "VMIBootTimeout"
+	checkupStatus.Attempts = []status.Attempt{{FailureCode: "GuestLoginFailed"}, {FailureCode: "VMIBootTimeout"}}
+
+	expectedData := map[string]string{
+		"status.succeeded":       strconv.FormatBool(false),
+		"status.failureReason":   checkupStatus.FailureReason[0],
+		"status.failureCode":     "VMIBootTimeout",
+		"status.result.attempts": "1:GuestLoginFailed,2:VMIBootTimeout",
+	}
+	assert.Equal(t, expectedData, reporter.CompletedStatusData(checkupStatus))
+
+	checkupStatus.Attempts[1] = status.Attempt{}
+	assert.Equal(t, "1:GuestLoginFailed,2:Succeeded", reporter.CompletedStatusData(checkupStatus)["status.result.attempts"])
+}
+
 func TestReportShouldFailWhenCannotUpdateConfigMap(t *testing.T) {
 	// ConfigMap does not exist
 	fakeClient := fake.NewSimpleClientset()
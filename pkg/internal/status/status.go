@@ -20,6 +20,7 @@
 package status
 
 import (
+	"fmt"
 	"time"
 
 	kstatus "github.com/kiagnose/kiagnose/kiagnose/status"
@@ -28,9 +29,161 @@ import (
 type Results struct {
 	VMUnderTestActualNodeName string
 	OslatMaxLatency           time.Duration
+	// OslatCoreMaxLatencies and OslatPercentiles are measured when oslat runs in a single window.
+	OslatCoreMaxLatencies []CoreLatency
+	// OslatPercentiles are computed out of the oslat histogram.
+	OslatPercentiles []LatencyPercentile
+	OslatWindows     []LatencyWindow
+	// OslatSpikesInterval is the interval latency spikes recur at, when found to be periodic.
+	OslatSpikesInterval time.Duration
+	// OslatSpikesMinDetectableInterval is the shortest interval of periodic spikes the measurement windows allow detecting.
+	OslatSpikesMinDetectableInterval time.Duration
+	// OslatTrace is the guest kernel trace tail, collected when the trace threshold is breached.
+	OslatTrace string
+	// OslatTraceConfigMap is the full name of the ConfigMap the trace is archived in.
+	OslatTraceConfigMap string
+	// MeasuredCPUsInterrupts accounts for the interruptions of the measured CPUs during the oslat run.
+	MeasuredCPUsInterrupts *InterruptAccounting
+	// Rtla is the noise attribution measured by rtla on the isolated CPUs, when enabled.
+	Rtla *RtlaResults
+	// StressWorkloads are the noisy neighbor workloads which ran on the node during the measurements.
+	StressWorkloads []string
+	// GuestHousekeepingLoad is the load which ran on the VM under test housekeeping vCPUs during the oslat run.
+	GuestHousekeepingLoad string
+	// GuestHousekeepingLoadWorkers is the number of workers the guest housekeeping load ran.
+	GuestHousekeepingLoadWorkers int
+	// BaselineOslatMaxLatency is the max latency oslat measured in the bare-metal baseline pod.
+	BaselineOslatMaxLatency time.Duration
+	// OslatOverhead is the VM under test max latency over the baseline one, attributed to virtualization.
+	OslatOverhead time.Duration
+	// PerformanceProfile is the name of the performance profile discovered for the target node.
+	PerformanceProfile string
+	// VMUnderTestRuntimeClassName is the runtime class the VM under test was verified to run with.
+	VMUnderTestRuntimeClassName string
+	// GuestKernelVersion and HostKernelVersion are the kernel releases of the VM under test and of its node.
+	GuestKernelVersion string
+	HostKernelVersion  string
+	// GoldenBaselineHardwareModel is the hardware model of the golden baseline the results were compared against.
+	GoldenBaselineHardwareModel string
+	// GoldenBaselineComparisons are the per metric comparisons to the golden baseline, when one is given.
+	GoldenBaselineComparisons []MetricComparison
+	// Regression is the comparison of the max latency to the previous runs on the same node, when the history is enabled.
+	Regression *Regression
+	// Timing is the VM under test lifecycle breakdown.
+	Timing Timing
+}
+
+// Timing holds the times of the VM under test lifecycle transitions, zero when not observed.
+type Timing struct {
+	VMICreated          time.Time
+	VMIScheduled        time.Time
+	VirtLauncherRunning time.Time
+	GuestBooted         time.Time
+	TunedApplied        time.Time
+	GuestRebooted       time.Time
+	VMIReady            time.Time
+	ConsoleLoggedIn     time.Time
+}
+
+// LatencyPercentile is the latency below which the given percentage of the oslat measurements fall.
+type LatencyPercentile struct {
+	Percent float64
+	Latency time.Duration
+}
+
+// CoreLatency is the max latency measured on a single CPU.
+type CoreLatency struct {
+	CPU        int
+	MaxLatency time.Duration
+}
+
+// MetricComparison compares a measured latency to the one allowed by the golden baseline.
+// A metric which was not measured does not pass.
+type MetricComparison struct {
+	Metric     string
+	Measured   time.Duration
+	IsMeasured bool
+	Allowed    time.Duration
+	Passed     bool
+}
+
+// String formats the comparison as "<metric>:<measured>/<allowed>:<pass|fail>", the latencies in microseconds.
+func (c MetricComparison) String() string {
+	measured := "-"
+	if c.IsMeasured {
+		measured = fmt.Sprintf("%d", c.Measured.Microseconds())
+	}
+	verdict := "fail"
+	if c.Passed {
+		verdict = "pass"
+	}
+	return fmt.Sprintf("%s:%s/%d:%s", c.Metric, measured, c.Allowed.Microseconds(), verdict)
+}
+
+// Regression compares the max latency to the average max latency of the previous runs on the same node.
+type Regression struct {
+	Detected bool
+	// PreviousRuns is the number of previous runs the average is computed over.
+	PreviousRuns              int
+	PreviousAverageMaxLatency time.Duration
+	IncreasePercent           float64
+}
+
+// LatencyWindow is the max latency measured during a single oslat measurement window.
+type LatencyWindow struct {
+	StartTimestamp time.Time
+	MaxLatency     time.Duration
+}
+
+// InterruptAccounting is the interrupts, softirqs and context switches that hit the measured CPUs.
+type InterruptAccounting struct {
+	Interrupts      []InterruptCount
+	SoftIRQs        []InterruptCount
+	ContextSwitches map[int]uint64
+}
+
+// InterruptCount is the number of times an interrupt source hit a CPU.
+type InterruptCount struct {
+	Source string
+	CPU    int
+	Count  uint64
+}
+
+// String formats the count as "<source>@<cpu>:<count>".
+func (c InterruptCount) String() string {
+	return fmt.Sprintf("%s@%d:%d", c.Source, c.CPU, c.Count)
+}
+
+// RtlaResults are the rtla measurements of all the isolated CPUs.
+// Osnoise attributes the noise to its sources, while timerlat tells the timer IRQ latency from the thread wakeup latency.
+type RtlaResults struct {
+	Mode             string
+	MaxNoise         time.Duration
+	NoiseOccurrences *NoiseOccurrences
+	IRQMaxLatency    time.Duration
+	ThreadMaxLatency time.Duration
+}
+
+// NoiseOccurrences is the number of noise occurrences by source.
+type NoiseOccurrences struct {
+	HW      uint64
+	NMI     uint64
+	IRQ     uint64
+	SoftIRQ uint64
+	Thread  uint64
 }
 
 type Status struct {
 	kstatus.Status
+	// FailureCode classifies the first failure the checkup encountered, empty on success.
+	FailureCode string
+	// Attempts are the outcomes of the checkup attempts, when infrastructure failures may be retried.
+	Attempts []Attempt
 	Results
 }
+
+// Attempt is the outcome of a single Setup, Run and Teardown cycle of the checkup.
+type Attempt struct {
+	// FailureCode classifies the first failure of the attempt, empty when it succeeded.
+	FailureCode string
+}
@@ -21,52 +21,161 @@ package pkg
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strings"
 
 	kconfig "github.com/kiagnose/kiagnose/kiagnose/config"
 
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/executor"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/checkup/performanceprofile"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/client"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/failure"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/launcher"
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/permissions"
 	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/reporter"
 )
 
-func Run(rawEnv map[string]string, namespace string) error {
-	c, err := client.New()
+var ErrAbortedBySignal = errors.New("aborted by signal")
+
+// Run executes the checkup.
+// An empty kubeconfigPath means the checkup is running inside the cluster.
+func Run(ctx context.Context, rawEnv map[string]string, namespace, kubeconfigPath string) error {
+	c, err := client.New(kubeconfigPath)
 	if err != nil {
 		return err
 	}
 
 	baseConfig, err := kconfig.Read(c, rawEnv)
 	if err != nil {
-		return err
+		return failure.New(failure.ConfigInvalid, err)
 	}
 
 	cfg, err := config.New(baseConfig)
 	if err != nil {
-		return err
+		return failure.New(failure.ConfigInvalid, err)
 	}
 
 	printConfig(cfg)
 
+	if err = permissions.Verify(ctx, c, requiredPermissions(namespace, baseConfig.ConfigMapNamespace, cfg)); err != nil {
+		return failure.New(failure.PermissionDenied, err)
+	}
+
 	realtimeCheckupExecutor := executor.New(c, namespace, cfg)
 	l := launcher.New(
 		checkup.New(c, namespace, cfg, realtimeCheckupExecutor),
 		reporter.New(c, baseConfig.ConfigMapNamespace, baseConfig.ConfigMapName),
+		launcher.WithRetries(cfg.InfraFailureRetries),
 	)
 
-	ctx, cancel := context.WithTimeout(context.Background(), baseConfig.Timeout)
+	ctx, cancel := context.WithTimeout(ctx, baseConfig.Timeout)
 	defer cancel()
 
 	return l.Run(ctx)
 }
 
+// ExitCode returns the process exit code matching the classification of the checkup failure, 0 on success.
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	return failure.CodeOf(err).ExitCode()
+}
+
+// requiredPermissions lists the actions the checkup performs on the cluster.
+// It should be kept in sync with the Roles documented in the README.
+func requiredPermissions(namespace, configMapNamespace string, checkupConfig config.Config) []permissions.Permission {
+	const (
+		kubeVirtGroup               = "kubevirt.io"
+		kubeVirtSubresourcesGroup   = "subresources.kubevirt.io"
+		vmisResource                = "virtualmachineinstances"
+		configMapsResource          = "configmaps"
+		podsResource                = "pods"
+		nodesResource               = "nodes"
+		performanceGroup            = "performance.openshift.io"
+		performanceProfilesResource = "performanceprofiles"
+		tunedGroup                  = "tuned.openshift.io"
+		tunedProfilesResource       = "profiles"
+	)
+
+	requiredPermissions := []permissions.Permission{
+		{Namespace: configMapNamespace, Resource: configMapsResource, Verb: "get"},
+		{Namespace: configMapNamespace, Resource: configMapsResource, Verb: "update"},
+		{Namespace: namespace, Group: kubeVirtGroup, Resource: vmisResource, Verb: "create"},
+		{Namespace: namespace, Group: kubeVirtGroup, Resource: vmisResource, Verb: "get"},
+		{Namespace: namespace, Group: kubeVirtGroup, Resource: vmisResource, Verb: "watch"},
+		{Namespace: namespace, Group: kubeVirtGroup, Resource: vmisResource, Verb: "delete"},
+		{Namespace: namespace, Group: kubeVirtSubresourcesGroup, Resource: vmisResource, Subresource: "console", Verb: "get"},
+		{Namespace: namespace, Resource: configMapsResource, Verb: "create"},
+		{Namespace: namespace, Resource: configMapsResource, Verb: "delete"},
+	}
+
+	if len(checkupConfig.StressWorkloads) > 0 || checkupConfig.BaselineEnabled {
+		requiredPermissions = append(requiredPermissions,
+			permissions.Permission{Namespace: namespace, Resource: podsResource, Verb: "create"},
+			permissions.Permission{Namespace: namespace, Resource: podsResource, Verb: "get"},
+			permissions.Permission{Namespace: namespace, Resource: podsResource, Verb: "delete"},
+		)
+	}
+
+	if checkupConfig.VMUnderTestRuntimeClassName != "" || checkupConfig.PerformanceProfileDiscovery {
+		requiredPermissions = append(requiredPermissions,
+			permissions.Permission{Namespace: namespace, Resource: podsResource, Verb: "list"},
+		)
+	}
+
+	if checkupConfig.HistoryConfigMapName != "" || checkupConfig.GoldenBaselineConfigMapName != "" {
+		requiredPermissions = append(requiredPermissions,
+			permissions.Permission{Namespace: namespace, Resource: configMapsResource, Verb: "get"},
+		)
+	}
+
+	if checkupConfig.HistoryConfigMapName != "" {
+		requiredPermissions = append(requiredPermissions,
+			permissions.Permission{Namespace: namespace, Resource: configMapsResource, Verb: "update"},
+		)
+	}
+
+	if checkupConfig.PerformanceProfileDiscovery {
+		requiredPermissions = append(requiredPermissions,
+			permissions.Permission{Resource: nodesResource, Verb: "get"},
+			permissions.Permission{Group: performanceGroup, Resource: performanceProfilesResource, Verb: "list"},
+			permissions.Permission{Namespace: performanceprofile.TunedNamespace, Group: tunedGroup, Resource: tunedProfilesResource, Verb: "get"},
+		)
+	}
+
+	return requiredPermissions
+}
+
 func printConfig(checkupConfig config.Config) {
 	log.Println("Using the following config:")
 	log.Printf("\t%q: %q", config.VMUnderTestTargetNodeNameParamName, checkupConfig.VMUnderTestTargetNodeName)
 	log.Printf("\t%q: %q", config.VMUnderTestContainerDiskImageParamName, checkupConfig.VMUnderTestContainerDiskImage)
 	log.Printf("\t%q: %q", config.OslatDurationParamName, checkupConfig.OslatDuration.String())
 	log.Printf("\t%q: %q", config.OslatLatencyThresholdParamName, checkupConfig.OslatLatencyThreshold.String())
+	log.Printf("\t%q: %q", config.OslatMeasurementWindowParamName, checkupConfig.OslatMeasurementWindow.String())
+	log.Printf("\t%q: %q", config.OslatTraceThresholdParamName, checkupConfig.OslatTraceThreshold.String())
+	log.Printf("\t%q: %t", config.FailOnUnexpectedInterruptsParamName, checkupConfig.FailOnUnexpectedInterrupts)
+	log.Printf("\t%q: %q", config.RtlaModeParamName, checkupConfig.RtlaMode)
+	log.Printf("\t%q: %q", config.RtlaDurationParamName, checkupConfig.RtlaDuration.String())
+	log.Printf("\t%q: %q", config.StressWorkloadsParamName, strings.Join(checkupConfig.StressWorkloads, ","))
+	log.Printf("\t%q: %q", config.StressImageParamName, checkupConfig.StressImage)
+	log.Printf("\t%q: %q", config.GuestHousekeepingLoadParamName, checkupConfig.GuestHousekeepingLoad)
+	log.Printf("\t%q: %d", config.GuestHousekeepingLoadWorkersParamName, checkupConfig.GuestHousekeepingLoadWorkers)
+	log.Printf("\t%q: %t", config.BaselineEnabledParamName, checkupConfig.BaselineEnabled)
+	log.Printf("\t%q: %q", config.BaselineImageParamName, checkupConfig.BaselineImage)
+	log.Printf("\t%q: %q", config.BaselineMaxOverheadParamName, checkupConfig.BaselineMaxOverhead.String())
+	log.Printf("\t%q: %q", config.VMUnderTestRuntimeClassNameParamName, checkupConfig.VMUnderTestRuntimeClassName)
+	log.Printf("\t%q: %t", config.PerformanceProfileDiscoveryParamName, checkupConfig.PerformanceProfileDiscovery)
+	log.Printf("\t%q: %q", config.HistoryConfigMapNameParamName, checkupConfig.HistoryConfigMapName)
+	log.Printf("\t%q: %d", config.HistorySizeParamName, checkupConfig.HistorySize)
+	log.Printf("\t%q: %d", config.RegressionWindowParamName, checkupConfig.RegressionWindow)
+	log.Printf("\t%q: %d", config.RegressionThresholdParamName, checkupConfig.RegressionThresholdPercent)
+	log.Printf("\t%q: %q", config.GoldenBaselineConfigMapNameParamName, checkupConfig.GoldenBaselineConfigMapName)
+	log.Printf("\t%q: %d", config.InfraFailureRetriesParamName, checkupConfig.InfraFailureRetries)
+	log.Printf("\t%q: %q", config.SetupTimeoutParamName, checkupConfig.SetupTimeout.String())
+	log.Printf("\t%q: %q", config.TeardownTimeoutParamName, checkupConfig.TeardownTimeout.String())
 }
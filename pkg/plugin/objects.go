@@ -0,0 +1,248 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package plugin
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	kconfig "github.com/kiagnose/kiagnose/kiagnose/config"
+	"github.com/kiagnose/kiagnose/kiagnose/types"
+)
+
+const checkupContainerName = "realtime-checkup"
+
+func serviceAccountName(name string) string {
+	return name + "-sa"
+}
+
+func configMapAccessRoleName(name string) string {
+	return name + "-configmap-access"
+}
+
+func checkerRoleName(name string) string {
+	return name + "-checker"
+}
+
+// performanceProfileReaderName is prefixed by the namespace, as the ClusterRole and ClusterRoleBinding are cluster scoped.
+func performanceProfileReaderName(namespace, name string) string {
+	return namespace + "-" + name + "-performance-profile-reader"
+}
+
+func configMapName(name string) string {
+	return name + "-config"
+}
+
+func newServiceAccount(name string) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+	}
+}
+
+func newConfigMapAccessRole(name string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"get", "update"},
+			},
+		},
+	}
+}
+
+func newCheckerRole(name string) *rbacv1.Role {
+	return &rbacv1.Role{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{"kubevirt.io"},
+				Resources: []string{"virtualmachineinstances"},
+				Verbs:     []string{"create", "get", "watch", "delete"},
+			},
+			{
+				APIGroups: []string{"subresources.kubevirt.io"},
+				Resources: []string{"virtualmachineinstances/console"},
+				Verbs:     []string{"get"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"configmaps"},
+				Verbs:     []string{"create", "get", "update", "delete"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"create", "get", "list", "delete"},
+			},
+		},
+	}
+}
+
+// newPerformanceProfileReaderClusterRole allows discovering the performance profile of the VM under test node.
+func newPerformanceProfileReaderClusterRole(name string) *rbacv1.ClusterRole {
+	return &rbacv1.ClusterRole{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Rules: []rbacv1.PolicyRule{
+			{
+				APIGroups: []string{""},
+				Resources: []string{"nodes"},
+				Verbs:     []string{"get"},
+			},
+			{
+				APIGroups: []string{"performance.openshift.io"},
+				Resources: []string{"performanceprofiles"},
+				Verbs:     []string{"list"},
+			},
+			{
+				APIGroups: []string{"tuned.openshift.io"},
+				Resources: []string{"profiles"},
+				Verbs:     []string{"get"},
+			},
+		},
+	}
+}
+
+func newClusterRoleBinding(name, serviceAccountNamespace, serviceAccountName, clusterRoleName string) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      serviceAccountName,
+				Namespace: serviceAccountNamespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     clusterRoleName,
+		},
+	}
+}
+
+func newRoleBinding(name, serviceAccountName, roleName string) *rbacv1.RoleBinding {
+	return &rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind: rbacv1.ServiceAccountKind,
+				Name: serviceAccountName,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "Role",
+			Name:     roleName,
+		},
+	}
+}
+
+func newConfigMap(name string, cfg Config) *corev1.ConfigMap {
+	data := map[string]string{
+		types.TimeoutKey: cfg.Timeout.String(),
+	}
+
+	for paramName, paramValue := range cfg.Params {
+		data[types.ParamNameKeyPrefix+paramName] = paramValue
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Data: data,
+	}
+}
+
+func newCheckupJob(name, namespace, serviceAccountName, configMapName, image string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: pointer(int32(0)),
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					ServiceAccountName: serviceAccountName,
+					RestartPolicy:      corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:            checkupContainerName,
+							Image:           image,
+							ImagePullPolicy: corev1.PullAlways,
+							SecurityContext: newSecurityContext(),
+							Env: []corev1.EnvVar{
+								{
+									Name:  kconfig.ConfigMapNamespaceEnvVarName,
+									Value: namespace,
+								},
+								{
+									Name:  kconfig.ConfigMapNameEnvVarName,
+									Value: configMapName,
+								},
+								{
+									Name: kconfig.PodUIDEnvVarName,
+									ValueFrom: &corev1.EnvVarSource{
+										FieldRef: &corev1.ObjectFieldSelector{
+											FieldPath: "metadata.uid",
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newSecurityContext() *corev1.SecurityContext {
+	return &corev1.SecurityContext{
+		AllowPrivilegeEscalation: pointer(false),
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+		RunAsNonRoot: pointer(true),
+		SeccompProfile: &corev1.SeccompProfile{
+			Type: corev1.SeccompProfileTypeRuntimeDefault,
+		},
+	}
+}
+
+func pointer[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,304 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kiagnose/kiagnose/kiagnose/types"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/internal/config"
+)
+
+const (
+	DefaultName  = "realtime-checkup"
+	DefaultImage = "quay.io/kiagnose/kubevirt-realtime-checkup:main"
+)
+
+const (
+	VMUnderTestTargetNodeNameParamName     = config.VMUnderTestTargetNodeNameParamName
+	VMUnderTestContainerDiskImageParamName = config.VMUnderTestContainerDiskImageParamName
+	OslatDurationParamName                 = config.OslatDurationParamName
+	OslatLatencyThresholdParamName         = config.OslatLatencyThresholdParamName
+	PerformanceProfileDiscoveryParamName   = config.PerformanceProfileDiscoveryParamName
+)
+
+const pollInterval = time.Second
+
+type Config struct {
+	// Name is used as the checkup Job's name, and as a prefix for the rest of the created objects.
+	Name      string
+	Namespace string
+	Image     string
+	Timeout   time.Duration
+	Params    map[string]string
+}
+
+type plugin struct {
+	client kubernetes.Interface
+	cfg    Config
+	out    io.Writer
+}
+
+// Run provisions the checkup's permissions, ConfigMap and Job, streams the checkup logs to `out`
+// and prints the checkup results once it completes.
+// The permissions are removed when Run returns, the ConfigMap and Job are left for inspection.
+func Run(ctx context.Context, client kubernetes.Interface, cfg Config, out io.Writer) (runErr error) {
+	p := plugin{client: client, cfg: cfg, out: out}
+
+	cleanupFuncs, err := p.setupPermissions(ctx)
+	defer func() {
+		if cleanupErr := p.cleanup(ctx, cleanupFuncs); cleanupErr != nil {
+			runErr = errors.Join(runErr, cleanupErr)
+		}
+	}()
+	if err != nil {
+		return err
+	}
+
+	if _, err = p.client.CoreV1().ConfigMaps(cfg.Namespace).Create(
+		ctx, newConfigMap(configMapName(cfg.Name), cfg), metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	job := newCheckupJob(cfg.Name, cfg.Namespace, serviceAccountName(cfg.Name), configMapName(cfg.Name), cfg.Image)
+	if _, err = p.client.BatchV1().Jobs(cfg.Namespace).Create(ctx, job, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "Checkup Job %q started\n", objectFullName(cfg.Namespace, cfg.Name))
+
+	// Allow the checkup pod to be scheduled and its image to be pulled, on top of the checkup's own timeout.
+	const jobStartGrace = 5 * time.Minute
+	waitCtx, cancel := context.WithTimeout(ctx, cfg.Timeout+jobStartGrace)
+	defer cancel()
+
+	if err = p.streamLogs(waitCtx); err != nil {
+		fmt.Fprintf(out, "Failed to stream checkup logs: %v\n", err)
+	}
+
+	if err = p.waitForJobCompletion(waitCtx); err != nil {
+		return err
+	}
+
+	configMap, err := p.client.CoreV1().ConfigMaps(cfg.Namespace).Get(ctx, configMapName(cfg.Name), metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	printResults(out, configMap.Data)
+
+	if configMap.Data[types.SucceededKey] != "true" {
+		return fmt.Errorf("checkup failed: %s", configMap.Data[types.FailureReasonKey])
+	}
+
+	return nil
+}
+
+func (p plugin) setupPermissions(ctx context.Context) ([]func(context.Context) error, error) {
+	var cleanupFuncs []func(context.Context) error
+	namespace := p.cfg.Namespace
+	saName := serviceAccountName(p.cfg.Name)
+
+	if _, err := p.client.CoreV1().ServiceAccounts(namespace).Create(
+		ctx, newServiceAccount(saName), metav1.CreateOptions{}); err != nil {
+		return cleanupFuncs, err
+	}
+	cleanupFuncs = append(cleanupFuncs, func(ctx context.Context) error {
+		return p.client.CoreV1().ServiceAccounts(namespace).Delete(ctx, saName, metav1.DeleteOptions{})
+	})
+
+	for _, role := range []*rbacv1.Role{
+		newConfigMapAccessRole(configMapAccessRoleName(p.cfg.Name)),
+		newCheckerRole(checkerRoleName(p.cfg.Name)),
+	} {
+		roleName := role.Name
+		if _, err := p.client.RbacV1().Roles(namespace).Create(ctx, role, metav1.CreateOptions{}); err != nil {
+			return cleanupFuncs, err
+		}
+		cleanupFuncs = append(cleanupFuncs, func(ctx context.Context) error {
+			return p.client.RbacV1().Roles(namespace).Delete(ctx, roleName, metav1.DeleteOptions{})
+		})
+
+		if _, err := p.client.RbacV1().RoleBindings(namespace).Create(
+			ctx, newRoleBinding(roleName, saName, roleName), metav1.CreateOptions{}); err != nil {
+			return cleanupFuncs, err
+		}
+		cleanupFuncs = append(cleanupFuncs, func(ctx context.Context) error {
+			return p.client.RbacV1().RoleBindings(namespace).Delete(ctx, roleName, metav1.DeleteOptions{})
+		})
+	}
+
+	if !p.performanceProfileDiscovery() {
+		return cleanupFuncs, nil
+	}
+
+	clusterRoleName := performanceProfileReaderName(namespace, p.cfg.Name)
+	if _, err := p.client.RbacV1().ClusterRoles().Create(
+		ctx, newPerformanceProfileReaderClusterRole(clusterRoleName), metav1.CreateOptions{}); err != nil {
+		return cleanupFuncs, err
+	}
+	cleanupFuncs = append(cleanupFuncs, func(ctx context.Context) error {
+		return p.client.RbacV1().ClusterRoles().Delete(ctx, clusterRoleName, metav1.DeleteOptions{})
+	})
+
+	if _, err := p.client.RbacV1().ClusterRoleBindings().Create(
+		ctx, newClusterRoleBinding(clusterRoleName, namespace, saName, clusterRoleName), metav1.CreateOptions{}); err != nil {
+		return cleanupFuncs, err
+	}
+	cleanupFuncs = append(cleanupFuncs, func(ctx context.Context) error {
+		return p.client.RbacV1().ClusterRoleBindings().Delete(ctx, clusterRoleName, metav1.DeleteOptions{})
+	})
+
+	return cleanupFuncs, nil
+}
+
+// performanceProfileDiscovery tells whether the checkup discovers the performance profile,
+// reading cluster scoped objects. An invalid value is left for the checkup to report.
+func (p plugin) performanceProfileDiscovery() bool {
+	performanceProfileDiscovery, err := strconv.ParseBool(p.cfg.Params[config.PerformanceProfileDiscoveryParamName])
+	return err == nil && performanceProfileDiscovery
+}
+
+func (p plugin) cleanup(ctx context.Context, cleanupFuncs []func(context.Context) error) error {
+	// Clean up even when the given context was canceled (e.g. by the user interrupting the plugin).
+	const cleanupTimeout = time.Minute
+	cleanupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cleanupTimeout)
+	defer cancel()
+
+	var errs []error
+	for i := len(cleanupFuncs) - 1; i >= 0; i-- {
+		if err := cleanupFuncs[i](cleanupCtx); err != nil && !k8serrors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p plugin) streamLogs(ctx context.Context) error {
+	podName, err := p.waitForJobPodToStart(ctx)
+	if err != nil {
+		return err
+	}
+
+	logsStream, err := p.client.CoreV1().Pods(p.cfg.Namespace).GetLogs(podName, &corev1.PodLogOptions{
+		Container: checkupContainerName,
+		Follow:    true,
+	}).Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer logsStream.Close()
+
+	_, err = io.Copy(p.out, logsStream)
+	return err
+}
+
+func (p plugin) waitForJobPodToStart(ctx context.Context) (string, error) {
+	var podName string
+
+	conditionFn := func(ctx context.Context) (bool, error) {
+		pods, err := p.client.CoreV1().Pods(p.cfg.Namespace).List(ctx, metav1.ListOptions{
+			LabelSelector: "job-name=" + p.cfg.Name,
+		})
+		if err != nil {
+			return false, err
+		}
+
+		for i := range pods.Items {
+			if pods.Items[i].Status.Phase != corev1.PodPending && pods.Items[i].Status.Phase != "" {
+				podName = pods.Items[i].Name
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
+		return "", fmt.Errorf("failed to wait for the checkup pod to start: %w", err)
+	}
+
+	return podName, nil
+}
+
+func (p plugin) waitForJobCompletion(ctx context.Context) error {
+	conditionFn := func(ctx context.Context) (bool, error) {
+		job, err := p.client.BatchV1().Jobs(p.cfg.Namespace).Get(ctx, p.cfg.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+
+		for _, condition := range job.Status.Conditions {
+			if (condition.Type == batchv1.JobComplete || condition.Type == batchv1.JobFailed) &&
+				condition.Status == corev1.ConditionTrue {
+				return true, nil
+			}
+		}
+
+		return false, nil
+	}
+	if err := wait.PollImmediateUntilWithContext(ctx, pollInterval, conditionFn); err != nil {
+		return fmt.Errorf("failed to wait for checkup Job %q to complete: %w", objectFullName(p.cfg.Namespace, p.cfg.Name), err)
+	}
+
+	return nil
+}
+
+func printResults(out io.Writer, data map[string]string) {
+	var statusKeys []string
+	for key := range data {
+		if strings.HasPrefix(key, "status.") {
+			statusKeys = append(statusKeys, key)
+		}
+	}
+	sort.Strings(statusKeys)
+
+	fmt.Fprintln(out, "Checkup results:")
+	const (
+		minWidth = 0
+		tabWidth = 8
+		padding  = 2
+	)
+	w := tabwriter.NewWriter(out, minWidth, tabWidth, padding, ' ', 0)
+	for _, key := range statusKeys {
+		fmt.Fprintf(w, "  %s\t%s\n", key, data[key])
+	}
+	w.Flush()
+}
+
+func objectFullName(namespace, name string) string {
+	return fmt.Sprintf("%s/%s", namespace, name)
+}
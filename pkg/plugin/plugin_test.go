@@ -0,0 +1,225 @@
+/*
+ * This file is part of the kiagnose project
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * Copyright 2023 Red Hat, Inc.
+ *
+ */
+
+package plugin_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/kiagnose/kubevirt-realtime-checkup/pkg/plugin"
+)
+
+const (
+	testNamespace   = "target-ns"
+	testName        = "rt-checkup"
+	testVMImage     = "quay.io/kiagnose/kubevirt-realtime-checkup-vm:main"
+	testFailureText = "oslat Max Latency measured 60µs exceeded the given threshold 40µs"
+)
+
+func TestRunShouldSucceed(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	jobCompletion := completeCheckupJob(fakeClient, batchv1.JobComplete, map[string]string{
+		"status.succeeded":                          "true",
+		"status.failureReason":                      "",
+		"status.result.oslatMaxLatencyMicroSeconds": "12",
+	})
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, plugin.Run(context.Background(), fakeClient, newTestConfig(), out))
+	assert.NoError(t, <-jobCompletion)
+
+	configMap, err := fakeClient.CoreV1().ConfigMaps(testNamespace).Get(context.Background(), testName+"-config", metav1.GetOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, "30m0s", configMap.Data["spec.timeout"])
+	assert.Equal(t, testVMImage, configMap.Data["spec.param."+plugin.VMUnderTestContainerDiskImageParamName])
+
+	assert.Contains(t, out.String(), "fake logs")
+	assert.Regexp(t, `status\.result\.oslatMaxLatencyMicroSeconds\s+12`, out.String())
+
+	assertPermissionsCleanedUp(t, fakeClient)
+}
+
+func TestRunShouldFailWhenCheckupFails(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	jobCompletion := completeCheckupJob(fakeClient, batchv1.JobFailed, map[string]string{
+		"status.succeeded":     "false",
+		"status.failureReason": testFailureText,
+	})
+
+	out := &bytes.Buffer{}
+	assert.ErrorContains(t, plugin.Run(context.Background(), fakeClient, newTestConfig(), out), testFailureText)
+	assert.NoError(t, <-jobCompletion)
+	assert.Contains(t, out.String(), testFailureText)
+
+	assertPermissionsCleanedUp(t, fakeClient)
+}
+
+func TestRunShouldGrantThePerformanceProfileDiscoveryPermissions(t *testing.T) {
+	fakeClient := fake.NewSimpleClientset()
+	jobCompletion := completeCheckupJob(fakeClient, batchv1.JobComplete, map[string]string{
+		"status.succeeded":     "true",
+		"status.failureReason": "",
+	})
+
+	cfg := newTestConfig()
+	cfg.Params[plugin.VMUnderTestTargetNodeNameParamName] = "worker-0"
+	cfg.Params[plugin.PerformanceProfileDiscoveryParamName] = "true"
+	assert.NoError(t, plugin.Run(context.Background(), fakeClient, cfg, &bytes.Buffer{}))
+	assert.NoError(t, <-jobCompletion)
+
+	const clusterRoleName = testNamespace + "-" + testName + "-performance-profile-reader"
+	var clusterRole *rbacv1.ClusterRole
+	var clusterRoleBinding *rbacv1.ClusterRoleBinding
+	for _, action := range fakeClient.Actions() {
+		if createAction, ok := action.(k8stesting.CreateAction); ok {
+			switch object := createAction.GetObject().(type) {
+			case *rbacv1.ClusterRole:
+				clusterRole = object
+			case *rbacv1.ClusterRoleBinding:
+				clusterRoleBinding = object
+			}
+		}
+	}
+
+	assert.NotNil(t, clusterRole)
+	assert.Equal(t, clusterRoleName, clusterRole.Name)
+	assert.Equal(t, []rbacv1.PolicyRule{
+		{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"get"}},
+		{APIGroups: []string{"performance.openshift.io"}, Resources: []string{"performanceprofiles"}, Verbs: []string{"list"}},
+		{APIGroups: []string{"tuned.openshift.io"}, Resources: []string{"profiles"}, Verbs: []string{"get"}},
+	}, clusterRole.Rules)
+
+	assert.NotNil(t, clusterRoleBinding)
+	assert.Equal(t, []rbacv1.Subject{
+		{Kind: rbacv1.ServiceAccountKind, Name: testName + "-sa", Namespace: testNamespace},
+	}, clusterRoleBinding.Subjects)
+	assert.Equal(t, rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: clusterRoleName}, clusterRoleBinding.RoleRef)
+
+	assertPermissionsCleanedUp(t, fakeClient)
+}
+
+func TestRunShouldCleanupPermissionsWhenProvisioningFails(t *testing.T) {
+	existingConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: testName + "-config", Namespace: testNamespace},
+	}
+	fakeClient := fake.NewSimpleClientset(existingConfigMap)
+
+	assert.ErrorContains(t, plugin.Run(context.Background(), fakeClient, newTestConfig(), &bytes.Buffer{}), "already exists")
+
+	assertPermissionsCleanedUp(t, fakeClient)
+}
+
+func newTestConfig() plugin.Config {
+	return plugin.Config{
+		Name:      testName,
+		Namespace: testNamespace,
+		Image:     plugin.DefaultImage,
+		Timeout:   30 * time.Minute,
+		Params: map[string]string{
+			plugin.VMUnderTestContainerDiskImageParamName: testVMImage,
+		},
+	}
+}
+
+// completeCheckupJob plays the part of the Job controller and the checkup in the background:
+// once the checkup Job is created, it starts the Job's pod, reports the given results and completes the Job.
+// The returned channel receives the outcome once done.
+func completeCheckupJob(fakeClient *fake.Clientset, conditionType batchv1.JobConditionType, results map[string]string) <-chan error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- doCompleteCheckupJob(fakeClient, conditionType, results)
+	}()
+	return errCh
+}
+
+func doCompleteCheckupJob(fakeClient *fake.Clientset, conditionType batchv1.JobConditionType, results map[string]string) error {
+	ctx := context.Background()
+
+	var job *batchv1.Job
+	if err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		var err error
+		job, err = fakeClient.BatchV1().Jobs(testNamespace).Get(ctx, testName, metav1.GetOptions{})
+		return err == nil, nil
+	}); err != nil {
+		return fmt.Errorf("the checkup Job was not created: %w", err)
+	}
+
+	if _, err := fakeClient.CoreV1().Pods(testNamespace).Create(ctx, &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   testName + "-abcde",
+			Labels: map[string]string{"job-name": testName},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}, metav1.CreateOptions{}); err != nil {
+		return err
+	}
+
+	configMap, err := fakeClient.CoreV1().ConfigMaps(testNamespace).Get(ctx, testName+"-config", metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	for key, value := range results {
+		configMap.Data[key] = value
+	}
+	if _, err = fakeClient.CoreV1().ConfigMaps(testNamespace).Update(ctx, configMap, metav1.UpdateOptions{}); err != nil {
+		return err
+	}
+
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{Type: conditionType, Status: corev1.ConditionTrue})
+	_, err = fakeClient.BatchV1().Jobs(testNamespace).UpdateStatus(ctx, job, metav1.UpdateOptions{})
+	return err
+}
+
+func assertPermissionsCleanedUp(t *testing.T, fakeClient *fake.Clientset) {
+	ctx := context.Background()
+
+	serviceAccounts, err := fakeClient.CoreV1().ServiceAccounts(testNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, serviceAccounts.Items)
+
+	roles, err := fakeClient.RbacV1().Roles(testNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, roles.Items)
+
+	roleBindings, err := fakeClient.RbacV1().RoleBindings(testNamespace).List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, roleBindings.Items)
+
+	clusterRoles, err := fakeClient.RbacV1().ClusterRoles().List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, clusterRoles.Items)
+
+	clusterRoleBindings, err := fakeClient.RbacV1().ClusterRoleBindings().List(ctx, metav1.ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, clusterRoleBindings.Items)
+}
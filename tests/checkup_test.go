@@ -92,7 +92,7 @@ var _ = Describe("Checkup execution", func() {
 			}
 
 			return jobConditions
-		}, 15*time.Minute, 5*time.Second).Should(
+		}, 35*time.Minute, 5*time.Second).Should(
 			ContainElement(MatchFields(IgnoreExtras, Fields{
 				"Type":   Equal(batchv1.JobComplete),
 				"Status": Equal(corev1.ConditionTrue),
@@ -252,7 +252,7 @@ func newKubeVirtRealTimeCheckerRole() *rbacv1.Role {
 			{
 				APIGroups: []string{"kubevirt.io"},
 				Resources: []string{"virtualmachineinstances"},
-				Verbs:     []string{"create", "get", "delete"},
+				Verbs:     []string{"create", "get", "watch", "delete"},
 			},
 			{
 				APIGroups: []string{"subresources.kubevirt.io"},
@@ -262,7 +262,12 @@ func newKubeVirtRealTimeCheckerRole() *rbacv1.Role {
 			{
 				APIGroups: []string{""},
 				Resources: []string{"configmaps"},
-				Verbs:     []string{"create", "delete"},
+				Verbs:     []string{"create", "get", "update", "delete"},
+			},
+			{
+				APIGroups: []string{""},
+				Resources: []string{"pods"},
+				Verbs:     []string{"create", "get", "list", "delete"},
 			},
 		},
 	}
@@ -289,7 +294,7 @@ func newRoleBinding(name, serviceAccountName, roleName string) *rbacv1.RoleBindi
 
 func newConfigMap() *corev1.ConfigMap {
 	testConfig := map[string]string{
-		"spec.timeout":                                 "15m",
+		"spec.timeout":                                 "30m",
 		"spec.param.vmUnderTestTargetNodeName":         "",
 		"spec.param.oslatDuration":                     "10m",
 		"spec.param.oslatLatencyThresholdMicroSeconds": "45",